@@ -0,0 +1,198 @@
+package redis
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// InvalidateChannel is where the server publishes invalidation messages for
+// client-side caching in REDIRECT mode.
+const invalidateChannel = "__redis__:invalidate"
+
+// Cache serves GET from local memory, with client-side caching as described at
+// <https://redis.io/topics/client-side-caching>. The Client connection executes
+// CLIENT TRACKING with REDIRECT to a Listener, which receives the invalidation
+// messages of the server. Reads do not use the cache while the Listener is
+// offline, and all entries are dropped on each reconnect of either connection,
+// as the server does not track for closed connections. Multiple goroutines may
+// invoke methods on a Cache simultaneously.
+type Cache struct {
+	// Client executes commands with tracking enabled. Values changed with
+	// this Client invalidate like changes from any other connection.
+	Client *Client
+
+	listener *Listener
+
+	// client ID of the listener, or zero when offline
+	redirect int64
+
+	mutex sync.Mutex
+	// values with their tracking state
+	entries map[string]*cacheEntry
+	// maximum number of entries
+	entryMax int
+}
+
+type cacheEntry struct {
+	value []byte
+	// placeholder until the value is in
+	ready bool
+}
+
+// NewCache launches a Client with client-side caching. The options apply like
+// NewClientWithOptions, with the exception of ReplicaAddr, which is not
+// supported. The Listener for invalidation messages connects with the address,
+// the password and the timeouts only. EntryMax limits the number of keys in
+// memory, with a random eviction once full. Zero defaults to 10000.
+func NewCache(addr string, commandTimeout, dialTimeout time.Duration, o ClientOptions, entryMax int) *Cache {
+	if entryMax <= 0 {
+		entryMax = 10000
+	}
+	o.ReplicaAddr = ""
+	cache := &Cache{
+		entries:  make(map[string]*cacheEntry),
+		entryMax: entryMax,
+	}
+
+	cache.listener = newListener(ListenerConfig{
+		Func:           cache.receive,
+		Addr:           addr,
+		DialTimeout:    dialTimeout,
+		Password:       o.Password,
+		CommandTimeout: commandTimeout,
+	})
+	cache.listener.onConnect = cache.listenerConnect
+	cache.listener.onLoss = cache.listenerLoss
+	cache.listener.SUBSCRIBE(invalidateChannel)
+
+	cache.Client = newClient(addr, commandTimeout, dialTimeout, o)
+	cache.Client.tracking = cache
+
+	go cache.listener.connectLoop()
+	cache.Client.launch()
+	return cache
+}
+
+// Close terminates both the Client and the Listener.
+func (cache *Cache) Close() error {
+	cache.listener.Close()
+	err := cache.Client.Close()
+	cache.flush()
+	return err
+}
+
+// GET executes <https://redis.io/commands/get>, unless the value is in the
+// cache already. The return is nil if key does not exist. Absence is cached
+// too. Callers may modify the value returned.
+func (cache *Cache) GET(key string) (value []byte, err error) {
+	cache.mutex.Lock()
+	e, ok := cache.entries[key]
+	if ok && e.ready {
+		value = cloneBytes(e.value)
+		cache.mutex.Unlock()
+		return value, nil
+	}
+	if !ok && atomic.LoadInt64(&cache.redirect) != 0 {
+		if len(cache.entries) >= cache.entryMax {
+			for k := range cache.entries {
+				delete(cache.entries, k)
+				break
+			}
+		}
+		// Invalidation may arrive before the response.
+		// The placeholder detects such with its removal.
+		e = new(cacheEntry)
+		cache.entries[key] = e
+	}
+	cache.mutex.Unlock()
+
+	value, err = cache.Client.GET(key)
+	if err != nil || e == nil {
+		return value, err
+	}
+
+	cache.mutex.Lock()
+	if cache.entries[key] == e && !e.ready {
+		e.value = cloneBytes(value)
+		e.ready = true
+	}
+	cache.mutex.Unlock()
+	return value, nil
+}
+
+// Len returns the number of keys in memory.
+func (cache *Cache) Len() int {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	return len(cache.entries)
+}
+
+// Flush drops all entries.
+func (cache *Cache) flush() {
+	cache.mutex.Lock()
+	cache.entries = make(map[string]*cacheEntry)
+	cache.mutex.Unlock()
+}
+
+// Receive is the Listener callback.
+func (cache *Cache) receive(channel string, message []byte, err error) {
+	if err != nil || channel != invalidateChannel {
+		return
+	}
+	if message == nil {
+		// FLUSHALL or FLUSHDB
+		cache.flush()
+		return
+	}
+	cache.mutex.Lock()
+	delete(cache.entries, string(message))
+	cache.mutex.Unlock()
+}
+
+// ListenerConnect resolves the client ID for the REDIRECT of tracking.
+func (cache *Cache) listenerConnect(conn net.Conn, reader *bufio.Reader) error {
+	req := newRequest("*2\r\n$6\r\nCLIENT\r\n$2\r\nID\r\n")
+	defer req.free()
+
+	if timeout := cache.listener.CommandTimeout; timeout != 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+		defer conn.SetDeadline(time.Time{})
+	}
+	_, err := conn.Write(req.buf)
+	var id int64
+	if err == nil {
+		id, err = decodeInteger(reader)
+	}
+	if err == nil && id == 0 {
+		err = errors.New("zero ID")
+	}
+	if err != nil {
+		return fmt.Errorf("redis: CLIENT ID with %w", err)
+	}
+
+	atomic.StoreInt64(&cache.redirect, id)
+	// reconnect with the new REDIRECT on the next command
+	atomic.StoreInt32(&cache.Client.renew, 1)
+	cache.flush()
+	return nil
+}
+
+// ListenerLoss disables the cache, as invalidation messages are missed.
+func (cache *Cache) listenerLoss() {
+	// reconnects of the Client go without tracking, as the
+	// server rejects a REDIRECT to an unknown client ID
+	atomic.StoreInt64(&cache.redirect, 0)
+	cache.flush()
+}
+
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append(make([]byte, 0, len(b)), b...)
+}
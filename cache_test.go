@@ -0,0 +1,81 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test")
+
+	cache := NewCache(testClient.Addr, time.Second, 0, ClientOptions{}, 0)
+	defer cache.Close()
+	// testClient may have another database selected
+	c := NewClient(testClient.Addr, time.Second, 0)
+	defer c.Close()
+
+	// await tracking
+	for i := 0; ; i++ {
+		if _, err := cache.GET(key); err != nil {
+			t.Fatal("GET error:", err)
+		}
+		if cache.Len() != 0 {
+			break
+		}
+		if i > 100 {
+			t.Fatal("GET not cached")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := c.SET(key, []byte("v1")); err != nil {
+		t.Fatal("SET error:", err)
+	}
+	// await invalidation
+	for i := 0; cache.Len() != 0; i++ {
+		if i > 100 {
+			t.Fatal("cache not invalidated on SET")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if v, err := cache.GET(key); err != nil {
+		t.Fatal("GET error:", err)
+	} else if string(v) != "v1" {
+		t.Errorf("got %q, want v1", v)
+	}
+	if n := cache.Len(); n != 1 {
+		t.Errorf("got %d cache entries, want 1", n)
+	}
+
+	// served from memory
+	v, err := cache.GET(key)
+	if err != nil {
+		t.Fatal("GET error:", err)
+	}
+	if string(v) != "v1" {
+		t.Errorf("got %q, want v1", v)
+	}
+	v[0] = 'x'
+	if v, _ := cache.GET(key); string(v) != "v1" {
+		t.Errorf("got %q after modification of return, want v1", v)
+	}
+
+	// change with own client
+	if err := cache.Client.SET(key, []byte("v2")); err != nil {
+		t.Fatal("SET error:", err)
+	}
+	for i := 0; ; i++ {
+		v, err := cache.GET(key)
+		if err != nil {
+			t.Fatal("GET error:", err)
+		}
+		if string(v) == "v2" {
+			break
+		}
+		if i > 100 {
+			t.Fatalf("got %q, want v2", v)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
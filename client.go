@@ -2,9 +2,14 @@ package redis
 
 import (
 	"bufio"
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
 	"net"
+	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -21,32 +26,96 @@ const (
 	queueSizeUnix = 512
 )
 
-// ErrConnLost signals connection loss to response queue.
-var errConnLost = errors.New("redis: connection lost while awaiting response")
+// ErrConnLost signals connection loss to response queue. The command may or
+// may not have been executed. The same applies to a net.Error with Timeout().
+var ErrConnLost = errors.New("redis: connection lost while awaiting response")
+
+// ErrOffline signals command submission without a network connection. Such
+// commands were not sent, and a retry is safe thus. Use errors.Is to test,
+// as the actual error also wraps the cause of the connection failure.
+var ErrOffline = errors.New("redis: offline")
+
+// ErrQueueFull signals command submission with ClientOptions NoQueueBlock
+// while QueueSize commands await a response already. Such commands were not
+// sent, and a retry is safe thus.
+var ErrQueueFull = errors.New("redis: response queue full")
+
+// OfflineError is an ErrOffline with its cause.
+type offlineError struct{ cause error }
+
+// Error honors the error interface.
+func (e offlineError) Error() string {
+	return "redis: offline due " + e.cause.Error()
+}
+
+// Unwrap honors the errors package conventions.
+func (e offlineError) Unwrap() error { return e.cause }
+
+// Is honors the errors package conventions.
+func (e offlineError) Is(target error) bool { return target == ErrOffline }
 
 // Client manages a connection to a Redis node until Close. Broken connection
 // states cause automated reconnects.
 //
 // Multiple goroutines may invoke methods on a Client simultaneously. Command
 // invocation applies <https://redis.io/topics/pipelining> on concurrency.
+//
+// The connection of a Client never enters subscribe mode, as push messages
+// can't share the pipeline with command responses. See Listener for
+// <https://redis.io/topics/pubsub>, and see Monitor for MONITOR.
 type Client struct {
-	// Normalized node address in use. This field is read-only.
+	// Normalized node address in use. This field is read-only. The
+	// address is empty with NewSentinelClient, as it may change. See
+	// StateChange for the address in use.
 	Addr string
 
 	noCopy noCopy
 
+	// state is shared with the views from WithContext
+	*clientState
+
+	// optional cancellation from WithContext
+	ctx context.Context
+}
+
+// ClientState is the connection management of a Client.
+type clientState struct {
 	// sticky AUTH(entication)
 	password atomic.Value
 
 	// sticky database SELECT
 	db int64
 
+	// sticky CLIENT SETNAME
+	name atomic.Value
+
 	// optional execution expiry
 	commandTimeout time.Duration
 
 	// network establishment expiry
 	dialTimeout time.Duration
 
+	// connection tuning
+	options ClientOptions
+
+	// optional replica for read-only commands
+	replica *Client
+
+	// optional master discovery on connect
+	sentinel *sentinelDiscovery
+
+	// idle connections for Watch
+	dedicated chan *Client
+
+	// optional client-side caching
+	tracking *Cache
+
+	// Reconnect on the next command submission when not zero.
+	renew int32
+
+	// READONLY mode on connect
+	readOnly bool
+
 	// The connection semaphore is used as a write lock.
 	connSem chan *redisConn
 
@@ -58,6 +127,35 @@ type Client struct {
 	// The read routine stops on receive: no more readQueue receives
 	// nor network use. The idle state is not set/restored.
 	readInterrupt chan struct{}
+
+	// Command submission may hand over requests to the owner of the
+	// write lock [connSem], which sends them along with its own in one
+	// write [write coalescing].
+	pending chan *request
+
+	// Write coalescing buffers are guarded by the write lock [connSem].
+	batch     []*request
+	batchBufs net.Buffers
+
+	// Command submission counts in write lock [connSem].
+	sendCount uint32
+
+	// Mirrors the idle state of the read lock with zero, for Pool.
+	busy int32
+
+	// Closed on Close or CloseWait.
+	closed chan struct{}
+
+	keepAlive sync.Once
+
+	// connection state notification
+	stateMutex sync.Mutex
+	state      StateChange
+	stateSubs  []chan<- StateChange
+
+	// RESP3 push message callbacks per kind
+	pushMutex    sync.Mutex
+	pushHandlers map[string]func(Reply)
 }
 
 // NewClient launches a managed connection to a node (address).
@@ -68,6 +166,8 @@ type Client struct {
 //
 // A command timeout limits the execution duration when nonzero. Expiry causes a
 // reconnect (to prevent stale connections) and a net.Error with Timeout() true.
+// The timeout applies to the write of a command, and to the read of its response
+// once all preceding responses are in.
 //
 // The dial timeout limits the duration for network connection establishment.
 // Expiry causes an abort + retry. Zero defaults to one second. Any command
@@ -75,28 +175,234 @@ type Client struct {
 // then command submission receives the error of the last attempt, until the
 // connection restores.
 func NewClient(addr string, commandTimeout, dialTimeout time.Duration) *Client {
+	return NewClientWithOptions(addr, commandTimeout, dialTimeout, ClientOptions{})
+}
+
+// ClientOptions configure a Client beyond the address and the timeouts of
+// NewClient, connection tuning, AUTH, SELECT and TLS included. The zero value
+// has the defaults of NewClient, and so does the zero value of any field added
+// in the future. TCP settings have no effect on Unix domain sockets.
+type ClientOptions struct {
+	// NoDelay disables Nagle's algorithm, such that small requests are sent
+	// without delay, at the cost of more packets on the wire.
+	NoDelay bool
+
+	// KeepAlive is the period between TCP keep-alive probes. Zero leaves
+	// the defaults of net.Dialer in place. Negative values disable probes.
+	KeepAlive time.Duration
+
+	// Linger is the SO_LINGER timeout in seconds. See the net.TCPConn
+	// SetLinger method for details. Zero discards any unsent data on close.
+	// Negative values leave the operating system defaults in place.
+	Linger int
+
+	// ReadBufferSize is the number of bytes buffered from the network.
+	// Larger buffers reduce system calls on large values. Zero defaults
+	// to the conservative MSS of 1208 bytes.
+	ReadBufferSize int
+
+	// MaxConnLifetime causes a reconnect on the first command submission
+	// after the connection reached the age, when positive. The age counts
+	// from the first availability to commands. The command holds the write
+	// lock [connSem] until any responses in progress are read, as it awaits
+	// the read lock from the read routine, unless idle already. Then, the
+	// connection is closed, and the command continues on a new connection.
+	MaxConnLifetime time.Duration
+
+	// QueueSize limits the number of commands awaiting a response. Command
+	// submission blocks on a full queue, unless NoQueueBlock. The block is
+	// bounded by the command timeout, if any, as expiry on the response in
+	// line causes a reconnect, which discards the queue. Zero defaults to
+	// 128 for TCP, and 512 for Unix domain sockets.
+	QueueSize int
+
+	// NoQueueBlock makes command submission fail with ErrQueueFull, rather
+	// than block, when QueueSize commands await a response already. The
+	// check applies before the write, i.e., such commands are not executed.
+	NoQueueBlock bool
+
+	// ChunkSize limits the number of keys per request for MGET, MSET, DEL
+	// and UNLINK, when positive. Larger key lists are split into chunks,
+	// which are pipelined in case of MGET, DEL and UNLINK, and submitted in
+	// order in case of MSET. No more than QueueSize chunks are in flight per
+	// invocation. Chunks still compete with other commands for the queue, and
+	// with NoQueueBlock, any chunk may fail with ErrQueueFull, even when
+	// other chunks executed already. Note that chunked execution is not
+	// atomic.
+	ChunkSize int
+
+	// ReadRetries is the number of times a read-only command executes
+	// again after connection loss, including timeouts. A retry awaits the
+	// reconnect. Commands which were not sent, i.e., ErrOffline, do not
+	// retry. The read-only commands are GET, MGET, EXISTS, HGET, HMGET and
+	// LRANGE, in all of their variants. Other commands never retry, as they
+	// may have executed already.
+	ReadRetries int
+
+	// RESP3 negotiates protocol version 3 with HELLO on each connect.
+	// Servers without HELLO, i.e., before Redis 6.0, continue on RESP2.
+	// Commands return the same with either protocol. Do maps the types of
+	// RESP3 to their nearest equivalent, as described by Reply.
+	RESP3 bool
+
+	// ReplicaAddr is an optional node for read-only commands, as served by
+	// the Replica method. The replica connection gets READONLY mode with
+	// the same settings as the primary, including AUTH and SELECT.
+	ReplicaAddr string
+
+	// ReplicaReads sends read-only commands to the replica, as long as it
+	// is online. The read-only commands are the same as with ReadRetries.
+	// Replication is asynchronous, so replica reads may miss the latest
+	// writes. Do always executes on the primary, e.g., for a GET which
+	// must see the latest writes.
+	ReplicaReads bool
+
+	// User is the ACL username for AUTH, which requires Redis 6.0. The
+	// empty string applies to the default user. The option has no effect
+	// without a password.
+	User string
+
+	// Password enables AUTH on each (re)connect, before any command goes
+	// out, when not nil. The AUTH method replaces the password.
+	Password []byte
+
+	// DB is the logical database applied with SELECT on each (re)connect,
+	// when not zero. The SELECT method replaces the database.
+	DB int64
+
+	// TLSConfig enables TLS when not nil. An empty ServerName defaults to
+	// the host of the address, unless InsecureSkipVerify. The handshake
+	// counts towards the dial timeout. TLS has no effect on Unix domain
+	// sockets.
+	TLSConfig *tls.Config
+}
+
+// NewClientWithOptions is like NewClient, with connection tuning on each
+// (re)connect.
+func NewClientWithOptions(addr string, commandTimeout, dialTimeout time.Duration, o ClientOptions) *Client {
+	c := newClient(addr, commandTimeout, dialTimeout, o)
+	c.launch()
+	return c
+}
+
+// NewClientURL is like NewClientWithOptions, with the address, AUTH and SELECT
+// from a URL. The redis scheme has an optional database number as the path,
+// e.g., "redis://:secret@rds1.example.com:6380/2". The rediss scheme enables
+// TLS, with a default configuration when the TLSConfig option is nil. The unix
+// scheme has the socket as the path, and an optional database number as the db
+// query parameter, e.g., "unix:///var/run/redis.sock?db=1". Query parameters
+// dial_timeout and command_timeout, in the format of time.ParseDuration,
+// replace the respective arguments, e.g., "redis://rds1?dial_timeout=5s". A user
+// name without password is not accepted, and neither are other query parameters.
+func NewClientURL(url string, commandTimeout, dialTimeout time.Duration, o ClientOptions) (*Client, error) {
+	u, err := parseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	if u.User != "" {
+		o.User = u.User
+	}
+	if u.Password != nil {
+		o.Password = u.Password
+	}
+	if u.DB != 0 {
+		o.DB = u.DB
+	}
+	if u.TLS && o.TLSConfig == nil {
+		o.TLSConfig = new(tls.Config)
+	}
+	if u.DialTimeout != 0 {
+		dialTimeout = u.DialTimeout
+	}
+	if u.CommandTimeout != 0 {
+		commandTimeout = u.CommandTimeout
+	}
+
+	c := newClient(u.Addr, commandTimeout, dialTimeout, o)
+	c.launch()
+	return c, nil
+}
+
+// Launch starts connection management, for the replica, if any, included.
+// The replica gets the same AUTH and SELECT as the primary.
+func (c *Client) launch() {
+	if c.options.ReplicaAddr != "" {
+		replicaOptions := c.options
+		replicaOptions.ReplicaAddr = ""
+		c.replica = newClient(c.options.ReplicaAddr, c.commandTimeout, c.dialTimeout, replicaOptions)
+		c.replica.readOnly = true
+		c.replica.db = c.db
+		if password := c.password.Load(); password != nil {
+			c.replica.password.Store(password)
+		}
+		go c.replica.connectOrClosed()
+	}
+	go c.connectOrClosed()
+}
+
+func newClient(addr string, commandTimeout, dialTimeout time.Duration, o ClientOptions) *Client {
 	addr = normalizeAddr(addr)
 	if dialTimeout == 0 {
 		dialTimeout = time.Second
 	}
-	queueSize := queueSizeTCP
-	if isUnixAddr(addr) {
+	queueSize := o.QueueSize
+	switch {
+	case queueSize > 0:
+		break
+	case isUnixAddr(addr):
 		queueSize = queueSizeUnix
+	default:
+		queueSize = queueSizeTCP
 	}
 
-	c := &Client{
-		Addr:           addr,
+	c := &Client{Addr: addr, clientState: &clientState{
 		commandTimeout: commandTimeout,
 		dialTimeout:    dialTimeout,
+		options:        o,
 
 		connSem:       make(chan *redisConn, 1),
 		readQueue:     make(chan chan<- *bufio.Reader, queueSize),
 		readInterrupt: make(chan struct{}),
+		pending:       make(chan *request, queueSize),
+		closed:        make(chan struct{}),
+		dedicated:     make(chan *Client, dedicatedIdleMax),
+		db:            o.DB,
+	}}
+	if o.Password != nil {
+		c.password.Store(o.Password)
+	}
+	return c
+}
+
+// Replica returns the Client of the replica node, as configured with the
+// ReplicaAddr option. Use it for read-only commands only. The return is c when
+// the replica is not online, or when no replica was configured. Commands on the
+// replica may still fail on connection loss, i.e., there is no retry. The
+// return has the context of c, if any.
+func (c *Client) Replica() *Client {
+	if c.replica == nil || c.replica.State().State != Online {
+		return c
 	}
+	if c.ctx != nil {
+		return c.replica.WithContext(c.ctx)
+	}
+	return c.replica
+}
 
-	go c.connectOrClosed()
+// WithContext returns a view of c, on which each command applies ctx like
+// DoContext. The view shares the connection and its management with c, so
+// Close on either one closes both. Commands which block on the server, such as
+// BLPOP, are bound by their timeout argument, as the context does not apply to
+// the read of a response. A nil ctx returns a view without cancellation.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	return &Client{Addr: c.Addr, clientState: c.clientState, ctx: ctx}
+}
 
-	return c
+// QueueLen returns the number of commands which await either their write, or
+// their response, excluding the response being read, if any. The value is a
+// snapshot, e.g., for monitoring against QueueSize from ClientOptions.
+func (c *Client) QueueLen() int {
+	return len(c.pending) + len(c.readQueue)
 }
 
 type redisConn struct {
@@ -105,19 +411,31 @@ type redisConn struct {
 
 	// The token is nil when a read routine is using it.
 	idle *bufio.Reader
+
+	// moment of publication to connSem, i.e., the first use
+	since time.Time
 }
 
 // Close terminates the connection establishment.
 // Command submission is stopped with ErrClosed.
 // All pending commands are dealt with on return.
 // Calling Close more than once has no effect.
+// The replica, if any, closes too.
+// See CloseWait for a graceful shutdown.
 func (c *Client) Close() error {
+	if c.replica != nil {
+		c.replica.Close()
+	}
+
 	conn := <-c.connSem
 	if conn.offline == ErrClosed {
 		// redundant invocation
 		c.connSem <- conn // restore
 		return nil
 	}
+	close(c.closed)
+	c.setState(StateChange{State: Closed})
+	c.closeDedicated()
 
 	// stop command submission
 	c.connSem <- &redisConn{offline: ErrClosed}
@@ -131,19 +449,262 @@ func (c *Client) Close() error {
 	return nil
 }
 
+// CloseWait is like Close, but it awaits the responses for commands which were
+// sent already. Command submission is stopped with ErrClosed immediately. When
+// ctx expires before all responses are in, then the network connection is torn
+// down, pending commands receive a connection loss, and the return is the
+// context error.
+//
+// Command submission holds the write lock [connSem] until its request is in the
+// response queue. CloseWait acquires the write lock before it enqueues its own
+// handover. Thus, any command written before CloseWait is part of the drain, no
+// command can follow, and the read routine can't go idle in between.
+func (c *Client) CloseWait(ctx context.Context) error {
+	if c.replica != nil {
+		c.replica.CloseWait(ctx)
+	}
+
+	conn := <-c.connSem
+	if conn.offline == ErrClosed {
+		// redundant invocation
+		c.connSem <- conn // restore
+		return nil
+	}
+	close(c.closed)
+	c.setState(StateChange{State: Closed})
+	c.closeDedicated()
+
+	if conn.offline != nil || conn.idle != nil {
+		// no pending responses
+		c.connSem <- &redisConn{offline: ErrClosed}
+		if conn.Conn != nil {
+			return conn.Close()
+		}
+		return nil
+	}
+
+	// The read routine passes the read lock once all preceding responses
+	// are in, or it signals connection loss with nil. Buffer for cancelQueue.
+	readHandover := make(chan *bufio.Reader, 1)
+	select {
+	case c.readQueue <- readHandover:
+		break
+	case <-ctx.Done():
+		// stop command submission
+		c.connSem <- &redisConn{offline: ErrClosed}
+		// abort any read in progress
+		conn.Close()
+		c.haltReceive(conn)
+		c.cancelQueue()
+		return ctx.Err()
+	}
+
+	// stop command submission
+	c.connSem <- &redisConn{offline: ErrClosed}
+
+	var err error
+	select {
+	case reader := <-readHandover:
+		if reader == nil {
+			// connection loss during drain
+			c.readInterrupt <- struct{}{}
+		}
+
+	case c.readInterrupt <- struct{}{}:
+		// The read routine accepted the halt on
+		// connection loss (from dropConn).
+		break
+
+	case <-ctx.Done():
+		err = ctx.Err()
+		// abort any read in progress
+		conn.Close()
+		c.awaitHandover(readHandover)
+	}
+
+	c.cancelQueue()
+	if e := conn.Close(); err == nil && !isClosed(e) {
+		err = e
+	}
+	return err
+}
+
+// AwaitHandover stops the read routine after its connection got closed.
+func (c *Client) awaitHandover(readHandover <-chan *bufio.Reader) {
+	select {
+	case reader := <-readHandover:
+		if reader == nil {
+			// connection loss signaled by dropConn
+			c.readInterrupt <- struct{}{}
+		}
+	case c.readInterrupt <- struct{}{}:
+		// The read routine accepted the halt
+		// on connection loss (from dropConn).
+		break
+	}
+}
+
+// KeepAlive launches a routine which sends a PING whenever no command was sent
+// during an interval. Idle connections stay in use, such that they don't get
+// reaped by the server or a middlebox. A silent connection loss is detected
+// before the next command suffers from it, given a command timeout. Only the
+// first invocation with a positive interval has effect. The routine stops on
+// Close.
+func (c *Client) KeepAlive(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	c.keepAlive.Do(func() {
+		go c.keepAliveLoop(interval)
+	})
+}
+
+func (c *Client) keepAliveLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastCount := atomic.LoadUint32(&c.sendCount)
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+			count := atomic.LoadUint32(&c.sendCount)
+			if count != lastCount {
+				lastCount = count
+				continue
+			}
+			// Errors cause a reconnect already.
+			if err := c.PING(); err == ErrClosed {
+				return
+			}
+			lastCount = atomic.LoadUint32(&c.sendCount)
+		}
+	}
+}
+
+// ConnState is the connection status of a Client.
+type ConnState int
+
+// Connection States
+const (
+	// Offline has no network connection. Connect attempts are in progress.
+	Offline ConnState = iota
+	// Online has a network connection in place.
+	Online
+	// Closed is the final state, after Close or CloseWait.
+	Closed
+)
+
+// String returns the name.
+func (s ConnState) String() string {
+	switch s {
+	case Offline:
+		return "offline"
+	case Online:
+		return "online"
+	case Closed:
+		return "closed"
+	default:
+		return fmt.Sprintf("ConnState(%d)", int(s))
+	}
+}
+
+// StateChange is a connection state transition.
+type StateChange struct {
+	State ConnState
+
+	// Err has the cause of an Offline state, which is nil for a reconnect
+	// on request (with AUTH or SELECT), and for the initial state.
+	Err error
+
+	// Attempt has the number of failed connect attempts in a row, which is
+	// zero for an Offline state due to connection loss.
+	Attempt int
+
+	// Addr has the node address of an Online state.
+	Addr string
+}
+
+// Notify registers ch for StateChange delivery. The current state is sent
+// first. Delivery does not block. Changes are discarded when ch is not ready,
+// so buffering is recommended. Transitions are sent in order of occurrence.
+// Nothing is sent after the Closed state.
+func (c *Client) Notify(ch chan<- StateChange) {
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+	c.stateSubs = append(c.stateSubs, ch)
+	select {
+	case ch <- c.state:
+		break
+	default:
+		break
+	}
+}
+
+// State returns the current connection state.
+func (c *Client) State() StateChange {
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+	return c.state
+}
+
+// setState updates the connection state, and it notifies all subscribers.
+// Callers must hold the write lock [connSem], such that transitions are in
+// order of occurrence.
+func (c *Client) setState(s StateChange) {
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+	if c.state.State == Closed {
+		return
+	}
+	c.state = s
+	for _, ch := range c.stateSubs {
+		select {
+		case ch <- s:
+			break
+		default:
+			break // discard
+		}
+	}
+}
+
 // connectOrClosed populates the connection semaphore.
 func (c *Client) connectOrClosed() {
 	var retryDelay time.Duration
+	var attempt int
 	for {
 		config := connConfig{
-			BufferSize:     conservativeMSS,
+			BufferSize:     c.options.ReadBufferSize,
 			Addr:           c.Addr,
 			DB:             atomic.LoadInt64(&c.db),
 			CommandTimeout: c.commandTimeout,
 			DialTimeout:    c.dialTimeout,
+			NoDelay:        c.options.NoDelay,
+			KeepAlive:      c.options.KeepAlive,
+			Linger:         c.options.Linger,
+			ReadOnly:       c.readOnly,
+			User:           c.options.User,
+			TLSConfig:      c.options.TLSConfig,
+			RESP3:          c.options.RESP3,
+		}
+		if config.BufferSize <= 0 {
+			config.BufferSize = conservativeMSS
 		}
 		config.Password, _ = c.password.Load().([]byte)
-		conn, reader, err := connect(config)
+		config.Name, _ = c.name.Load().(string)
+		if c.tracking != nil {
+			config.TrackingRedirect = atomic.LoadInt64(&c.tracking.redirect)
+		}
+		var conn net.Conn
+		var reader *bufio.Reader
+		var err error
+		if c.sentinel != nil {
+			config.Addr, err = c.sentinel.masterAddr()
+		}
+		if err == nil {
+			conn, reader, err = connect(config)
+		}
 		if err != nil {
 			retry := time.NewTimer(retryDelay)
 
@@ -157,204 +718,1217 @@ func (c *Client) connectOrClosed() {
 				}
 			}
 			// propagate current connect error
-			c.connSem <- &redisConn{offline: fmt.Errorf("redis: offline due %w", err)}
+			attempt++
+			c.setState(StateChange{State: Offline, Err: err, Attempt: attempt})
+			c.connSem <- &redisConn{offline: offlineError{err}}
+
+			retryDelay = 2*retryDelay + time.Millisecond
+			if retryDelay > DialDelayMax {
+				retryDelay = DialDelayMax
+			}
+			<-retry.C
+			continue
+		}
+
+		// remove previous connect error unless closed
+		if retryDelay != 0 {
+			current := <-c.connSem
+			if current.offline == ErrClosed {
+				c.connSem <- current // restore
+				conn.Close()         // discard
+				return               // abandon
+			}
+		}
+
+		if c.tracking != nil {
+			// entries of previous connections are not tracked
+			c.tracking.flush()
+		}
+
+		// release
+		c.setState(StateChange{State: Online, Addr: config.Addr})
+		atomic.StoreInt32(&c.busy, 0)
+		c.connSem <- &redisConn{Conn: conn, idle: reader, since: time.Now()}
+		return
+	}
+}
+
+// RenewConn replaces the connection of the write lock, after all responses in
+// progress are read. The return is the write lock on the replacement.
+func (c *Client) renewConn(writeLock *redisConn) *redisConn {
+	if writeLock.idle == nil {
+		// The read routine passes the read lock once all
+		// preceding responses are in, or it signals loss.
+		readHandover := make(chan *bufio.Reader, 1)
+		c.readQueue <- readHandover
+		c.awaitHandover(readHandover)
+		// commands of the connection, if any, including
+		// readHandover when the read routine was halted
+		c.cancelQueue()
+	}
+	writeLock.Close()
+	c.setState(StateChange{State: Offline})
+
+	go c.connectOrClosed()
+	return <-c.connSem
+}
+
+// CurrentConn returns the network connection of the write lock, if any.
+func (c *Client) currentConn() net.Conn {
+	conn := <-c.connSem
+	c.connSem <- conn // restore
+	return conn.Conn
+}
+
+// CancelQueue signals connection loss to all pending commands.
+func (c *Client) cancelQueue() {
+	for n := len(c.readQueue); n > 0; n-- {
+		(<-c.readQueue) <- (*bufio.Reader)(nil)
+	}
+}
+
+// Submit sends a request, and deals with response ordering.
+func (c *Client) submit(req *request) (*bufio.Reader, error) {
+	return c.send(c.ctx, req, nil, 0, 0)
+}
+
+// SubmitPayload is like submit, but it appends a blob of size bytes from
+// payload to the request. The request buffer must end with the length
+// announcement of the blob. A nil payload has no effect.
+func (c *Client) submitPayload(req *request, payload io.Reader, size int64) (*bufio.Reader, error) {
+	return c.send(c.ctx, req, payload, size, 0)
+}
+
+// BlockForever is the submitBlocking duration for commands without expiry.
+const blockForever time.Duration = -1
+
+// BlockOf returns the submitBlocking duration for a timeout argument, where zero
+// means no expiry.
+func blockOf(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return blockForever
+	}
+	return timeout
+}
+
+// BlockSeconds returns the timeout argument for a submitBlocking duration.
+func blockSeconds(block time.Duration) string {
+	if block == blockForever {
+		return "0"
+	}
+	return strconv.FormatFloat(block.Seconds(), 'f', -1, 64)
+}
+
+// BlockMillis returns the timeout argument in milliseconds for a submitBlocking
+// duration.
+func blockMillis(block time.Duration) int64 {
+	if block == blockForever {
+		return 0
+	}
+	ms := block.Milliseconds()
+	if ms == 0 {
+		ms = 1 // zero blocks indefinitely
+	}
+	return ms
+}
+
+// SubmitBlocking is like submit, but for commands which may hold the response
+// on the server for up to block. The read deadline from the command timeout, if
+// any, extends with block, and it is suppressed entirely with blockForever.
+func (c *Client) submitBlocking(req *request, block time.Duration) (*bufio.Reader, error) {
+	return c.send(c.ctx, req, nil, 0, block)
+}
+
+// Send is the implementation of submission, where ctx is optional (nil). The
+// submit functions pass the context from WithContext, if any.
+func (c *Client) send(ctx context.Context, req *request, payload io.Reader, size int64, block time.Duration) (*bufio.Reader, error) {
+	if err := req.invalid; err != nil {
+		req.free()
+		return nil, err
+	}
+
+	// operate in write lock
+	var conn *redisConn
+	switch {
+	case ctx != nil:
+		if err := ctx.Err(); err != nil {
+			req.free()
+			return nil, err
+		}
+		// Pending requests can't be withdrawn on cancel.
+		select {
+		case conn = <-c.connSem:
+			break // write lock acquired
+		case <-ctx.Done():
+			req.free()
+			return nil, ctx.Err()
+		}
+	case payload != nil || req.pin != nil:
+		// payload streams don't fit in a batch,
+		// and pins need a check before the write
+		conn = <-c.connSem
+	default:
+		select {
+		case conn = <-c.connSem:
+			break // write lock acquired
+
+		case c.pending <- req:
+			select {
+			case reader := <-req.receive:
+				// sent on our behalf
+				return c.received(req, reader, block)
+
+			case conn = <-c.connSem:
+				if req.conn != nil || req.err != nil {
+					// Sent on our behalf, yet the handover is
+					// pending. Don't block on the read queue
+					// while our own response is in line.
+					c.connSem <- conn // release write lock
+					return c.received(req, <-req.receive, block)
+				}
+				// still pending; collected below
+			}
+		}
+	}
+
+	if req.pin != nil && conn.Conn != req.pin {
+		err := conn.offline
+		if err == nil {
+			err = ErrConnLost
+		}
+		c.connSem <- conn // release write lock
+		req.free()
+		return nil, err
+	}
+
+	// collect pending requests from other routines
+	batch := c.batch[:0]
+	for len(batch) < cap(c.pending) {
+		var r *request
+		select {
+		case r = <-c.pending:
+			break
+		default:
+			break
+		}
+		if r == nil {
+			break
+		}
+		if r != req {
+			batch = append(batch, r)
+		}
+	}
+	// Own request goes last, as it may have a payload.
+	batch = append(batch, req)
+	c.batch = batch // retain capacity
+
+	// retire aged or outdated connection, unless pinned
+	if conn.offline == nil && req.pin == nil {
+		aged := c.options.MaxConnLifetime > 0 && time.Since(conn.since) >= c.options.MaxConnLifetime
+		if atomic.SwapInt32(&c.renew, 0) != 0 || aged {
+			conn = c.renewConn(conn)
+		}
+	}
+
+	// validate connection state
+	if err := conn.offline; err != nil {
+		c.failBatch(batch, req, err)
+		c.resetBatch()
+		c.connSem <- conn // restore
+		req.free()
+		return nil, err
+	}
+
+	// Bound the response queue before any write,
+	// as an executed command would owe its response.
+	var rejected bool
+	if c.options.NoQueueBlock {
+		room := cap(c.readQueue) - len(c.readQueue)
+		if conn.idle != nil {
+			room++ // first in line takes the idle reader
+		}
+		if room < len(batch) {
+			// Own request goes last, so it is rejected too.
+			rejected = true
+			c.failBatch(batch[room:], req, ErrQueueFull)
+			batch = batch[:room]
+			if len(batch) == 0 {
+				c.resetBatch()
+				c.connSem <- conn // release write lock
+				req.free()
+				return nil, ErrQueueFull
+			}
+		}
+	}
+
+	// apply timeout if set, for the batch as a whole
+	if c.commandTimeout != 0 {
+		conn.SetWriteDeadline(time.Now().Add(c.commandTimeout))
+	}
+
+	// send commands
+	atomic.AddUint32(&c.sendCount, uint32(len(batch)))
+	var err error
+	if len(batch) == 1 {
+		_, err = conn.Write(batch[0].buf)
+	} else {
+		bufs := c.batchBufs[:0]
+		for _, r := range batch {
+			bufs = append(bufs, r.buf)
+		}
+		c.batchBufs = bufs
+		// writev(2) when available
+		_, err = bufs.WriteTo(conn.Conn)
+	}
+	var payloadErr error
+	if err == nil && payload != nil && !rejected {
+		payloadErr = writePayload(conn, payload, size)
+		err = payloadErr
+	}
+	if err != nil {
+		if payloadErr != nil {
+			// The others were written in full, and they
+			// may have executed thus. Own request is last.
+			c.failBatch(batch, req, ErrConnLost)
+		} else {
+			c.failBatch(batch, req, err)
+		}
+		c.resetBatch()
+		c.setState(StateChange{State: Offline, Err: err})
+		// write remains locked
+		go func() {
+			c.haltReceive(conn)
+			c.cancelQueue()
+			conn.Close()
+			c.connectOrClosed()
+		}()
+		// The receive channel was not queued.
+		req.free()
+		if rejected {
+			return nil, ErrQueueFull
+		}
+		return nil, err
+	}
+
+	var ownReader *bufio.Reader
+	for i, r := range batch {
+		r.conn = conn.Conn
+		switch {
+		case i == 0 && conn.idle != nil:
+			// Own the virtual read lock by clearing the idle state.
+			reader := conn.idle
+			conn.idle = nil
+			atomic.StoreInt32(&c.busy, 1)
+			if r == req {
+				// The receive channel is not used, as we're next in line.
+				ownReader = reader
+			} else {
+				r.receive <- reader
+			}
+		default:
+			// The virtual read lock is processing the queue.
+			c.readQueue <- r.receive
+		}
+	}
+	c.resetBatch()
+
+	c.connSem <- conn // release write lock
+
+	if rejected {
+		req.free()
+		return nil, ErrQueueFull
+	}
+	if ownReader != nil {
+		return c.received(req, ownReader, block)
+	}
+	if ctx != nil {
+		select {
+		case reader := <-req.receive:
+			return c.received(req, reader, block)
+		case <-ctx.Done():
+			go c.discard(req, block)
+			return nil, ctx.Err()
+		}
+	}
+	// await handover of virtual read lock
+	return c.received(req, <-req.receive, block)
+}
+
+// Discard awaits the response of a request which was sent on behalf of a
+// cancelled submission, and it skips the response to keep the order in place.
+func (c *Client) discard(req *request, block time.Duration) {
+	more := req.more
+	r, err := c.received(req, <-req.receive, block)
+	if err != nil {
+		return // connection loss
+	}
+	for {
+		_, err = decodeAny(r)
+		if _, ok := err.(ServerError); ok {
+			err = nil
+		}
+		if err != nil || more == 0 {
+			break
+		}
+		more--
+		if err = c.dispatchPush(r); err != nil {
+			break
+		}
+	}
+	c.pass(r, err)
+}
+
+// FailBatch signals err to each request in batch, other than own.
+func (c *Client) failBatch(batch []*request, own *request, err error) {
+	for _, r := range batch {
+		if r != own {
+			r.err = err
+			r.receive <- (*bufio.Reader)(nil)
+		}
+	}
+}
+
+// ResetBatch clears the write coalescing buffers for reuse, such that they
+// don't retain any request. The caller must hold the write lock [connSem].
+func (c *Client) resetBatch() {
+	for i := range c.batch {
+		c.batch[i] = nil
+	}
+	c.batch = c.batch[:0]
+	for i := range c.batchBufs {
+		c.batchBufs[i] = nil
+	}
+	c.batchBufs = c.batchBufs[:0]
+}
+
+// Received concludes a submission with the handover of the virtual read lock.
+// A nil reader signals either a send error on our behalf, or connection loss.
+func (c *Client) received(req *request, reader *bufio.Reader, block time.Duration) (*bufio.Reader, error) {
+	// The read queue has no more reference after the handover,
+	// including the nil of a queue abandonment.
+	conn, err := req.conn, req.err
+	req.free()
+	if reader == nil {
+		if err != nil {
+			return nil, err
+		}
+		// queue abandonment
+		return nil, ErrConnLost
+	}
+
+	// The read deadline starts with the handover, i.e., time spent in the
+	// read queue does not count, as the predecessors are bound by their own
+	// deadline, blocking commands included. A handover only comes from the
+	// connection which sent the request, because reconnects cancel the read
+	// queue.
+	if c.commandTimeout != 0 {
+		switch {
+		case block == 0:
+			conn.SetReadDeadline(time.Now().Add(c.commandTimeout))
+		case block == blockForever:
+			conn.SetReadDeadline(time.Time{})
+		default:
+			conn.SetReadDeadline(time.Now().Add(c.commandTimeout + block))
+		}
+	}
+
+	if err := c.dispatchPush(reader); err != nil {
+		c.pass(reader, err)
+		return nil, err
+	}
+	return reader, nil
+}
+
+// HandlePush registers fn for RESP3 push messages of a kind, such as
+// "invalidate" from CLIENT TRACKING without REDIRECT. The push arrives as an
+// ArrayReply with the kind as its first element. A nil fn removes the handler
+// of kind. Push messages without a handler are discarded. Push messages only
+// occur with the RESP3 option. They are read in line with the responses, i.e.,
+// a push dispatches when it precedes a response to a command, and fn must not
+// block nor submit commands on c, as the response reads wait for its return.
+func (c *Client) HandlePush(kind string, fn func(push Reply)) {
+	c.pushMutex.Lock()
+	defer c.pushMutex.Unlock()
+	if fn == nil {
+		delete(c.pushHandlers, kind)
+		return
+	}
+	if c.pushHandlers == nil {
+		c.pushHandlers = make(map[string]func(Reply))
+	}
+	c.pushHandlers[kind] = fn
+}
+
+// DispatchPush reads any push messages in line, and it passes each to the
+// handler of its kind, if any. The reader must be positioned at the start of a
+// response.
+func (c *Client) dispatchPush(r *bufio.Reader) error {
+	if !c.options.RESP3 {
+		return nil // push requires HELLO 3
+	}
+	for {
+		b, err := r.Peek(1)
+		if err != nil || b[0] != '>' {
+			return nil // read errors go to the response decode
+		}
+		push, err := decodeReply(r)
+		if err != nil {
+			return err
+		}
+		var kind string
+		if a := push.Array(); len(a) != 0 {
+			kind = a[0].Str()
+		}
+		c.pushMutex.Lock()
+		fn := c.pushHandlers[kind]
+		c.pushMutex.Unlock()
+		if fn != nil {
+			fn(push)
+		}
+	}
+}
+
+// Route returns the replica for read-only requests with the ReplicaReads
+// option, when online.
+func (c *Client) route(req *request) *Client {
+	if req.readOnly && c.options.ReplicaReads {
+		return c.Replica()
+	}
+	return c
+}
+
+// RetryOf returns a duplicate of req for the next attempt, or nil when req is
+// not read-only, or when it ran out of ClientOptions ReadRetries. The return
+// goes to retry, which frees it when unused.
+func (c *Client) retryOf(req *request) *request {
+	if !req.readOnly || req.attempt >= c.options.ReadRetries {
+		return nil
+	}
+	dup := requestPool.Get().(*request)
+	dup.buf = append(dup.buf[:0], req.buf...)
+	dup.readOnly = true
+	dup.attempt = req.attempt + 1
+	return dup
+}
+
+// Retry returns whether the duplicate from retryOf, if any, should execute
+// after err. The duplicate is freed otherwise.
+func (c *Client) retry(dup *request, err error) bool {
+	if dup == nil {
+		return false
+	}
+	if isConnLoss(err) {
+		return true
+	}
+	dup.free()
+	return false
+}
+
+// IsConnLoss returns whether err leaves command execution uncertain due to a
+// network failure. Server errors and ErrOffline don't qualify.
+func isConnLoss(err error) bool {
+	switch err {
+	case nil, ErrNil:
+		return false
+	case ErrConnLost, io.EOF, io.ErrUnexpectedEOF:
+		return true
+	}
+	if errors.Is(err, ErrOffline) {
+		return false
+	}
+	var e net.Error
+	return errors.As(err, &e)
+}
+
+// CRLF is the line terminator.
+var crlf = []byte{'\r', '\n'}
+
+// WritePayload sends exactly size bytes from r, followed by a CRLF. Any
+// mismatch between size and the content of r desynchronizes the protocol,
+// which leaves the connection unusable. The read of the last byte asks for one
+// more, as a read-ahead which does not block on readers without EOF, such as a
+// network connection. Excess beyond that one read goes undetected.
+func writePayload(w io.Writer, r io.Reader, size int64) error {
+	if size == 0 {
+		_, err := w.Write(crlf)
+		return err
+	}
+
+	n, err := io.CopyN(w, r, size-1)
+	if err == nil {
+		// last byte with read-ahead
+		var last [2]byte
+		var l int
+		for l == 0 && err == nil {
+			l, err = r.Read(last[:])
+		}
+		switch {
+		case l > 1:
+			return fmt.Errorf("redis: payload reader exceeds %d bytes", size)
+		case l == 1:
+			n++
+			if _, err := w.Write(last[:1]); err != nil {
+				return err
+			}
+			err = nil // EOF with the last byte is fine
+		}
+	}
+	switch {
+	case err == io.EOF:
+		return fmt.Errorf("redis: payload reader ended after %d bytes, want %d", n, size)
+	case err != nil:
+		return fmt.Errorf("redis: payload aborted after %d bytes, want %d: %w", n, size, err)
+	}
+
+	_, err = w.Write(crlf)
+	return err
+}
+
+func (c *Client) commandOK(req *request) error {
+	r, err := c.submit(req)
+	if err != nil {
+		return err
+	}
+	err = decodeOK(r)
+	c.pass(r, err)
+	return err
+}
+
+func (c *Client) commandOKBlocking(req *request, block time.Duration) error {
+	r, err := c.submitBlocking(req, block)
+	if err != nil {
+		return err
+	}
+	err = decodeOK(r)
+	c.pass(r, err)
+	return err
+}
+
+func (c *Client) commandOKPayload(req *request, payload io.Reader, size int64) error {
+	r, err := c.submitPayload(req, payload, size)
+	if err != nil {
+		return err
+	}
+	err = decodeOK(r)
+	c.pass(r, err)
+	return err
+}
+
+func (c *Client) commandOKOrReconnect(req *request) error {
+	r, err := c.submit(req)
+	if err != nil {
+		return err
+	}
+	err = decodeOK(r)
+	if err != nil {
+		c.dropConn(err)
+	} else {
+		c.pass(r, nil)
+	}
+	return err
+}
+
+func (c *Client) commandOKAndReconnect(req *request) error {
+	r, err := c.submit(req)
+	if err != nil {
+		return err
+	}
+	err = decodeOK(r)
+	c.dropConn(nil)
+	return err
+}
+
+func (c *Client) commandSimpleString(req *request) (string, error) {
+	r, err := c.submit(req)
+	if err != nil {
+		return "", err
+	}
+	s, err := decodeSimpleString(r)
+	c.pass(r, err)
+	return s, err
+}
+
+func (c *Client) commandInteger(req *request) (int64, error) {
+	c = c.route(req)
+	dup := c.retryOf(req)
+	r, err := c.submit(req)
+	if err != nil {
+		if c.retry(dup, err) {
+			return c.commandInteger(dup)
+		}
+		return 0, err
+	}
+	integer, err := decodeInteger(r)
+	c.pass(r, err)
+	if c.retry(dup, err) {
+		return c.commandInteger(dup)
+	}
+	return integer, err
+}
+
+func (c *Client) commandIntegerOK(req *request) (int64, bool, error) {
+	r, err := c.submit(req)
+	if err != nil {
+		return 0, false, err
+	}
+	integer, err := decodeInteger(r)
+	c.pass(r, err)
+	if err == ErrNil {
+		return 0, false, nil
+	}
+	return integer, err == nil, err
+}
+
+func (c *Client) commandIntegerBlocking(req *request, block time.Duration) (int64, error) {
+	r, err := c.submitBlocking(req, block)
+	if err != nil {
+		return 0, err
+	}
+	integer, err := decodeInteger(r)
+	c.pass(r, err)
+	return integer, err
+}
+
+func (c *Client) commandIntegerPayload(req *request, payload io.Reader, size int64) (int64, error) {
+	r, err := c.submitPayload(req, payload, size)
+	if err != nil {
+		return 0, err
+	}
+	integer, err := decodeInteger(r)
+	c.pass(r, err)
+	return integer, err
+}
+
+func (c *Client) commandBlobBytes(req *request) ([]byte, error) {
+	c = c.route(req)
+	dup := c.retryOf(req)
+	r, err := c.submit(req)
+	if err != nil {
+		if c.retry(dup, err) {
+			return c.commandBlobBytes(dup)
+		}
+		return nil, err
+	}
+	bytes, err := decodeBlobBytes(r)
+	c.pass(r, err)
+	if c.retry(dup, err) {
+		return c.commandBlobBytes(dup)
+	}
+	if err == ErrNil {
+		return nil, nil
+	}
+	return bytes, err
+}
+
+func (c *Client) commandScan(req *request) (next string, page [][]byte, err error) {
+	r, err := c.submit(req)
+	if err != nil {
+		return "", nil, err
+	}
+	next, page, err = decodeScan(r)
+	c.pass(r, err)
+	return next, page, err
+}
+
+func (c *Client) commandBlobReader(req *request) (io.ReadCloser, int64, error) {
+	c = c.route(req)
+	dup := c.retryOf(req)
+	r, err := c.submit(req)
+	if err != nil {
+		if c.retry(dup, err) {
+			return c.commandBlobReader(dup)
+		}
+		return nil, 0, err
+	}
+	l, err := readBlobLen(r)
+	if err != nil {
+		c.pass(r, err)
+		if c.retry(dup, err) {
+			return c.commandBlobReader(dup)
+		}
+		if err == ErrNil {
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+	c.retry(dup, nil) // free
+	return &blobReader{c: c, r: r, remaining: l}, int64(l), nil
+}
+
+// BlobReader streams a blob string response. The read lock is passed on once
+// the payload is read completely, or on Close.
+type blobReader struct {
+	c *Client
+	// nil once passed
+	r *bufio.Reader
+	// payload bytes not read yet
+	remaining int
+	// sticky error, if any
+	err error
+}
+
+// Read implements the io.Reader interface.
+func (b *blobReader) Read(p []byte) (n int, err error) {
+	if b.r == nil {
+		if b.err != nil {
+			return 0, b.err
+		}
+		return 0, io.EOF
+	}
+	if len(p) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err = b.r.Read(p)
+	b.remaining -= n
+	if err == nil && b.remaining == 0 {
+		// skip CRLF
+		_, err = b.r.Discard(2)
+		if err == nil {
+			b.c.pass(b.r, nil)
+			b.r = nil
+			return n, io.EOF
+		}
+	}
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		b.c.pass(b.r, err)
+		b.r = nil
+		b.err = err
+	}
+	return n, err
+}
+
+// Close implements the io.Closer interface.
+func (b *blobReader) Close() error {
+	if b.r == nil {
+		return nil
+	}
+	_, err := b.r.Discard(b.remaining + 2)
+	b.c.pass(b.r, err)
+	b.r = nil
+	if err != nil {
+		b.err = err
+		return err
+	}
+	b.err = ErrClosed
+	return nil
+}
+
+func (c *Client) commandBlobInto(req *request, dst []byte) (int, bool, error) {
+	c = c.route(req)
+	dup := c.retryOf(req)
+	r, err := c.submit(req)
+	if err != nil {
+		if c.retry(dup, err) {
+			return c.commandBlobInto(dup, dst)
+		}
+		return 0, false, err
+	}
+	n, err := decodeBlobInto(r, dst)
+	if err == io.ErrShortBuffer {
+		c.pass(r, nil) // payload skipped
+	} else {
+		c.pass(r, err)
+	}
+	if c.retry(dup, err) {
+		return c.commandBlobInto(dup, dst)
+	}
+	switch err {
+	case io.ErrShortBuffer:
+		return n, true, err
+	case ErrNil:
+		return 0, false, nil
+	}
+	return n, err == nil, err
+}
+
+func (c *Client) commandBlobString(req *request) (string, bool, error) {
+	c = c.route(req)
+	dup := c.retryOf(req)
+	r, err := c.submit(req)
+	if err != nil {
+		if c.retry(dup, err) {
+			return c.commandBlobString(dup)
+		}
+		return "", false, err
+	}
+	s, err := decodeBlobString(r)
+	c.pass(r, err)
+	if c.retry(dup, err) {
+		return c.commandBlobString(dup)
+	}
+	if err == ErrNil {
+		return "", false, nil
+	}
+	return s, true, err
+}
+
+// CommandBytesArrayFunc has no retries, as fn may have seen elements already.
+func (c *Client) commandBytesArrayFunc(req *request, fn func([]byte) error) error {
+	c = c.route(req)
+	r, err := c.submit(req)
+	if err != nil {
+		return err
+	}
+	fnErr, err := decodeBytesArrayFunc(r, fn)
+	c.pass(r, err)
+	if err != nil && err != ErrNil {
+		return err
+	}
+	return fnErr
+}
+
+func (c *Client) commandStringBytesPairsFunc(req *request, fn func(string, []byte) error) error {
+	r, err := c.submit(req)
+	if err != nil {
+		return err
+	}
+	fnErr, err := decodeStringBytesPairsFunc(r, fn)
+	c.pass(r, err)
+	if err != nil && err != ErrNil {
+		return err
+	}
+	return fnErr
+}
 
-			retryDelay = 2*retryDelay + time.Millisecond
-			if retryDelay > DialDelayMax {
-				retryDelay = DialDelayMax
-			}
-			<-retry.C
-			continue
+func (c *Client) commandBytesArray(req *request) ([][]byte, error) {
+	c = c.route(req)
+	dup := c.retryOf(req)
+	r, err := c.submit(req)
+	if err != nil {
+		if c.retry(dup, err) {
+			return c.commandBytesArray(dup)
 		}
+		return nil, err
+	}
+	array, err := decodeBytesArray(r)
+	c.pass(r, err)
+	if c.retry(dup, err) {
+		return c.commandBytesArray(dup)
+	}
+	if err == ErrNil {
+		return nil, nil
+	}
+	return array, err
+}
 
-		// remove previous connect error unless closed
-		if retryDelay != 0 {
-			current := <-c.connSem
-			if current.offline == ErrClosed {
-				c.connSem <- current // restore
-				conn.Close()         // discard
-				return               // abandon
-			}
+func (c *Client) commandStringArray(req *request) ([]string, error) {
+	c = c.route(req)
+	dup := c.retryOf(req)
+	r, err := c.submit(req)
+	if err != nil {
+		if c.retry(dup, err) {
+			return c.commandStringArray(dup)
 		}
-
-		// release
-		c.connSem <- &redisConn{Conn: conn, idle: reader}
-		return
+		return nil, err
+	}
+	array, err := decodeStringArray(r)
+	c.pass(r, err)
+	if c.retry(dup, err) {
+		return c.commandStringArray(dup)
+	}
+	if err == ErrNil {
+		return nil, nil
 	}
+	return array, err
 }
 
-// CancelQueue signals connection loss to all pending commands.
-func (c *Client) cancelQueue() {
-	for n := len(c.readQueue); n > 0; n-- {
-		(<-c.readQueue) <- (*bufio.Reader)(nil)
+func (c *Client) commandIntegerArray(req *request) ([]int64, error) {
+	r, err := c.submit(req)
+	if err != nil {
+		return nil, err
+	}
+	array, err := decodeIntegerArray(r)
+	c.pass(r, err)
+	if err == ErrNil {
+		return nil, nil
 	}
+	return array, err
 }
 
-// Submit sends a request, and deals with response ordering.
-func (c *Client) submit(req *request) (*bufio.Reader, error) {
-	// operate in write lock
-	conn := <-c.connSem
+func (c *Client) commandStringArrayOK(req *request) ([]string, []bool, error) {
+	c = c.route(req)
+	dup := c.retryOf(req)
+	r, err := c.submit(req)
+	if err != nil {
+		if c.retry(dup, err) {
+			return c.commandStringArrayOK(dup)
+		}
+		return nil, nil, err
+	}
+	array, ok, err := decodeStringArrayOK(r)
+	c.pass(r, err)
+	if c.retry(dup, err) {
+		return c.commandStringArrayOK(dup)
+	}
+	if err == ErrNil {
+		return nil, nil, nil
+	}
+	return array, ok, err
+}
 
-	// validate connection state
-	if err := conn.offline; err != nil {
-		c.connSem <- conn // restore
+func (c *Client) commandBytesMap(req *request) (map[string][]byte, error) {
+	r, err := c.submit(req)
+	if err != nil {
 		return nil, err
 	}
-
-	// apply timeout if set
-	var deadline time.Time
-	if c.commandTimeout != 0 {
-		deadline = time.Now().Add(c.commandTimeout)
-		conn.SetWriteDeadline(deadline)
+	m, err := decodeBytesMap(r)
+	c.pass(r, err)
+	if err == ErrNil {
+		return nil, nil
 	}
+	return m, err
+}
 
-	// send command
-	if _, err := conn.Write(req.buf); err != nil {
-		// write remains locked
-		go func() {
-			c.haltReceive(conn)
-			c.cancelQueue()
-			conn.Close()
-			c.connectOrClosed()
-		}()
+func (c *Client) commandStringMap(req *request) (map[string]string, error) {
+	r, err := c.submit(req)
+	if err != nil {
 		return nil, err
 	}
+	m, err := decodeStringMap(r)
+	c.pass(r, err)
+	if err == ErrNil {
+		return nil, nil
+	}
+	return m, err
+}
 
-	reader := conn.idle
-	if reader != nil {
-		// Own the virtual read lock by clearing the idle state.
-		conn.idle = nil
-		// The receive channel is not used, as we're next in line.
-		req.free()
-	} else {
-		// The virtual read lock is processing the queue.
-		c.readQueue <- req.receive
+func (c *Client) commandStringBytesPairs(req *request) ([]string, [][]byte, error) {
+	r, err := c.submit(req)
+	if err != nil {
+		return nil, nil, err
 	}
+	fields, values, err := decodeStringBytesPairs(r)
+	c.pass(r, err)
+	if err == ErrNil {
+		return nil, nil, nil
+	}
+	return fields, values, err
+}
 
-	c.connSem <- conn // release write lock
+func (c *Client) commandZMembers(req *request) ([]ZMember, error) {
+	r, err := c.submit(req)
+	if err != nil {
+		return nil, err
+	}
+	members, err := decodeZMembers(r)
+	c.pass(r, err)
+	if err == ErrNil {
+		return nil, nil
+	}
+	return members, err
+}
 
-	if reader == nil {
-		// await handover of virtual read lock
-		reader = <-req.receive
-		req.free()
-		if reader == nil {
-			// queue abandonment
-			return nil, errConnLost
-		}
+func (c *Client) commandZPop(req *request, block time.Duration) (string, ZMember, bool, error) {
+	r, err := c.submitBlocking(req, block)
+	if err != nil {
+		return "", ZMember{}, false, err
+	}
+	key, member, err := decodeZPop(r)
+	c.pass(r, err)
+	if err == ErrNil {
+		return "", ZMember{}, false, nil
 	}
+	return key, member, err == nil, err
+}
 
-	if !deadline.IsZero() {
-		conn.SetReadDeadline(deadline)
+func (c *Client) commandZMPop(req *request, block time.Duration) (string, []ZMember, error) {
+	r, err := c.submitBlocking(req, block)
+	if err != nil {
+		return "", nil, err
+	}
+	key, members, err := decodeZMPop(r)
+	c.pass(r, err)
+	if err == ErrNil {
+		return "", nil, nil
 	}
+	return key, members, err
+}
 
-	return reader, nil
+func (c *Client) commandLMPop(req *request, block time.Duration) (string, [][]byte, error) {
+	r, err := c.submitBlocking(req, block)
+	if err != nil {
+		return "", nil, err
+	}
+	key, values, err := decodeLMPop(r)
+	c.pass(r, err)
+	if err == ErrNil {
+		return "", nil, nil
+	}
+	return key, values, err
 }
 
-func (c *Client) commandOK(req *request) error {
+func (c *Client) commandCommandSpecs(req *request) ([]CommandSpec, error) {
 	r, err := c.submit(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	err = decodeOK(r)
+	specs, err := decodeCommandSpecs(r)
 	c.pass(r, err)
-	return err
+	return specs, err
 }
 
-func (c *Client) commandOKOrReconnect(req *request) error {
+func (c *Client) commandGeoResults(req *request, q *GeoSearch) ([]GeoResult, error) {
 	r, err := c.submit(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	err = decodeOK(r)
+	results, err := decodeGeoResults(r, q)
+	c.pass(r, err)
+	if err == ErrNil {
+		return nil, nil
+	}
+	return results, err
+}
+
+func (c *Client) commandFloat(req *request) (float64, bool, error) {
+	r, err := c.submit(req)
 	if err != nil {
-		c.dropConn()
-	} else {
-		c.pass(r, nil)
+		return 0, false, err
 	}
-	return err
+	f, err := decodeFloat(r)
+	c.pass(r, err)
+	if err == ErrNil {
+		return 0, false, nil
+	}
+	return f, err == nil, err
 }
 
-func (c *Client) commandOKAndReconnect(req *request) error {
+func (c *Client) commandStreamEntries(req *request) ([]StreamEntry, error) {
 	r, err := c.submit(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	err = decodeOK(r)
-	c.dropConn()
-	return err
+	entries, err := decodeStreamEntries(r)
+	c.pass(r, err)
+	if err == ErrNil {
+		return nil, nil
+	}
+	return entries, err
 }
 
-func (c *Client) commandInteger(req *request) (int64, error) {
+func (c *Client) commandStreams(req *request, block time.Duration) ([]Stream, error) {
+	r, err := c.submitBlocking(req, block)
+	if err != nil {
+		return nil, err
+	}
+	streams, err := decodeStreams(r)
+	c.pass(r, err)
+	if err == ErrNil {
+		return nil, nil
+	}
+	return streams, err
+}
+
+func (c *Client) commandXPendingSummary(req *request) (XPendingSummary, error) {
 	r, err := c.submit(req)
 	if err != nil {
-		return 0, err
+		return XPendingSummary{}, err
 	}
-	integer, err := decodeInteger(r)
+	summary, err := decodeXPendingSummary(r)
 	c.pass(r, err)
-	return integer, err
+	return summary, err
 }
 
-func (c *Client) commandBlobBytes(req *request) ([]byte, error) {
+func (c *Client) commandXPendingEntries(req *request) ([]XPendingEntry, error) {
 	r, err := c.submit(req)
 	if err != nil {
 		return nil, err
 	}
-	bytes, err := decodeBlobBytes(r)
+	entries, err := decodeXPendingEntries(r)
 	c.pass(r, err)
-	if err == errNull {
-		return nil, nil
+	return entries, err
+}
+
+func (c *Client) commandXInfoStream(req *request) (XInfoStream, error) {
+	r, err := c.submit(req)
+	if err != nil {
+		return XInfoStream{}, err
 	}
-	return bytes, err
+	info, err := decodeXInfoStream(r)
+	c.pass(r, err)
+	return info, err
 }
 
-func (c *Client) commandBlobString(req *request) (string, bool, error) {
+func (c *Client) commandXInfoGroups(req *request) ([]XInfoGroup, error) {
 	r, err := c.submit(req)
 	if err != nil {
-		return "", false, err
+		return nil, err
 	}
-	s, err := decodeBlobString(r)
+	groups, err := decodeXInfoGroups(r)
 	c.pass(r, err)
-	if err == errNull {
-		return "", false, nil
+	return groups, err
+}
+
+func (c *Client) commandXInfoConsumers(req *request) ([]XInfoConsumer, error) {
+	r, err := c.submit(req)
+	if err != nil {
+		return nil, err
 	}
-	return s, true, err
+	consumers, err := decodeXInfoConsumers(r)
+	c.pass(r, err)
+	return consumers, err
 }
 
-func (c *Client) commandBytesArray(req *request) ([][]byte, error) {
+func (c *Client) commandFunctionLibraries(req *request) ([]FunctionLibrary, error) {
 	r, err := c.submit(req)
 	if err != nil {
 		return nil, err
 	}
-	array, err := decodeBytesArray(r)
+	libs, err := decodeFunctionLibraries(r)
 	c.pass(r, err)
-	if err == errNull {
-		return nil, nil
+	return libs, err
+}
+
+func (c *Client) commandXAutoClaim(req *request) (string, []StreamEntry, []string, error) {
+	r, err := c.submit(req)
+	if err != nil {
+		return "", nil, nil, err
 	}
-	return array, err
+	next, entries, deleted, err := decodeXAutoClaim(r)
+	c.pass(r, err)
+	return next, entries, deleted, err
 }
 
-func (c *Client) commandStringArray(req *request) ([]string, error) {
+func (c *Client) commandAny(req *request) (interface{}, error) {
+	c = c.route(req)
+	dup := c.retryOf(req)
 	r, err := c.submit(req)
 	if err != nil {
+		if c.retry(dup, err) {
+			return c.commandAny(dup)
+		}
 		return nil, err
 	}
-	array, err := decodeStringArray(r)
+	v, err := decodeAny(r)
 	c.pass(r, err)
-	if err == errNull {
-		return nil, nil
+	if c.retry(dup, err) {
+		return c.commandAny(dup)
 	}
-	return array, err
+	return v, err
+}
+
+func (c *Client) commandReply(ctx context.Context, req *request) (Reply, error) {
+	r, err := c.send(ctx, req, nil, 0, 0)
+	if err != nil {
+		return Reply{}, err
+	}
+	v, err := decodeReply(r)
+	c.pass(r, err)
+	return v, err
 }
 
 // Pass over the virtual read lock to the following command in line.
 // If there are no routines waiting for response, then go in idle mode.
 func (c *Client) pass(r *bufio.Reader, err error) {
 	switch err {
-	case nil, errNull:
+	case nil, ErrNil:
 		break
 	default:
 		if _, ok := err.(ServerError); !ok {
-			c.dropConn()
+			c.dropConn(err)
 			return
 		}
 	}
@@ -384,6 +1958,7 @@ func (c *Client) pass(r *bufio.Reader, err error) {
 		default:
 			// set read lock to idle
 			conn.idle = r
+			atomic.StoreInt32(&c.busy, 0)
 		}
 		c.connSem <- conn // unlock write
 
@@ -393,7 +1968,8 @@ func (c *Client) pass(r *bufio.Reader, err error) {
 	}
 }
 
-func (c *Client) dropConn() {
+// dropConn discards the connection, and it reconnects. The cause may be nil.
+func (c *Client) dropConn(cause error) {
 	for {
 		select {
 		case <-c.readInterrupt:
@@ -414,6 +1990,7 @@ func (c *Client) dropConn() {
 				}
 				c.connSem <- conn // restore
 			} else {
+				c.setState(StateChange{State: Offline, Err: cause})
 				// write remains locked
 				go func() {
 					conn.Close()
@@ -434,7 +2011,9 @@ func (c *Client) haltReceive(writeLock *redisConn) {
 	}
 	// Read routine needs the write lock to idle.
 
-	readHandover := make(chan *bufio.Reader)
+	// Buffer for cancelQueue, as the halt may be accepted
+	// with readHandover still in the queue.
+	readHandover := make(chan *bufio.Reader, 1)
 	select {
 	case c.readInterrupt <- struct{}{}:
 		// The read routine accepted the halt,
@@ -462,9 +2041,19 @@ type connConfig struct {
 	DB             int64
 	CommandTimeout time.Duration
 	DialTimeout    time.Duration
+	NoDelay        bool
+	KeepAlive      time.Duration
+	Linger         int
+	ReadOnly       bool
+	Name           string
+	User           string
+	TLSConfig      *tls.Config
+	RESP3          bool
+	// CLIENT TRACKING when not zero
+	TrackingRedirect int64
 }
 
-func connect(c connConfig) (net.Conn, *bufio.Reader, error) {
+func connect(c connConfig) (_ net.Conn, _ *bufio.Reader, err error) {
 	network := "tcp"
 	if isUnixAddr(c.Addr) {
 		network = "unix"
@@ -473,19 +2062,44 @@ func connect(c connConfig) (net.Conn, *bufio.Reader, error) {
 	if err != nil {
 		return nil, nil, err
 	}
+	defer func() {
+		if err != nil {
+			conn.Close() // handshake failed
+		}
+	}()
 
 	// connection tuning
 	if tcp, ok := conn.(*net.TCPConn); ok {
-		tcp.SetNoDelay(false)
-		tcp.SetLinger(0)
+		tcp.SetNoDelay(c.NoDelay)
+		tcp.SetLinger(c.Linger)
+		switch {
+		case c.KeepAlive < 0:
+			tcp.SetKeepAlive(false)
+		case c.KeepAlive > 0:
+			tcp.SetKeepAlive(true)
+			tcp.SetKeepAlivePeriod(c.KeepAlive)
+		}
+	}
+	if c.TLSConfig != nil && network == "tcp" {
+		config := c.TLSConfig
+		if config.ServerName == "" && !config.InsecureSkipVerify {
+			config = config.Clone()
+			config.ServerName, _, _ = net.SplitHostPort(c.Addr)
+		}
+		tlsConn := tls.Client(conn, config)
+		tlsConn.SetDeadline(time.Now().Add(c.DialTimeout))
+		if err := tlsConn.Handshake(); err != nil {
+			return nil, nil, fmt.Errorf("redis: TLS handshake with %w", err)
+		}
+		tlsConn.SetDeadline(time.Time{})
+		conn = tlsConn
 	}
 	reader := bufio.NewReaderSize(conn, c.BufferSize)
 
 	// apply sticky settings
 	if c.Password != nil {
-		req := newRequest("*2\r\n$4\r\nAUTH\r\n$")
+		req := newAUTHRequest(c.User, c.Password)
 		defer req.free()
-		req.addBytes(c.Password)
 
 		if c.CommandTimeout != 0 {
 			conn.SetDeadline(time.Now().Add(c.CommandTimeout))
@@ -499,6 +2113,25 @@ func connect(c connConfig) (net.Conn, *bufio.Reader, error) {
 			return nil, nil, fmt.Errorf("redis: AUTH with %w", err)
 		}
 	}
+	if c.RESP3 {
+		req := newRequest("*2\r\n$5\r\nHELLO\r\n$1\r\n3\r\n")
+		defer req.free()
+
+		if c.CommandTimeout != 0 {
+			conn.SetDeadline(time.Now().Add(c.CommandTimeout))
+			defer conn.SetDeadline(time.Time{})
+		}
+		_, err := conn.Write(req.buf)
+		if err == nil {
+			// discard server properties
+			_, err = decodeAny(reader)
+		}
+		// Servers without HELLO reject the command,
+		// and they remain on RESP2.
+		if _, ok := err.(ServerError); err != nil && !ok {
+			return nil, nil, fmt.Errorf("redis: HELLO with %w", err)
+		}
+	}
 	if c.DB != 0 {
 		req := newRequest("*2\r\n$6\r\nSELECT\r\n$")
 		defer req.free()
@@ -516,6 +2149,58 @@ func connect(c connConfig) (net.Conn, *bufio.Reader, error) {
 			return nil, nil, fmt.Errorf("redis: SELECT with %w", err)
 		}
 	}
+	if c.Name != "" {
+		req := newRequest("*3\r\n$6\r\nCLIENT\r\n$7\r\nSETNAME\r\n$")
+		defer req.free()
+		req.addString(c.Name)
+
+		if c.CommandTimeout != 0 {
+			conn.SetDeadline(time.Now().Add(c.CommandTimeout))
+			defer conn.SetDeadline(time.Time{})
+		}
+		_, err := conn.Write(req.buf)
+		if err == nil {
+			err = decodeOK(reader)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("redis: CLIENT SETNAME with %w", err)
+		}
+	}
+	if c.TrackingRedirect != 0 {
+		req := newRequest("*5\r\n$6\r\nCLIENT\r\n$8\r\nTRACKING\r\n$2\r\nON\r\n$8\r\nREDIRECT\r\n$")
+		defer req.free()
+		req.addDecimal(c.TrackingRedirect)
+
+		if c.CommandTimeout != 0 {
+			conn.SetDeadline(time.Now().Add(c.CommandTimeout))
+			defer conn.SetDeadline(time.Time{})
+		}
+		_, err := conn.Write(req.buf)
+		if err == nil {
+			err = decodeOK(reader)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("redis: CLIENT TRACKING with %w", err)
+		}
+	}
+	if c.ReadOnly {
+		req := newRequest("*1\r\n$8\r\nREADONLY\r\n")
+		defer req.free()
+
+		if c.CommandTimeout != 0 {
+			conn.SetDeadline(time.Now().Add(c.CommandTimeout))
+			defer conn.SetDeadline(time.Time{})
+		}
+		_, err := conn.Write(req.buf)
+		if err == nil {
+			err = decodeOK(reader)
+		}
+		// Replicas without cluster support reject READONLY,
+		// yet they serve reads regardless.
+		if _, ok := err.(ServerError); err != nil && !ok {
+			return nil, nil, fmt.Errorf("redis: READONLY with %w", err)
+		}
+	}
 
 	return conn, reader, nil
 }
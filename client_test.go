@@ -1,15 +1,26 @@
 package redis
 
 import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	crand "crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math/big"
 	"math/rand"
 	"net"
+	"net/url"
 	"os"
+	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -107,6 +118,140 @@ func TestCloseBussy(t *testing.T) {
 	}
 }
 
+func TestCloseWait(t *testing.T) {
+	t.Parallel()
+	c := NewClient(testClient.Addr, 0, 0)
+	if password != nil {
+		if err := c.AUTH(password); err != nil {
+			t.Fatal("AUTH error:", err)
+		}
+	}
+	key := randomKey("counter")
+	defer testClient.DEL(key)
+
+	// launch command loops
+	exit := make(chan error, runtime.GOMAXPROCS(0))
+	for routines := cap(exit); routines > 0; routines-- {
+		go func() {
+			for {
+				_, err := c.INCR(key)
+				if err != nil {
+					exit <- err
+					return
+				}
+			}
+		}()
+	}
+
+	// await full I/O activity
+	time.Sleep(2 * time.Millisecond)
+	t.Log(len(c.readQueue), "pending commands")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := c.CloseWait(ctx); err != nil {
+		t.Fatal("close got error:", err)
+	}
+	for i := 0; i < cap(exit); i++ {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("%d out of %d command routines stopped before timeout", i, cap(exit))
+		case err := <-exit:
+			if err != ErrClosed {
+				t.Errorf("got exit error %q, want %q", err, ErrClosed)
+			}
+		}
+	}
+
+	if err := c.CloseWait(ctx); err != nil {
+		t.Fatal("second close got error:", err)
+	}
+}
+
+func TestCloseWaitSlow(t *testing.T) {
+	t.Parallel()
+	c := NewClient(testClient.Addr, 0, 0)
+	if password != nil {
+		if err := c.AUTH(password); err != nil {
+			t.Fatal("AUTH error:", err)
+		}
+	}
+
+	sleepDone := make(chan error, 1)
+	go func() {
+		sleepDone <- c.DEBUGSLEEP(200 * time.Millisecond)
+	}()
+	time.Sleep(50 * time.Millisecond) // await submission
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := c.CloseWait(ctx); err != nil {
+		t.Fatal("close got error:", err)
+	}
+	if err := <-sleepDone; err != nil {
+		t.Error("DEBUG SLEEP during close got error:", err)
+	}
+	if _, err := c.GET("arbitrary"); err != ErrClosed {
+		t.Errorf("command after close got error %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestCloseWaitDeadline(t *testing.T) {
+	t.Parallel()
+	c := NewClient(testClient.Addr, 0, 0)
+	if password != nil {
+		if err := c.AUTH(password); err != nil {
+			t.Fatal("AUTH error:", err)
+		}
+	}
+	key := randomKey("test-list")
+
+	popDone := make(chan error, 1)
+	go func() {
+		_, _, err := c.BLMPOP(0, LEFT, 1, key)
+		popDone <- err
+	}()
+	time.Sleep(50 * time.Millisecond) // await submission
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := c.CloseWait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("close got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	select {
+	case err := <-popDone:
+		if err == nil {
+			t.Error("BLMPOP during close got no error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("BLMPOP still pending after close")
+	}
+}
+
+func TestCloseWaitQueueFull(t *testing.T) {
+	t.Parallel()
+	server := newSlowServer(t)
+	defer server.Close()
+	// no command timeout
+	c := NewClientWithOptions(server.Addr().String(), 0, 0, ClientOptions{QueueSize: 1})
+
+	// one response in progress, and one in the queue
+	for i := 0; i < 2; i++ {
+		go c.Do("ECHO", "1s")
+	}
+	time.Sleep(50 * time.Millisecond) // await submission
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := c.CloseWait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("close got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("close with full queue returned after %s", d)
+	}
+}
+
 func TestUnavailable(t *testing.T) {
 	t.Parallel()
 
@@ -129,6 +274,9 @@ func TestUnavailable(t *testing.T) {
 	} else if e.Op != "dial" {
 		t.Errorf(`got error for opperation %q, want "dial"`, e.Op)
 	}
+	if !errors.Is(err, ErrOffline) {
+		t.Errorf("got error %v, want an ErrOffline", err)
+	}
 
 	// let the Client retry…
 	time.Sleep(2 * connectTimeout)
@@ -174,6 +322,1031 @@ func TestWriteError(t *testing.T) {
 	}
 }
 
+func TestKeepAlive(t *testing.T) {
+	t.Parallel()
+	c := NewClient(testClient.Addr, time.Second, 0)
+	defer c.Close()
+	if password != nil {
+		if err := c.AUTH(password); err != nil {
+			t.Fatal("AUTH error:", err)
+		}
+	}
+	if err := c.PING(); err != nil {
+		t.Fatal("PING error:", err)
+	}
+	c.KeepAlive(0) // no effect
+	c.KeepAlive(10 * time.Millisecond)
+
+	// break connection silently
+	conn := <-c.connSem
+	if conn.Conn == nil {
+		c.connSem <- conn
+		t.Fatal("no connection")
+	}
+	conn.Close()
+	c.connSem <- conn
+
+	// keep-alive should detect the loss, and reconnect
+	time.Sleep(100 * time.Millisecond)
+	if err := c.PING(); err != nil {
+		t.Error("PING after connection loss got error:", err)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal("close error:", err)
+	}
+	// keep-alive should stop
+	time.Sleep(30 * time.Millisecond)
+	if err := c.PING(); err != ErrClosed {
+		t.Errorf("PING after close got error %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	t.Parallel()
+	c := NewClientWithOptions(testClient.Addr, time.Second, 0, ClientOptions{
+		NoDelay:        true,
+		KeepAlive:      time.Minute,
+		Linger:         -1,
+		ReadBufferSize: 64 << 10,
+		RESP3:          true,
+	})
+	defer c.Close()
+	if password != nil {
+		if err := c.AUTH(password); err != nil {
+			t.Fatal("AUTH error:", err)
+		}
+	}
+
+	key := randomKey("test")
+	value := strings.Repeat("x", 10000)
+	if err := c.SETString(key, value); err != nil {
+		t.Fatal("SET error:", err)
+	}
+	if got, _, err := c.GETString(key); err != nil {
+		t.Fatal("GET error:", err)
+	} else if got != value {
+		t.Errorf("GET got %d bytes, want %d", len(got), len(value))
+	}
+
+	conn := <-c.connSem
+	defer func() { c.connSem <- conn }()
+	if conn.idle == nil {
+		t.Fatal("read routine not idle")
+	}
+	if got := conn.idle.Size(); got != 64<<10 {
+		t.Errorf("got read buffer size %d, want %d", got, 64<<10)
+	}
+}
+
+func TestClientOptionsDB(t *testing.T) {
+	t.Parallel()
+	c := NewClientWithOptions(testClient.Addr, time.Second, 0, ClientOptions{Password: password, DB: 5})
+	defer c.Close()
+
+	key := randomKey("test")
+	if err := c.SETString(key, "v"); err != nil {
+		t.Fatal("SET in DB 5 error:", err)
+	}
+	defer c.DEL(key)
+	if _, ok, err := testClient.GETString(key); err != nil {
+		t.Error("GET in DB 0 error:", err)
+	} else if ok {
+		t.Error("GET in DB 0 got the value from DB 5")
+	}
+
+	// break the connection
+	conn := <-c.connSem
+	conn.Conn.Close()
+	c.connSem <- conn
+
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		got, _, err := c.GETString(key)
+		if err == nil {
+			if got != "v" {
+				t.Errorf(`GET after reconnect got %q, want "v"`, got)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("GET after reconnect error:", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNewClientURL(t *testing.T) {
+	t.Parallel()
+	var userinfo string
+	if password != nil {
+		userinfo = url.UserPassword("", string(password)).String() + "@"
+	}
+
+	c5, err := NewClientURL("redis://"+userinfo+testClient.Addr+"/5", time.Second, 0, ClientOptions{})
+	if err != nil {
+		t.Fatal("NewClientURL with DB 5 error:", err)
+	}
+	defer c5.Close()
+	c0, err := NewClientURL("redis://"+userinfo+testClient.Addr+"/0", time.Second, 0, ClientOptions{})
+	if err != nil {
+		t.Fatal("NewClientURL with DB 0 error:", err)
+	}
+	defer c0.Close()
+
+	key := randomKey("test")
+	if err := c5.SETString(key, "v"); err != nil {
+		t.Fatal("SET in DB 5 error:", err)
+	}
+	defer c5.DEL(key)
+	if got, _, err := c5.GETString(key); err != nil {
+		t.Error("GET in DB 5 error:", err)
+	} else if got != "v" {
+		t.Errorf(`GET in DB 5 got %q, want "v"`, got)
+	}
+	if _, ok, err := c0.GETString(key); err != nil {
+		t.Error("GET in DB 0 error:", err)
+	} else if ok {
+		t.Error("GET in DB 0 got the value from DB 5")
+	}
+
+	if _, err := NewClientURL("redis://"+testClient.Addr+"/x", time.Second, 0, ClientOptions{}); err == nil {
+		t.Error("NewClientURL with malformed DB got no error")
+	}
+}
+
+func TestSizeMax(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test-big")
+
+	// pages remain untouched, as the value is not copied
+	big := make([]byte, SizeMax+1)
+	if err := testClient.SET(key, big); !errors.Is(err, errSizeMax) {
+		t.Errorf("SET with %d bytes got error %v, want %v", len(big), err, errSizeMax)
+	}
+	if err := testClient.BytesMSET([][]byte{[]byte(key)}, [][]byte{big}); !errors.Is(err, errSizeMax) {
+		t.Errorf("MSET with %d bytes got error %v, want %v", len(big), err, errSizeMax)
+	}
+
+	// connection intact
+	if n, err := testClient.EXISTS(key); err != nil {
+		t.Error("EXISTS error:", err)
+	} else if n != 0 {
+		t.Errorf("EXISTS got %d, want 0", n)
+	}
+}
+
+// NewTLSProxy launches a TLS listener with a self-signed certificate for
+// localhost, which forwards each connection to addr. The drop function closes
+// all connections so far, and it returns the number of handshakes.
+func newTLSProxy(t *testing.T, addr string) (ln net.Listener, roots *x509.CertPool, drop func() int) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), crand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(crand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots = x509.NewCertPool()
+	roots.AddCert(cert)
+
+	ln, err = tls.Listen("tcp", "localhost:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	})
+	if err != nil {
+		t.Fatal("TLS proxy unavailable:", err)
+	}
+
+	var mutex sync.Mutex
+	var conns []net.Conn
+	var handshakes int
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			if err := conn.(*tls.Conn).Handshake(); err != nil {
+				conn.Close()
+				continue
+			}
+			backend, err := net.Dial("tcp", addr)
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			mutex.Lock()
+			conns = append(conns, conn, backend)
+			handshakes++
+			mutex.Unlock()
+			go io.Copy(conn, backend)
+			go io.Copy(backend, conn)
+		}
+	}()
+
+	return ln, roots, func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+		conns = nil
+		return handshakes
+	}
+}
+
+func TestTLS(t *testing.T) {
+	t.Parallel()
+	proxy, roots, drop := newTLSProxy(t, testClient.Addr)
+	defer proxy.Close()
+
+	addr := strings.Replace(proxy.Addr().String(), "127.0.0.1", "localhost", 1)
+	c := NewClientWithOptions(addr, time.Second, time.Second, ClientOptions{
+		TLSConfig: &tls.Config{RootCAs: roots},
+	})
+	defer c.Close()
+	if password != nil {
+		if err := c.AUTH(password); err != nil {
+			t.Fatal("AUTH error:", err)
+		}
+	}
+
+	key := randomKey("test-tls")
+	defer testClient.DEL(key)
+	if err := c.SETString(key, "v"); err != nil {
+		t.Fatal("SET over TLS error:", err)
+	}
+
+	if n := drop(); n != 1 {
+		t.Errorf("got %d TLS handshakes before drop, want 1", n)
+	}
+	// The first command may see the connection loss.
+	c.GET(key)
+	if v, _, err := c.GETString(key); err != nil {
+		t.Error("GET after reconnect error:", err)
+	} else if v != "v" {
+		t.Errorf(`GET after reconnect got %q, want "v"`, v)
+	}
+	if n := drop(); n != 2 {
+		t.Errorf("got %d TLS handshakes, want 2", n)
+	}
+}
+
+func TestNoQueueBlock(t *testing.T) {
+	t.Parallel()
+	c := NewClientWithOptions(testClient.Addr, time.Second, 0, ClientOptions{QueueSize: 1, NoQueueBlock: true})
+	defer c.Close()
+	if password != nil {
+		if err := c.AUTH(password); err != nil {
+			t.Fatal("AUTH error:", err)
+		}
+	}
+
+	// response in progress
+	sleepErr := make(chan error, 1)
+	go func() {
+		sleepErr <- c.DEBUGSLEEP(300 * time.Millisecond)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// response in queue
+	pingErr := make(chan error, 1)
+	go func() {
+		pingErr <- c.PING()
+	}()
+	for i := 0; c.QueueLen() != 1; i++ {
+		if i > 100 {
+			t.Fatalf("got queue length %d, want 1", c.QueueLen())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := c.PING(); err != ErrQueueFull {
+		t.Errorf("PING on full queue got error %v, want %v", err, ErrQueueFull)
+	}
+	if err := <-sleepErr; err != nil {
+		t.Error("DEBUG SLEEP error:", err)
+	}
+	if err := <-pingErr; err != nil {
+		t.Error("queued PING error:", err)
+	}
+	if err := c.PING(); err != nil {
+		t.Error("PING after queue drain error:", err)
+	}
+}
+
+func TestReplica(t *testing.T) {
+	t.Parallel()
+	c := NewClientWithOptions(testClient.Addr, time.Second, 0, ClientOptions{ReplicaAddr: testClient.Addr})
+	defer c.Close()
+	if password != nil {
+		if err := c.AUTH(password); err != nil {
+			t.Fatal("AUTH error:", err)
+		}
+	}
+
+	// await replica connect
+	states := make(chan StateChange, 4)
+	c.replica.Notify(states)
+	for s := range states {
+		if s.State == Online {
+			break
+		}
+	}
+
+	r := c.Replica()
+	if r == c {
+		t.Fatal("Replica got the primary, while online")
+	}
+	key := randomKey("test")
+	if err := c.SETString(key, "v"); err != nil {
+		t.Fatal("SET error:", err)
+	}
+	if got, _, err := r.GETString(key); err != nil {
+		t.Fatal("GET on replica error:", err)
+	} else if got != "v" {
+		t.Errorf(`GET on replica got %q, want "v"`, got)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal("close error:", err)
+	}
+	if s := r.State(); s.State != Closed {
+		t.Errorf("replica state after close got %s, want %s", s.State, Closed)
+	}
+}
+
+func TestReplicaReads(t *testing.T) {
+	t.Parallel()
+	replica, counts := newDropServer(t, 0)
+	defer replica.Close()
+	c := NewClientWithOptions(testClient.Addr, time.Second, 0, ClientOptions{
+		Password:     password,
+		ReplicaAddr:  replica.Addr().String(),
+		ReplicaReads: true,
+	})
+	defer c.Close()
+
+	// await replica connect
+	states := make(chan StateChange, 4)
+	c.replica.Notify(states)
+	for s := range states {
+		if s.State == Online {
+			break
+		}
+	}
+
+	key := randomKey("test")
+	if got, _, err := c.GETString(key); err != nil {
+		t.Fatal("GET error:", err)
+	} else if got != "v" {
+		t.Errorf(`GET got %q, want "v" from the replica`, got)
+	}
+	if n := counts("GET"); n != 1 {
+		t.Errorf("replica got %d GETs, want 1", n)
+	}
+
+	// writes and Do on primary
+	if err := c.SETString(key, "w"); err != nil {
+		t.Fatal("SET error:", err)
+	}
+	defer c.DEL(key)
+	if v, err := c.Do("GET", key); err != nil {
+		t.Fatal("GET with Do error:", err)
+	} else if v.Str() != "w" {
+		t.Errorf(`GET with Do got %q, want "w" from the primary`, v.Str())
+	}
+	if n := counts("SET") + counts("GET"); n != 1 {
+		t.Errorf("replica got %d SETs and GETs, want only the first GET", n)
+	}
+}
+
+func TestReplicaFallback(t *testing.T) {
+	t.Parallel()
+	c := NewClientWithOptions(testClient.Addr, time.Second, time.Millisecond, ClientOptions{ReplicaAddr: "doesnotexist.example.com:6379"})
+	defer c.Close()
+
+	if r := c.Replica(); r != c {
+		t.Error("Replica got the replica, while offline")
+	}
+}
+
+func TestNotify(t *testing.T) {
+	t.Parallel()
+	c := NewClient(testClient.Addr, time.Second, 0)
+	defer c.Close()
+	states := make(chan StateChange, 8)
+	c.Notify(states)
+
+	next := func() StateChange {
+		t.Helper()
+		select {
+		case s := <-states:
+			return s
+		case <-time.After(time.Second):
+			t.Fatal("state change timeout")
+			panic("unreachable")
+		}
+	}
+
+	s := next()
+	if s.State == Offline && s.Err == nil {
+		// initial state; connect pending
+		s = next()
+	}
+	if s.State != Online {
+		t.Fatalf("got state %s (error %v), want %s", s.State, s.Err, Online)
+	}
+
+	// break connection silently
+	conn := <-c.connSem
+	if conn.Conn == nil {
+		c.connSem <- conn
+		t.Fatal("no connection")
+	}
+	conn.Close()
+	c.connSem <- conn
+
+	if err := c.PING(); err == nil {
+		t.Error("PING on closed connection got no error")
+	}
+	if s := next(); s.State != Offline || s.Err == nil {
+		t.Errorf("after connection loss got state %s with error %v, want %s with error", s.State, s.Err, Offline)
+	}
+	if s := next(); s.State != Online {
+		t.Errorf("after reconnect got state %s with error %v, want %s", s.State, s.Err, Online)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal("close error:", err)
+	}
+	if s := next(); s.State != Closed {
+		t.Errorf("after close got state %s, want %s", s.State, Closed)
+	}
+	if s := c.State(); s.State != Closed {
+		t.Errorf("State after close got %s, want %s", s.State, Closed)
+	}
+}
+
+// NewSlowServer launches a server which replies to ECHO after the duration in
+// its argument, one command at a time per connection.
+func newSlowServer(t *testing.T) net.Listener {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal("slow server unavailable:", err)
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					n, err := readArrayLen(r)
+					if err != nil {
+						return
+					}
+					args := make([]string, n)
+					for i := range args {
+						args[i], err = decodeBlobString(r)
+						if err != nil {
+							return
+						}
+					}
+					if len(args) != 2 || args[0] != "ECHO" {
+						fmt.Fprintf(conn, "-ERR unknown command\r\n")
+						continue
+					}
+					delay, _ := time.ParseDuration(args[1])
+					time.Sleep(delay)
+					fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(args[1]), args[1])
+				}
+			}()
+		}
+	}()
+	return ln
+}
+
+// NewPushServer launches a server which replies to HELLO with an empty map,
+// and to ECHO with the argument, preceded by an invalidate push of the argument.
+func newPushServer(t *testing.T) net.Listener {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal("push server unavailable:", err)
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				w := bufio.NewWriter(conn)
+				for {
+					n, err := readArrayLen(r)
+					if err != nil {
+						return
+					}
+					args := make([]string, n)
+					for i := range args {
+						args[i], err = decodeBlobString(r)
+						if err != nil {
+							return
+						}
+					}
+					switch {
+					case len(args) == 2 && args[0] == "HELLO":
+						w.WriteString("%0\r\n")
+					case len(args) == 2 && args[0] == "ECHO":
+						fmt.Fprintf(w, ">2\r\n$10\r\ninvalidate\r\n*1\r\n$%d\r\n%s\r\n", len(args[1]), args[1])
+						fmt.Fprintf(w, "$%d\r\n%s\r\n", len(args[1]), args[1])
+					default:
+						w.WriteString("-ERR unknown command\r\n")
+					}
+					if r.Buffered() == 0 {
+						if err := w.Flush(); err != nil {
+							return
+						}
+					}
+				}
+			}()
+		}
+	}()
+	return ln
+}
+
+func TestHandlePush(t *testing.T) {
+	t.Parallel()
+	server := newPushServer(t)
+	defer server.Close()
+	c := NewClientWithOptions(server.Addr().String(), time.Second, 0, ClientOptions{RESP3: true})
+	defer c.Close()
+
+	var got []string
+	c.HandlePush("invalidate", func(push Reply) {
+		a := push.Array()
+		if len(a) != 2 {
+			t.Errorf("got push %s, want kind and keys", push)
+			return
+		}
+		for _, key := range a[1].Array() {
+			got = append(got, key.Str())
+		}
+	})
+
+	if v, err := c.Do("ECHO", "a"); err != nil {
+		t.Fatal("ECHO error:", err)
+	} else if v.Str() != "a" {
+		t.Errorf("ECHO got %s, want a", v)
+	}
+
+	p := c.Pipeline()
+	b := p.Queue("ECHO", "b")
+	x := p.Queue("ECHO", "c")
+	if err := p.Exec(); err != nil {
+		t.Fatal("pipeline error:", err)
+	}
+	if v, err := b.Str(); err != nil || v != "b" {
+		t.Errorf("pipelined ECHO got %q, %v; want b", v, err)
+	}
+	if v, err := x.Str(); err != nil || v != "c" {
+		t.Errorf("pipelined ECHO got %q, %v; want c", v, err)
+	}
+
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got invalidations %q, want %q", got, want)
+	}
+
+	// without handler
+	c.HandlePush("invalidate", nil)
+	if v, err := c.Do("ECHO", "d"); err != nil {
+		t.Fatal("ECHO error:", err)
+	} else if v.Str() != "d" {
+		t.Errorf("ECHO got %s, want d", v)
+	}
+	if len(got) != 3 {
+		t.Errorf("got invalidations %q after handler removal", got)
+	}
+}
+
+func TestReadDeadline(t *testing.T) {
+	t.Parallel()
+	server := newSlowServer(t)
+	defer server.Close()
+	c := NewClient(server.Addr().String(), 100*time.Millisecond, 0)
+	defer c.Close()
+
+	echo := func(delay string) error {
+		v, err := c.Do("ECHO", delay)
+		if err != nil {
+			return err
+		}
+		if v.Str() != delay {
+			return fmt.Errorf("ECHO %q got %s", delay, v)
+		}
+		return nil
+	}
+	// queue executes two commands with a 10 ms offset
+	queue := func(first, second string) (err1, err2 error) {
+		done := make(chan error)
+		go func() { done <- echo(first) }()
+		time.Sleep(10 * time.Millisecond)
+		err2 = echo(second)
+		return <-done, err2
+	}
+	isTimeout := func(err error) bool {
+		var e net.Error
+		return errors.As(err, &e) && e.Timeout()
+	}
+
+	if err := echo("0s"); err != nil {
+		t.Fatal("connect:", err)
+	}
+
+	t.Run("QueueWaitExcluded", func(t *testing.T) {
+		err1, err2 := queue("60ms", "70ms")
+		if err1 != nil {
+			t.Error("first command got error:", err1)
+		}
+		if err2 != nil {
+			t.Error("second command got error:", err2)
+		}
+	})
+
+	t.Run("SlowFirst", func(t *testing.T) {
+		err1, err2 := queue("300ms", "0s")
+		if !isTimeout(err1) {
+			t.Errorf("first command got error %v, want a timeout", err1)
+		}
+		if err2 != ErrConnLost {
+			t.Errorf("second command got error %v, want %v", err2, ErrConnLost)
+		}
+		if err := echo("1ms"); err != nil {
+			t.Error("command after timeout got error:", err)
+		}
+	})
+
+	t.Run("SlowSecond", func(t *testing.T) {
+		err1, err2 := queue("0s", "300ms")
+		if err1 != nil {
+			t.Error("first command got error:", err1)
+		}
+		if !isTimeout(err2) {
+			t.Errorf("second command got error %v, want a timeout", err2)
+		}
+		if err := echo("1ms"); err != nil {
+			t.Error("command after timeout got error:", err)
+		}
+	})
+}
+
+// NewDropServer launches a server which closes the connection without reply on
+// each of the first drops commands. Commands after that get an OK for SET, and
+// "v" for GET. The counts have the number of receptions per command name.
+func newDropServer(t *testing.T, drops int) (ln net.Listener, counts func(name string) int) {
+	return newDropServerOn(t, drops, "")
+}
+
+// NewDropServerOn is like newDropServer, but the drops apply to commands with
+// dropName only, when not empty. Transaction commands get an OK, with an empty
+// array for EXEC. XGROUP gets an OK, and XREADGROUP gets null.
+func newDropServerOn(t *testing.T, drops int, dropName string) (ln net.Listener, counts func(name string) int) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal("drop server unavailable:", err)
+	}
+
+	var mutex sync.Mutex
+	received := make(map[string]int)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					n, err := readArrayLen(r)
+					if err != nil || n == 0 {
+						return
+					}
+					name, err := decodeBlobString(r)
+					if err != nil {
+						return
+					}
+					for ; n > 1; n-- {
+						if _, err := decodeBlobString(r); err != nil {
+							return
+						}
+					}
+
+					mutex.Lock()
+					received[name]++
+					drop := drops > 0 && (dropName == "" || dropName == name)
+					if drop {
+						drops--
+					}
+					mutex.Unlock()
+					if drop {
+						return
+					}
+					switch name {
+					case "AUTH", "SET", "WATCH", "UNWATCH", "MULTI":
+						fmt.Fprintf(conn, "+OK\r\n")
+					case "XGROUP":
+						fmt.Fprintf(conn, "+OK\r\n")
+					case "EXEC":
+						fmt.Fprintf(conn, "*0\r\n")
+					case "XREADGROUP":
+						fmt.Fprintf(conn, "*-1\r\n")
+					case "GET":
+						fmt.Fprintf(conn, "$1\r\nv\r\n")
+					default:
+						fmt.Fprintf(conn, "-ERR unknown command\r\n")
+					}
+				}
+			}()
+		}
+	}()
+
+	return ln, func(name string) int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return received[name]
+	}
+}
+
+func TestReadRetries(t *testing.T) {
+	t.Parallel()
+	server, counts := newDropServer(t, 4)
+	defer server.Close()
+	c := NewClientWithOptions(server.Addr().String(), time.Second, 0, ClientOptions{ReadRetries: 2})
+	defer c.Close()
+
+	// first drop
+	if err := c.SET("k", []byte("v")); err == nil {
+		t.Error("SET on dropped connection got no error")
+	}
+	if n := counts("SET"); n != 1 {
+		t.Errorf("SET executed %d times, want 1 (no retry)", n)
+	}
+
+	// three drops: the initial attempt plus both retries
+	if _, err := c.GET("k"); err == nil {
+		t.Error("GET beyond retry limit got no error")
+	}
+	if n := counts("GET"); n != 3 {
+		t.Errorf("GET executed %d times, want 3", n)
+	}
+
+	if v, err := c.GET("k"); err != nil {
+		t.Error("GET error:", err)
+	} else if string(v) != "v" {
+		t.Errorf(`GET got %q, want "v"`, v)
+	}
+}
+
+func TestReadRetriesRecover(t *testing.T) {
+	t.Parallel()
+	server, counts := newDropServer(t, 1)
+	defer server.Close()
+	c := NewClientWithOptions(server.Addr().String(), time.Second, 0, ClientOptions{ReadRetries: 1})
+	defer c.Close()
+
+	if v, ok, err := c.GETString("k"); err != nil {
+		t.Error("GET error:", err)
+	} else if !ok || v != "v" {
+		t.Errorf(`GET got %q, %t, want "v", true`, v, ok)
+	}
+	if n := counts("GET"); n != 2 {
+		t.Errorf("GET executed %d times, want 2", n)
+	}
+}
+
+func TestPasswordReconnect(t *testing.T) {
+	t.Parallel()
+	// drop AUTH on the first connect, and GET on the second
+	server, counts := newDropServer(t, 2)
+	defer server.Close()
+	c := NewClientWithOptions(server.Addr().String(), time.Second, 0, ClientOptions{Password: []byte("secret")})
+	defer c.Close()
+
+	var drops int
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		_, err := c.GET("k")
+		if err == nil {
+			break
+		}
+		if err != ErrOffline {
+			drops++
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("GET error:", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if drops != 1 {
+		t.Errorf("got %d GET errors other than ErrOffline, want 1", drops)
+	}
+	if n := counts("AUTH"); n != 3 {
+		t.Errorf("AUTH executed %d times, want 3 (one per connect)", n)
+	}
+}
+
+func TestHandshakeErrorClose(t *testing.T) {
+	t.Parallel()
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal("listen error:", err)
+	}
+	defer ln.Close()
+
+	// The server rejects AUTH, and it awaits the close.
+	closed := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			closed <- err
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(time.Second))
+		if _, err := conn.Read(make([]byte, 64)); err != nil {
+			closed <- err
+			return
+		}
+		if _, err := conn.Write([]byte("-ERR rejected\r\n")); err != nil {
+			closed <- err
+			return
+		}
+		// EOF, or a reset with linger zero
+		_, err = conn.Read(make([]byte, 64))
+		if e, ok := err.(net.Error); ok && e.Timeout() {
+			closed <- err
+			return
+		}
+		closed <- nil
+	}()
+
+	c := NewClientWithOptions(ln.Addr().String(), time.Second, 0, ClientOptions{Password: []byte("secret")})
+	defer c.Close()
+
+	if err := <-closed; err != nil {
+		t.Error("connection not closed after handshake error:", err)
+	}
+}
+
+func TestDoContext(t *testing.T) {
+	t.Parallel()
+	server := newSlowServer(t)
+	defer server.Close()
+	c := NewClient(server.Addr().String(), time.Second, 0)
+	defer c.Close()
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.DoContext(cancelled, "ECHO", "0s"); err != context.Canceled {
+		t.Errorf("ECHO with cancelled context got error %v, want %v", err, context.Canceled)
+	}
+
+	// slow response in line
+	slowDone := make(chan error)
+	go func() {
+		_, err := c.Do("ECHO", "200ms")
+		slowDone <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.DoContext(ctx, "ECHO", "0s"); err != context.DeadlineExceeded {
+		t.Errorf("ECHO behind slow response got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if d := time.Since(start); d > 150*time.Millisecond {
+		t.Errorf("ECHO behind slow response returned after %s", d)
+	}
+	if err := <-slowDone; err != nil {
+		t.Error("slow ECHO error:", err)
+	}
+
+	// The abandoned response must not end up with another command.
+	if v, err := c.DoContext(context.Background(), "ECHO", "1ms"); err != nil {
+		t.Error("ECHO after cancel error:", err)
+	} else if v.Str() != "1ms" {
+		t.Errorf(`ECHO after cancel got %s, want "1ms"`, v)
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	t.Parallel()
+	server := newSlowServer(t)
+	defer server.Close()
+	c := NewClient(server.Addr().String(), time.Second, 0)
+	defer c.Close()
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.WithContext(cancelled).GET("k"); err != context.Canceled {
+		t.Errorf("GET with cancelled context got error %v, want %v", err, context.Canceled)
+	}
+	// no effect on c
+	if _, err := c.GET("k"); err == nil || err == context.Canceled {
+		t.Errorf("GET got error %v, want the unknown command of the server", err)
+	}
+
+	// slow response in line
+	slowDone := make(chan error)
+	go func() {
+		_, err := c.Do("ECHO", "200ms")
+		slowDone <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	p := c.WithContext(ctx).Pipeline()
+	p.Queue("ECHO", "0s")
+	p.Queue("ECHO", "0s")
+	if err := p.Exec(); err != context.DeadlineExceeded {
+		t.Errorf("Pipeline behind slow response got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := <-slowDone; err != nil {
+		t.Error("slow ECHO error:", err)
+	}
+
+	// The abandoned responses must not end up with another command.
+	if v, err := c.Do("ECHO", "1ms"); err != nil {
+		t.Error("ECHO after cancel error:", err)
+	} else if v.Str() != "1ms" {
+		t.Errorf(`ECHO after cancel got %s, want "1ms"`, v)
+	}
+}
+
+func TestMaxConnLifetime(t *testing.T) {
+	t.Parallel()
+	server := newSlowServer(t)
+	defer server.Close()
+	c := NewClientWithOptions(server.Addr().String(), time.Second, 0, ClientOptions{MaxConnLifetime: 50 * time.Millisecond})
+	defer c.Close()
+
+	localAddr := func() string {
+		conn := <-c.connSem
+		defer func() { c.connSem <- conn }()
+		if conn.Conn == nil {
+			return ""
+		}
+		return conn.LocalAddr().String()
+	}
+
+	if _, err := c.Do("ECHO", "0s"); err != nil {
+		t.Fatal("ECHO error:", err)
+	}
+	first := localAddr()
+
+	// response in progress while the lifetime expires
+	done := make(chan error)
+	go func() {
+		v, err := c.Do("ECHO", "100ms")
+		if err == nil && v.Str() != "100ms" {
+			err = fmt.Errorf("got reply %s", v)
+		}
+		done <- err
+	}()
+	time.Sleep(60 * time.Millisecond)
+	if v, err := c.Do("ECHO", "1ms"); err != nil {
+		t.Error("ECHO after lifetime error:", err)
+	} else if v.Str() != "1ms" {
+		t.Errorf("ECHO after lifetime got %s, want 1ms", v)
+	}
+	if err := <-done; err != nil {
+		t.Error("ECHO in progress during renewal:", err)
+	}
+
+	if second := localAddr(); second == first {
+		t.Errorf("connection %s remains in use after lifetime", first)
+	}
+}
+
 // Note that testClient must recover for the next test to pass.
 func TestReadError(t *testing.T) {
 	timeout := time.After(time.Second)
@@ -239,14 +1412,14 @@ func TestRedisError(t *testing.T) {
 		t.Errorf("APPEND %q %q got length %d, want %d", key, value, newLen, len(value))
 	}
 
-	_, err = testClient.BytesDELArgs()
+	_, err = testClient.INCR(key)
 	switch e := err.(type) {
 	default:
-		t.Errorf("DEL without arguments got error %v, want a RedisError", err)
+		t.Errorf("INCR %q got error %v, want a RedisError", key, err)
 	case ServerError:
-		t.Log("DEL without arguments got error:", e)
+		t.Log("INCR on non-integer got error:", e)
 		if got := e.Prefix(); got != "ERR" {
-			t.Errorf(`error %q got prefix %q, want "ERR"`, err, got)
+			t.Errorf(`INCR %q error %q got prefix %q, want "ERR"`, key, err, got)
 		}
 	}
 
@@ -273,6 +1446,70 @@ func TestRedisError(t *testing.T) {
 	}
 }
 
+// NewPONGServer replies with PONG to any command, with responses buffered for
+// as long as pipelined commands are pending.
+func newPONGServer(tb testing.TB) net.Listener {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		tb.Fatal("PONG server unavailable:", err)
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				w := bufio.NewWriter(conn)
+				for {
+					n, err := readArrayLen(r)
+					if err != nil {
+						return
+					}
+					for ; n > 0; n-- {
+						if _, err := decodeBlobBytes(r); err != nil {
+							return
+						}
+					}
+					w.WriteString("+PONG\r\n")
+					if r.Buffered() == 0 {
+						if err := w.Flush(); err != nil {
+							return
+						}
+					}
+				}
+			}()
+		}
+	}()
+	return ln
+}
+
+// BenchmarkCoalescing measures concurrent submission against a local server,
+// which isolates the client overhead, syscalls included.
+func BenchmarkCoalescing(b *testing.B) {
+	server := newPONGServer(b)
+	defer server.Close()
+	c := NewClient(server.Addr().String(), time.Second, 0)
+	defer c.Close()
+
+	for _, routines := range []int{1, 8, 64} {
+		b.Run(fmt.Sprintf("%droutines", routines), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetParallelism(routines)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if err := c.PING(); err != nil {
+						b.Fatal("PING error:", err)
+					}
+				}
+			})
+		})
+	}
+}
+
 func BenchmarkSimpleString(b *testing.B) {
 	key := randomKey("bench")
 	defer func() {
@@ -353,6 +1590,16 @@ func BenchmarkBulk(b *testing.B) {
 		}
 	}
 
+	getInto := func(b *testing.B, size int, buf []byte) {
+		n, _, err := benchClient.GETInto(key, buf)
+		if err != nil {
+			b.Fatal("error:", err)
+		}
+		if n != size {
+			b.Fatalf("got %d bytes, want %d", n, size)
+		}
+	}
+
 	for _, size := range []int{8, 800, 24000} {
 		b.Run(fmt.Sprintf("%dB", size), func(b *testing.B) {
 			if err := benchClient.SET(key, make([]byte, size)); err != nil {
@@ -372,6 +1619,13 @@ func BenchmarkBulk(b *testing.B) {
 						getString(b, size)
 					}
 				})
+				b.Run("into", func(b *testing.B) {
+					b.SetBytes(int64(size))
+					buf := make([]byte, size)
+					for i := 0; i < b.N; i++ {
+						getInto(b, size, buf)
+					}
+				})
 			})
 
 			b.Run("parallel", func(b *testing.B) {
@@ -391,6 +1645,15 @@ func BenchmarkBulk(b *testing.B) {
 						}
 					})
 				})
+				b.Run("into", func(b *testing.B) {
+					b.SetBytes(int64(size))
+					b.RunParallel(func(pb *testing.PB) {
+						buf := make([]byte, size)
+						for pb.Next() {
+							getInto(b, size, buf)
+						}
+					})
+				})
 			})
 		})
 	}
@@ -3,11 +3,15 @@ package redis
 import (
 	"errors"
 	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 )
 
-// Flags For SETOptions
+// Flags For SETOptions and GETEXOptions
 const (
 	// NX only sets the key if it does not already exist.
 	NX = 1 << iota
@@ -18,21 +22,34 @@ const (
 	EX
 	// PX sets an expire time, in milliseconds.
 	PX
+	// EXAT sets an expire timestamp, in seconds.
+	EXAT
+	// PXAT sets an expire timestamp, in milliseconds.
+	PXAT
+	// KEEPTTL retains any expire time associated with the key.
+	KEEPTTL
+	// PERSIST removes any expire time associated with the key.
+	PERSIST
 )
 
 // SETOptions are extra arguments for the SET command.
 type SETOptions struct {
-	// Composotion of NX, XX, EX or PX.
+	// Composotion of NX, XX, EX, PX, EXAT, PXAT or KEEPTTL.
 	Flags uint
 
 	// The value is rounded to seconds with the EX flag,
 	// and milliseconds with PX. Non-zero values without
 	// expiry Flags are rejected to prevent mistakes.
 	Expire time.Duration
+
+	// The value is rounded to seconds with the EXAT flag,
+	// and milliseconds with PXAT. Non-zero values without
+	// timestamp Flags are rejected to prevent mistakes.
+	ExpireAt time.Time
 }
 
 func (o *SETOptions) args() (existArg, expireArg string, expire int64, err error) {
-	if unknown := o.Flags &^ (NX | XX | EX | PX); unknown != 0 {
+	if unknown := o.Flags &^ (NX | XX | EX | PX | EXAT | PXAT | KEEPTTL); unknown != 0 {
 		return "", "", 0, fmt.Errorf("redis: unknown flags %#x", unknown)
 	}
 
@@ -47,49 +64,233 @@ func (o *SETOptions) args() (existArg, expireArg string, expire int64, err error
 		return "", "", 0, errors.New("redis: combination of NX and XX not allowed")
 	}
 
-	switch o.Flags & (EX | PX) {
-	case 0:
-		if o.Expire != 0 {
-			return "", "", 0, errors.New("redis: expire time without EX nor PX not allowed")
+	expireArg, expire, err = expireArgs(o.Flags, o.Expire, o.ExpireAt)
+	return
+}
+
+// ExpireArgs validates the expiry flags against their respective values.
+func expireArgs(flags uint, expire time.Duration, expireAt time.Time) (arg string, n int64, err error) {
+	switch flags & (EX | PX | EXAT | PXAT | KEEPTTL | PERSIST) {
+	case 0, KEEPTTL, PERSIST:
+		if expire != 0 {
+			return "", 0, errors.New("redis: expire time without EX nor PX not allowed")
+		}
+		if !expireAt.IsZero() {
+			return "", 0, errors.New("redis: expire timestamp without EXAT nor PXAT not allowed")
+		}
+		switch {
+		case flags&KEEPTTL != 0:
+			arg = "KEEPTTL"
+		case flags&PERSIST != 0:
+			arg = "PERSIST"
+		}
+	case EX, PX:
+		if !expireAt.IsZero() {
+			return "", 0, errors.New("redis: expire timestamp without EXAT nor PXAT not allowed")
+		}
+		if flags&EX != 0 {
+			arg = "EX"
+			n = int64(expire / time.Second)
+		} else {
+			arg = "PX"
+			n = int64(expire / time.Millisecond)
+		}
+	case EXAT, PXAT:
+		if expire != 0 {
+			return "", 0, errors.New("redis: expire time without EX nor PX not allowed")
+		}
+		if flags&EXAT != 0 {
+			arg = "EXAT"
+			n = expireAt.Unix()
+		} else {
+			arg = "PXAT"
+			n = expireAt.UnixNano() / int64(time.Millisecond)
 		}
-	case EX:
-		expireArg = "EX"
-		expire = int64(o.Expire / time.Second)
-	case PX:
-		expireArg = "PX"
-		expire = int64(o.Expire / time.Millisecond)
 	default:
-		return "", "", 0, errors.New("redis: combination of EX and PX not allowed")
+		return "", 0, errors.New("redis: combination of EX, PX, EXAT, PXAT, KEEPTTL and PERSIST not allowed")
 	}
 	return
 }
 
+// GETEXOptions are extra arguments for the GETEX command.
+type GETEXOptions struct {
+	// Composotion of EX, PX, EXAT, PXAT or PERSIST.
+	Flags uint
+
+	// The value is rounded to seconds with the EX flag,
+	// and milliseconds with PX. Non-zero values without
+	// expiry Flags are rejected to prevent mistakes.
+	Expire time.Duration
+
+	// The value is rounded to seconds with the EXAT flag,
+	// and milliseconds with PXAT. Non-zero values without
+	// timestamp Flags are rejected to prevent mistakes.
+	ExpireAt time.Time
+}
+
+func (o *GETEXOptions) args() (expireArg string, expire int64, err error) {
+	if unknown := o.Flags &^ (EX | PX | EXAT | PXAT | PERSIST); unknown != 0 {
+		return "", 0, fmt.Errorf("redis: unknown flags %#x", unknown)
+	}
+	return expireArgs(o.Flags, o.Expire, o.ExpireAt)
+}
+
+// newSETRequest returns a request with room for the SET arguments, which are
+// to be completed with addSETArgs after the key and value.
+func newSETRequest(existArg, expireArg string, get bool) *request {
+	n := 3
+	if existArg != "" {
+		n++
+	}
+	switch expireArg {
+	case "":
+		break
+	case "KEEPTTL":
+		n++
+	default:
+		n += 2
+	}
+	if get {
+		n++
+	}
+	return newRequestSize(n, "\r\n$3\r\nSET\r\n$")
+}
+
+// PING executes <https://redis.io/commands/ping>, which makes for a health
+// check. Any reply other than PONG is an error.
+func (c *Client) PING() error {
+	r := newRequest("*1\r\n$4\r\nPING\r\n")
+	s, err := c.commandSimpleString(r)
+	if err != nil {
+		return err
+	}
+	if s != "PONG" {
+		return fmt.Errorf("%w; PING got %q", errProtocol, s)
+	}
+	return nil
+}
+
 // AUTH executes <https://redis.io/commands/auth> in a persistent way, even when
 // the return is in error. Any following command execution runs on a connection
-// with password authentication. A nil value resets the password (to none).
+// with password authentication, for the User from ClientOptions, if any. A nil
+// value resets the password (to none). The replica, if any, gets the same
+// password.
 func (c *Client) AUTH(password []byte) error {
 	c.password.Store(password)
+	if c.replica != nil {
+		c.replica.AUTH(password)
+	}
 
 	var r *request
 	if password == nil {
 		r = newRequest("*1\r\n$4\r\nQUIT\r\n")
 	} else {
-		r = newRequest("*2\r\n$4\r\nAUTH\r\n$")
-		r.addBytes(password)
+		r = newAUTHRequest(c.options.User, password)
 	}
 	return c.commandOKAndReconnect(r)
 }
 
+// NewAUTHRequest returns the AUTH command, with the user name when not empty.
+func newAUTHRequest(user string, password []byte) *request {
+	if user == "" {
+		r := newRequest("*2\r\n$4\r\nAUTH\r\n$")
+		r.addBytes(password)
+		return r
+	}
+	r := newRequest("*3\r\n$4\r\nAUTH\r\n$")
+	r.addStringBytes(user, password)
+	return r
+}
+
 // SELECT executes <https://redis.io/commands/select> in a persistent way, even
 // when the return is in error. Any following command executions apply to this
-// database selection, reconnects included.
+// database selection, reconnects included. The replica, if any, gets the same
+// selection.
 func (c *Client) SELECT(db int64) error {
 	atomic.StoreInt64(&c.db, db)
+	if c.replica != nil {
+		c.replica.SELECT(db)
+	}
 	r := newRequest("*2\r\n$6\r\nSELECT\r\n$")
 	r.addDecimal(db)
 	return c.commandOKOrReconnect(r)
 }
 
+// CLIENTSETNAME executes <https://redis.io/commands/client-setname> in a
+// persistent way, even when the return is in error. Any following command
+// execution runs on a connection with the name, reconnects included. The empty
+// string removes the name. The replica, if any, gets the same name. Names with
+// spaces, newlines or other special characters are rejected without execution,
+// as they would fail any reconnect.
+func (c *Client) CLIENTSETNAME(name string) error {
+	for i := 0; i < len(name); i++ {
+		if name[i] < '!' || name[i] > '~' {
+			return errClientName
+		}
+	}
+	c.name.Store(name)
+	if c.replica != nil {
+		c.replica.CLIENTSETNAME(name)
+	}
+	r := newRequest("*3\r\n$6\r\nCLIENT\r\n$7\r\nSETNAME\r\n$")
+	r.addString(name)
+	return c.commandOK(r)
+}
+
+// CLIENTGETNAME executes <https://redis.io/commands/client-getname>. The
+// return is the empty string when no name is set, regardless of whether the
+// server replies with null (before Redis 7.0) or with an empty string.
+func (c *Client) CLIENTGETNAME() (string, error) {
+	name, _, err := c.commandBlobString(newRequest("*2\r\n$6\r\nCLIENT\r\n$7\r\nGETNAME\r\n"))
+	return name, err
+}
+
+// CLIENTID executes <https://redis.io/commands/client-id>. Note that each
+// reconnect gets a new identifier.
+func (c *Client) CLIENTID() (int64, error) {
+	return c.commandInteger(newRequest("*2\r\n$6\r\nCLIENT\r\n$2\r\nID\r\n"))
+}
+
+// CLIENTNOEVICT executes <https://redis.io/commands/client-no-evict>, which
+// requires Redis 7.0. The mode applies to the current connection only, i.e.,
+// it does not persist on reconnects.
+func (c *Client) CLIENTNOEVICT(on bool) error {
+	if on {
+		return c.commandOK(newRequest("*3\r\n$6\r\nCLIENT\r\n$8\r\nNO-EVICT\r\n$2\r\nON\r\n"))
+	}
+	return c.commandOK(newRequest("*3\r\n$6\r\nCLIENT\r\n$8\r\nNO-EVICT\r\n$3\r\nOFF\r\n"))
+}
+
+// CommandSpec is the static description of a command, as reported by COMMAND
+// INFO.
+type CommandSpec struct {
+	// Name is in lower case. An unknown command has the empty string, and
+	// zero for all other fields.
+	Name string
+	// Arity counts the command name as an argument. Negative values are
+	// the minimum for commands with a variable number of arguments.
+	Arity int64
+	// Flags are like "write", "readonly" and "fast".
+	Flags []string
+	// FirstKey, LastKey and KeyStep are the positions of key arguments.
+	// A negative LastKey counts from the end.
+	FirstKey, LastKey, KeyStep int64
+}
+
+// COMMANDCOUNT executes <https://redis.io/commands/command-count>.
+func (c *Client) COMMANDCOUNT() (int64, error) {
+	return c.commandInteger(newRequest("*2\r\n$7\r\nCOMMAND\r\n$5\r\nCOUNT\r\n"))
+}
+
+// COMMANDINFO executes <https://redis.io/commands/command-info>. The return
+// has one entry per name, in order, with a zero CommandSpec for each unknown
+// command.
+func (c *Client) COMMANDINFO(names ...string) ([]CommandSpec, error) {
+	r := newRequestSize(2+len(names), "\r\n$7\r\nCOMMAND\r\n$4\r\nINFO")
+	r.addStringList(names)
+	return c.commandCommandSpecs(r)
+}
+
 // MOVE executes <https://redis.io/commands/move>.
 func (c *Client) MOVE(key string, db int64) (bool, error) {
 	r := newRequest("*3\r\n$4\r\nMOVE\r\n$")
@@ -106,7 +307,231 @@ func (c *Client) BytesMOVE(key []byte, db int64) (bool, error) {
 	return n != 0, err
 }
 
-// FLUSHDB executes <https://redis.io/commands/flushdb>.
+// COPY executes <https://redis.io/commands/copy>. Replace applies REPLACE. The
+// return is false if src does not exist, or if dst exists without replace.
+func (c *Client) COPY(src, dst string, replace bool) (bool, error) {
+	var r *request
+	if replace {
+		r = newRequest("*4\r\n$4\r\nCOPY\r\n$")
+		r.addStringStringString(src, dst, "REPLACE")
+	} else {
+		r = newRequest("*3\r\n$4\r\nCOPY\r\n$")
+		r.addStringString(src, dst)
+	}
+	n, err := c.commandInteger(r)
+	return n != 0, err
+}
+
+// BytesCOPY executes <https://redis.io/commands/copy>. Replace applies REPLACE.
+// The return is false if src does not exist, or if dst exists without replace.
+func (c *Client) BytesCOPY(src, dst []byte, replace bool) (bool, error) {
+	var r *request
+	if replace {
+		r = newRequest("*4\r\n$4\r\nCOPY\r\n$")
+		r.addBytesBytesString(src, dst, "REPLACE")
+	} else {
+		r = newRequest("*3\r\n$4\r\nCOPY\r\n$")
+		r.addBytesBytes(src, dst)
+	}
+	n, err := c.commandInteger(r)
+	return n != 0, err
+}
+
+// COPYToDB executes <https://redis.io/commands/copy> with a destination
+// database. Replace applies REPLACE. The return is false if src does not exist,
+// or if dst exists without replace.
+func (c *Client) COPYToDB(src, dst string, db int64, replace bool) (bool, error) {
+	var r *request
+	if replace {
+		r = newRequest("*6\r\n$4\r\nCOPY\r\n$")
+		r.addStringStringStringIntString(src, dst, "DB", db, "REPLACE")
+	} else {
+		r = newRequest("*5\r\n$4\r\nCOPY\r\n$")
+		r.addStringStringStringInt(src, dst, "DB", db)
+	}
+	n, err := c.commandInteger(r)
+	return n != 0, err
+}
+
+// BytesCOPYToDB executes <https://redis.io/commands/copy> with a destination
+// database. Replace applies REPLACE. The return is false if src does not exist,
+// or if dst exists without replace.
+func (c *Client) BytesCOPYToDB(src, dst []byte, db int64, replace bool) (bool, error) {
+	var r *request
+	if replace {
+		r = newRequest("*6\r\n$4\r\nCOPY\r\n$")
+		r.addBytesBytesStringIntString(src, dst, "DB", db, "REPLACE")
+	} else {
+		r = newRequest("*5\r\n$4\r\nCOPY\r\n$")
+		r.addBytesBytesStringInt(src, dst, "DB", db)
+	}
+	n, err := c.commandInteger(r)
+	return n != 0, err
+}
+
+// DUMP executes <https://redis.io/commands/dump>. The return is nil if key does
+// not exist. The serialization format is opaque, and it is specific to the
+// Redis version.
+func (c *Client) DUMP(key string) (payload []byte, err error) {
+	r := newRequest("*2\r\n$4\r\nDUMP\r\n$")
+	r.addString(key)
+	return c.commandBlobBytes(r)
+}
+
+// RESTORE executes <https://redis.io/commands/restore> with a payload from
+// DUMP. A zero ttl applies no expiry. Replace applies REPLACE. Otherwise, an
+// existing key gets a ServerError with the "BUSYKEY" prefix.
+func (c *Client) RESTORE(key string, ttl time.Duration, payload []byte, replace bool) error {
+	return c.restore(key, int64(ttl/time.Millisecond), payload, replace, false)
+}
+
+// RESTOREAt is like RESTORE, but it expires at a point in time with ABSTTL
+// instead. The zero time applies no expiry.
+func (c *Client) RESTOREAt(key string, expire time.Time, payload []byte, replace bool) error {
+	if expire.IsZero() {
+		return c.restore(key, 0, payload, replace, false)
+	}
+	ms := expire.UnixNano() / int64(time.Millisecond)
+	if ms <= 0 {
+		return fmt.Errorf("redis: RESTORE expire time %s out of range", expire)
+	}
+	return c.restore(key, ms, payload, replace, true)
+}
+
+func (c *Client) restore(key string, ttl int64, payload []byte, replace, absTTL bool) error {
+	if ttl < 0 {
+		return fmt.Errorf("redis: RESTORE TTL %d ms out of range", ttl)
+	}
+	options := make([]string, 0, 2)
+	if replace {
+		options = append(options, "REPLACE")
+	}
+	if absTTL {
+		options = append(options, "ABSTTL")
+	}
+	r := newRequestSize(4+len(options), "\r\n$7\r\nRESTORE\r\n$")
+	r.addStringIntBytesStringList(key, ttl, payload, options)
+	return c.commandOK(r)
+}
+
+// MigrateKey copies key from c to dst with DUMP and RESTORE, with a zero ttl for
+// no expiry. The return is false if key does not exist on c. An existing key on
+// dst gets a ServerError with the "BUSYKEY" prefix. Use the MIGRATE command for
+// atomic moves between nodes.
+func (c *Client) MigrateKey(dst *Client, key string, ttl time.Duration) (bool, error) {
+	payload, err := c.DUMP(key)
+	if err != nil || payload == nil {
+		return false, err
+	}
+	if err := dst.RESTORE(key, ttl, payload, false); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// IsNoSuchKey returns whether err is the ServerError for an absent key, as
+// returned by OBJECT in some Redis versions.
+func isNoSuchKey(err error) bool {
+	e, ok := err.(ServerError)
+	return ok && e.Prefix() == "ERR" && strings.Contains(strings.ToLower(string(e)), "no such key")
+}
+
+// OBJECTENCODING executes <https://redis.io/commands/object-encoding>.
+// Boolean ok is false if key does not exist.
+func (c *Client) OBJECTENCODING(key string) (encoding string, ok bool, err error) {
+	r := newRequest("*3\r\n$6\r\nOBJECT\r\n$8\r\nENCODING\r\n$")
+	r.addString(key)
+	encoding, ok, err = c.commandBlobString(r)
+	if isNoSuchKey(err) {
+		return "", false, nil
+	}
+	return encoding, ok, err
+}
+
+// OBJECTIDLETIME executes <https://redis.io/commands/object-idletime>, with
+// a resolution of seconds. Boolean ok is false if key does not exist. The
+// server rejects the command when an LFU eviction policy is in use.
+func (c *Client) OBJECTIDLETIME(key string) (idle time.Duration, ok bool, err error) {
+	r := newRequest("*3\r\n$6\r\nOBJECT\r\n$8\r\nIDLETIME\r\n$")
+	r.addString(key)
+	seconds, ok, err := c.commandIntegerOK(r)
+	if isNoSuchKey(err) {
+		return 0, false, nil
+	}
+	return time.Duration(seconds) * time.Second, ok, err
+}
+
+// OBJECTFREQ executes <https://redis.io/commands/object-freq>. Boolean ok is
+// false if key does not exist. The server rejects the command unless an LFU
+// eviction policy is in use.
+func (c *Client) OBJECTFREQ(key string) (freq int64, ok bool, err error) {
+	r := newRequest("*3\r\n$6\r\nOBJECT\r\n$4\r\nFREQ\r\n$")
+	r.addString(key)
+	freq, ok, err = c.commandIntegerOK(r)
+	if isNoSuchKey(err) {
+		return 0, false, nil
+	}
+	return freq, ok, err
+}
+
+// OBJECTREFCOUNT executes <https://redis.io/commands/object-refcount>.
+// Boolean ok is false if key does not exist.
+func (c *Client) OBJECTREFCOUNT(key string) (count int64, ok bool, err error) {
+	r := newRequest("*3\r\n$6\r\nOBJECT\r\n$8\r\nREFCOUNT\r\n$")
+	r.addString(key)
+	count, ok, err = c.commandIntegerOK(r)
+	if isNoSuchKey(err) {
+		return 0, false, nil
+	}
+	return count, ok, err
+}
+
+// DEBUGOBJECT executes <https://redis.io/commands/debug-object>. The return
+// has the name–value pairs from the reply, such as "encoding", "refcount" and
+// "serializedlength". Boolean ok is false if key does not exist. Redis 7 may
+// reject the command, depending on the enable-debug-command configuration.
+func (c *Client) DEBUGOBJECT(key string) (info map[string]string, ok bool, err error) {
+	r := newRequest("*3\r\n$5\r\nDEBUG\r\n$6\r\nOBJECT\r\n$")
+	r.addString(key)
+	s, err := c.commandSimpleString(r)
+	if err != nil {
+		if isNoSuchKey(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	info = make(map[string]string)
+	for _, field := range strings.Fields(s) {
+		if i := strings.IndexByte(field, ':'); i > 0 {
+			info[field[:i]] = field[i+1:]
+		}
+	}
+	return info, true, nil
+}
+
+// MEMORYUSAGE executes <https://redis.io/commands/memory-usage>. Samples sets
+// the number of nested values to estimate with when positive. Zero applies the
+// server default, and a negative count applies SAMPLES 0, i.e., all of them.
+// Boolean ok is false if key does not exist.
+func (c *Client) MEMORYUSAGE(key string, samples int) (bytes int64, ok bool, err error) {
+	var r *request
+	switch {
+	case samples == 0:
+		r = newRequest("*3\r\n$6\r\nMEMORY\r\n$5\r\nUSAGE\r\n$")
+		r.addString(key)
+	case samples < 0:
+		r = newRequest("*5\r\n$6\r\nMEMORY\r\n$5\r\nUSAGE\r\n$")
+		r.addStringStringInt(key, "SAMPLES", 0)
+	default:
+		r = newRequest("*5\r\n$6\r\nMEMORY\r\n$5\r\nUSAGE\r\n$")
+		r.addStringStringInt(key, "SAMPLES", int64(samples))
+	}
+	return c.commandIntegerOK(r)
+}
+
+// FLUSHDB executes <https://redis.io/commands/flushdb>. Without async, the
+// mode defaults to the lazyfree-lazy-user-flush configuration of the server.
 func (c *Client) FLUSHDB(async bool) error {
 	var r *request
 	if async {
@@ -117,7 +542,14 @@ func (c *Client) FLUSHDB(async bool) error {
 	return c.commandOK(r)
 }
 
-// FLUSHALL executes <https://redis.io/commands/flushall>.
+// FLUSHDBSync executes <https://redis.io/commands/flushdb> with SYNC, which
+// requires Redis 6.2.
+func (c *Client) FLUSHDBSync() error {
+	return c.commandOK(newRequest("*2\r\n$7\r\nFLUSHDB\r\n$4\r\nSYNC\r\n"))
+}
+
+// FLUSHALL executes <https://redis.io/commands/flushall>. Without async, the
+// mode defaults to the lazyfree-lazy-user-flush configuration of the server.
 func (c *Client) FLUSHALL(async bool) error {
 	var r *request
 	if async {
@@ -128,10 +560,130 @@ func (c *Client) FLUSHALL(async bool) error {
 	return c.commandOK(r)
 }
 
+// FLUSHALLSync executes <https://redis.io/commands/flushall> with SYNC, which
+// requires Redis 6.2.
+func (c *Client) FLUSHALLSync() error {
+	return c.commandOK(newRequest("*2\r\n$8\r\nFLUSHALL\r\n$4\r\nSYNC\r\n"))
+}
+
+// DBSIZE executes <https://redis.io/commands/dbsize>.
+func (c *Client) DBSIZE() (int64, error) {
+	return c.commandInteger(newRequest("*1\r\n$6\r\nDBSIZE\r\n"))
+}
+
+// RANDOMKEY executes <https://redis.io/commands/randomkey>.
+// Boolean ok is false if the database is empty.
+func (c *Client) RANDOMKEY() (key string, ok bool, err error) {
+	return c.commandBlobString(newRequest("*1\r\n$9\r\nRANDOMKEY\r\n"))
+}
+
+// BytesRANDOMKEY executes <https://redis.io/commands/randomkey>.
+// The return is nil if the database is empty.
+func (c *Client) BytesRANDOMKEY() (key []byte, err error) {
+	return c.commandBlobBytes(newRequest("*1\r\n$9\r\nRANDOMKEY\r\n"))
+}
+
+// CONFIGGET executes <https://redis.io/commands/config-get>. The return maps
+// each configuration parameter that matches the glob-style pattern to its
+// value. No match gets an empty map.
+func (c *Client) CONFIGGET(pattern string) (map[string]string, error) {
+	r := newRequest("*3\r\n$6\r\nCONFIG\r\n$3\r\nGET\r\n$")
+	r.addString(pattern)
+	return c.commandStringMap(r)
+}
+
+// CONFIGGETArgs executes <https://redis.io/commands/config-get> with multiple
+// patterns, which requires Redis 7.0.
+func (c *Client) CONFIGGETArgs(patterns ...string) (map[string]string, error) {
+	if len(patterns) == 0 {
+		return nil, errNoKeys
+	}
+	r := newRequestSize(2+len(patterns), "\r\n$6\r\nCONFIG\r\n$3\r\nGET")
+	r.addStringList(patterns)
+	return c.commandStringMap(r)
+}
+
+// CONFIGSET executes <https://redis.io/commands/config-set>.
+func (c *Client) CONFIGSET(param, value string) error {
+	r := newRequest("*4\r\n$6\r\nCONFIG\r\n$3\r\nSET\r\n$")
+	r.addStringString(param, value)
+	return c.commandOK(r)
+}
+
+// CONFIGRESETSTAT executes <https://redis.io/commands/config-resetstat>.
+func (c *Client) CONFIGRESETSTAT() error {
+	return c.commandOK(newRequest("*2\r\n$6\r\nCONFIG\r\n$9\r\nRESETSTAT\r\n"))
+}
+
+// CONFIGREWRITE executes <https://redis.io/commands/config-rewrite>. The
+// server fails when it was started without a configuration file.
+func (c *Client) CONFIGREWRITE() error {
+	return c.commandOK(newRequest("*2\r\n$6\r\nCONFIG\r\n$7\r\nREWRITE\r\n"))
+}
+
+// DEBUGSLEEP executes <https://redis.io/commands/debug> with SLEEP, which is
+// meant for testing only. The server blocks for duration d, including for all
+// other clients. The command timeout of the Client extends with d, such that
+// the sleep itself does not expire. Redis 7 may reject the command, depending
+// on the enable-debug-command configuration.
+func (c *Client) DEBUGSLEEP(d time.Duration) error {
+	if d < 0 {
+		d = 0
+	}
+	r := newRequest("*3\r\n$5\r\nDEBUG\r\n$5\r\nSLEEP\r\n$")
+	r.addString(strconv.FormatFloat(d.Seconds(), 'f', -1, 64))
+	return c.commandOKBlocking(r, d)
+}
+
+// WAIT executes <https://redis.io/commands/wait>. The server holds the response
+// until numReplicas acknowledged all preceding writes, or until timeout expires.
+// A zero timeout blocks indefinitely. The return is the number of replicas
+// reached. The command timeout of the Client extends with the WAIT timeout.
+func (c *Client) WAIT(numReplicas int64, timeout time.Duration) (int64, error) {
+	block := blockOf(timeout)
+	r := newRequest("*3\r\n$4\r\nWAIT\r\n$")
+	r.addDecimalDecimal(numReplicas, blockMillis(block))
+	return c.commandIntegerBlocking(r, block)
+}
+
+// FAILOVER executes <https://redis.io/commands/failover> on a master. The
+// replica to promote is optional with to as a "host:port" address, in which
+// case force applies FORCE. A zero timeout applies no TIMEOUT. The coordination
+// continues on the server after return. See FAILOVERAbort for cancellation.
+func (c *Client) FAILOVER(to string, force bool, timeout time.Duration) error {
+	args := make([]string, 0, 6)
+	if to != "" {
+		host, port, err := net.SplitHostPort(to)
+		if err != nil {
+			return fmt.Errorf("redis: FAILOVER target: %w", err)
+		}
+		args = append(args, "TO", host, port)
+		if force {
+			args = append(args, "FORCE")
+		}
+	}
+	if timeout > 0 {
+		ms := timeout.Milliseconds()
+		if ms == 0 {
+			ms = 1
+		}
+		args = append(args, "TIMEOUT", strconv.FormatInt(ms, 10))
+	}
+	r := newRequestSize(1+len(args), "\r\n$8\r\nFAILOVER")
+	r.addStringList(args)
+	return c.commandOK(r)
+}
+
+// FAILOVERAbort executes <https://redis.io/commands/failover> with ABORT.
+func (c *Client) FAILOVERAbort() error {
+	return c.commandOK(newRequest("*2\r\n$8\r\nFAILOVER\r\n$5\r\nABORT\r\n"))
+}
+
 // GET executes <https://redis.io/commands/get>.
 // The return is nil if key does not exist.
 func (c *Client) GET(key string) (value []byte, err error) {
 	r := newRequest("*2\r\n$3\r\nGET\r\n$")
+	r.readOnly = true
 	r.addString(key)
 	return c.commandBlobBytes(r)
 }
@@ -140,6 +692,7 @@ func (c *Client) GET(key string) (value []byte, err error) {
 // Boolean ok is false if key does not exist.
 func (c *Client) GETString(key string) (value string, ok bool, err error) {
 	r := newRequest("*2\r\n$3\r\nGET\r\n$")
+	r.readOnly = true
 	r.addString(key)
 	return c.commandBlobString(r)
 }
@@ -148,14 +701,114 @@ func (c *Client) GETString(key string) (value string, ok bool, err error) {
 // The return is nil if key does not exist.
 func (c *Client) BytesGET(key []byte) (value []byte, err error) {
 	r := newRequest("*2\r\n$3\r\nGET\r\n$")
+	r.readOnly = true
 	r.addBytes(key)
 	return c.commandBlobBytes(r)
 }
 
+// GETInto executes <https://redis.io/commands/get>, with the value copied into
+// dst. Boolean ok is false if key does not exist. When dst is too small, then
+// the return is io.ErrShortBuffer, with n set to the size required.
+func (c *Client) GETInto(key string, dst []byte) (n int, ok bool, err error) {
+	r := newRequest("*2\r\n$3\r\nGET\r\n$")
+	r.readOnly = true
+	r.addString(key)
+	return c.commandBlobInto(r, dst)
+}
+
+// GETReader executes <https://redis.io/commands/get>, with the value streamed
+// from the network connection instead of buffered in memory. The return is nil
+// if key does not exist. Otherwise, the caller must Close value, as the Client
+// can not read the response of any other command until then. Close skips any
+// remainder of the value. The command timeout, if any, applies to the transfer
+// as a whole.
+func (c *Client) GETReader(key string) (value io.ReadCloser, length int64, err error) {
+	r := newRequest("*2\r\n$3\r\nGET\r\n$")
+	r.readOnly = true
+	r.addString(key)
+	return c.commandBlobReader(r)
+}
+
+// BytesGETInto executes <https://redis.io/commands/get>, with the value copied
+// into dst. Boolean ok is false if key does not exist. When dst is too small,
+// then the return is io.ErrShortBuffer, with n set to the size required.
+func (c *Client) BytesGETInto(key, dst []byte) (n int, ok bool, err error) {
+	r := newRequest("*2\r\n$3\r\nGET\r\n$")
+	r.readOnly = true
+	r.addBytes(key)
+	return c.commandBlobInto(r, dst)
+}
+
+// chunkSize returns the ClientOptions ChunkSize when n keys exceed the limit,
+// and zero otherwise.
+func (c *Client) chunkSize(n int) int {
+	if size := c.options.ChunkSize; size > 0 && n > size {
+		return size
+	}
+	return 0
+}
+
+// pipelineChunks invokes f for each range of at most size elements from n.
+// The invocations run concurrently, such that the client pipelines their
+// requests. Concurrency is bounded by the QueueSize from ClientOptions. The
+// return is the first error encountered, if any.
+func (c *Client) pipelineChunks(n, size int, f func(start, end int) error) error {
+	errs := make(chan error, (n+size-1)/size)
+	running := make(chan struct{}, cap(c.readQueue))
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		running <- struct{}{}
+		go func(start, end int) {
+			err := f(start, end)
+			<-running
+			errs <- err
+		}(start, end)
+	}
+
+	var err error
+	for i := cap(errs); i > 0; i-- {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// serialChunks invokes f for each range of at most size elements from n, in
+// order. Execution stops on the first error.
+func serialChunks(n, size int, f func(start, end int) error) error {
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		if err := f(start, end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // MGET executes <https://redis.io/commands/mget>.
 // For every key that does not exist, a nil value is returned.
 func (c *Client) MGET(keys ...string) (values [][]byte, err error) {
+	if size := c.chunkSize(len(keys)); size != 0 {
+		values = make([][]byte, len(keys))
+		err = c.pipelineChunks(len(keys), size, func(start, end int) error {
+			chunk, err := c.MGET(keys[start:end]...)
+			copy(values[start:], chunk)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return values, nil
+	}
 	r := newRequestSize(len(keys)+1, "\r\n$4\r\nMGET")
+	r.readOnly = true
 	r.addStringList(keys)
 	return c.commandBytesArray(r)
 }
@@ -163,185 +816,803 @@ func (c *Client) MGET(keys ...string) (values [][]byte, err error) {
 // MGETString executes <https://redis.io/commands/mget>.
 // For every key that does not exist, an empty string is returned.
 func (c *Client) MGETString(keys ...string) (values []string, err error) {
+	if size := c.chunkSize(len(keys)); size != 0 {
+		values = make([]string, len(keys))
+		err = c.pipelineChunks(len(keys), size, func(start, end int) error {
+			chunk, err := c.MGETString(keys[start:end]...)
+			copy(values[start:], chunk)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return values, nil
+	}
 	r := newRequestSize(len(keys)+1, "\r\n$4\r\nMGET")
+	r.readOnly = true
 	r.addStringList(keys)
 	return c.commandStringArray(r)
 }
 
-// BytesMGET executes <https://redis.io/commands/mget>.
-// For every key that does not exist, a nil value is returned.
-func (c *Client) BytesMGET(keys ...[]byte) (values [][]byte, err error) {
-	r := newRequestSize(len(keys)+1, "\r\n$4\r\nMGET")
-	r.addBytesList(keys)
-	return c.commandBytesArray(r)
+// MGETStringOK executes <https://redis.io/commands/mget>.
+// For every key that does not exist, an empty string is returned, with false
+// in ok at the same index.
+func (c *Client) MGETStringOK(keys ...string) (values []string, ok []bool, err error) {
+	if size := c.chunkSize(len(keys)); size != 0 {
+		values = make([]string, len(keys))
+		ok = make([]bool, len(keys))
+		err = c.pipelineChunks(len(keys), size, func(start, end int) error {
+			chunk, chunkOK, err := c.MGETStringOK(keys[start:end]...)
+			copy(values[start:], chunk)
+			copy(ok[start:], chunkOK)
+			return err
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return values, ok, nil
+	}
+	r := newRequestSize(len(keys)+1, "\r\n$4\r\nMGET")
+	r.readOnly = true
+	r.addStringList(keys)
+	return c.commandStringArrayOK(r)
+}
+
+// BytesMGET executes <https://redis.io/commands/mget>.
+// For every key that does not exist, a nil value is returned.
+func (c *Client) BytesMGET(keys ...[]byte) (values [][]byte, err error) {
+	if size := c.chunkSize(len(keys)); size != 0 {
+		values = make([][]byte, len(keys))
+		err = c.pipelineChunks(len(keys), size, func(start, end int) error {
+			chunk, err := c.BytesMGET(keys[start:end]...)
+			copy(values[start:], chunk)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return values, nil
+	}
+	r := newRequestSize(len(keys)+1, "\r\n$4\r\nMGET")
+	r.readOnly = true
+	r.addBytesList(keys)
+	return c.commandBytesArray(r)
+}
+
+// SET executes <https://redis.io/commands/set>.
+func (c *Client) SET(key string, value []byte) error {
+	r := newRequest("*3\r\n$3\r\nSET\r\n$")
+	r.addStringBytes(key, value)
+	return c.commandOK(r)
+}
+
+// BytesSET executes <https://redis.io/commands/set>.
+func (c *Client) BytesSET(key, value []byte) error {
+	r := newRequest("*3\r\n$3\r\nSET\r\n$")
+	r.addBytesBytes(key, value)
+	return c.commandOK(r)
+}
+
+// SETString executes <https://redis.io/commands/set>.
+func (c *Client) SETString(key, value string) error {
+	r := newRequest("*3\r\n$3\r\nSET\r\n$")
+	r.addStringString(key, value)
+	return c.commandOK(r)
+}
+
+// SETNX executes <https://redis.io/commands/setnx>. The return is false if key
+// exists, in which case the value is not set.
+func (c *Client) SETNX(key string, value []byte) (bool, error) {
+	r := newRequest("*3\r\n$5\r\nSETNX\r\n$")
+	r.addStringBytes(key, value)
+	n, err := c.commandInteger(r)
+	return n != 0, err
+}
+
+// BytesSETNX executes <https://redis.io/commands/setnx>. The return is false if
+// key exists, in which case the value is not set.
+func (c *Client) BytesSETNX(key, value []byte) (bool, error) {
+	r := newRequest("*3\r\n$5\r\nSETNX\r\n$")
+	r.addBytesBytes(key, value)
+	n, err := c.commandInteger(r)
+	return n != 0, err
+}
+
+// SETNXString executes <https://redis.io/commands/setnx>. The return is false
+// if key exists, in which case the value is not set.
+func (c *Client) SETNXString(key, value string) (bool, error) {
+	r := newRequest("*3\r\n$5\r\nSETNX\r\n$")
+	r.addStringString(key, value)
+	n, err := c.commandInteger(r)
+	return n != 0, err
+}
+
+// SETEX executes <https://redis.io/commands/setex>. The ttl is truncated to
+// whole seconds. Anything less than a second gets a ServerError.
+func (c *Client) SETEX(key string, ttl time.Duration, value []byte) error {
+	r := newRequest("*4\r\n$5\r\nSETEX\r\n$")
+	r.addStringIntBytes(key, int64(ttl/time.Second), value)
+	return c.commandOK(r)
+}
+
+// BytesSETEX executes <https://redis.io/commands/setex>. The ttl is truncated
+// to whole seconds. Anything less than a second gets a ServerError.
+func (c *Client) BytesSETEX(key []byte, ttl time.Duration, value []byte) error {
+	r := newRequest("*4\r\n$5\r\nSETEX\r\n$")
+	r.addBytesIntBytes(key, int64(ttl/time.Second), value)
+	return c.commandOK(r)
+}
+
+// SETEXString executes <https://redis.io/commands/setex>. The ttl is truncated
+// to whole seconds. Anything less than a second gets a ServerError.
+func (c *Client) SETEXString(key string, ttl time.Duration, value string) error {
+	r := newRequest("*4\r\n$5\r\nSETEX\r\n$")
+	r.addStringIntString(key, int64(ttl/time.Second), value)
+	return c.commandOK(r)
+}
+
+// PSETEX executes <https://redis.io/commands/psetex>. The ttl is truncated to
+// whole milliseconds. Anything less than a millisecond gets a ServerError.
+func (c *Client) PSETEX(key string, ttl time.Duration, value []byte) error {
+	r := newRequest("*4\r\n$6\r\nPSETEX\r\n$")
+	r.addStringIntBytes(key, int64(ttl/time.Millisecond), value)
+	return c.commandOK(r)
+}
+
+// BytesPSETEX executes <https://redis.io/commands/psetex>. The ttl is truncated
+// to whole milliseconds. Anything less than a millisecond gets a ServerError.
+func (c *Client) BytesPSETEX(key []byte, ttl time.Duration, value []byte) error {
+	r := newRequest("*4\r\n$6\r\nPSETEX\r\n$")
+	r.addBytesIntBytes(key, int64(ttl/time.Millisecond), value)
+	return c.commandOK(r)
+}
+
+// PSETEXString executes <https://redis.io/commands/psetex>. The ttl is
+// truncated to whole milliseconds. Anything less than a millisecond gets a
+// ServerError.
+func (c *Client) PSETEXString(key string, ttl time.Duration, value string) error {
+	r := newRequest("*4\r\n$6\r\nPSETEX\r\n$")
+	r.addStringIntString(key, int64(ttl/time.Millisecond), value)
+	return c.commandOK(r)
+}
+
+// SETFrom executes <https://redis.io/commands/set> with a value of length bytes
+// read from r. The value is streamed to the network connection instead of
+// buffered in memory. The command timeout, if any, applies to the transfer as a
+// whole. A reader with less or more than length bytes breaks the connection,
+// which causes a reconnect and an error return. The check for more reads ahead
+// with the last byte only, such that readers without EOF, like io.Pipe, don't
+// block.
+func (c *Client) SETFrom(key string, r io.Reader, length int64) error {
+	if length < 0 || length > SizeMax {
+		return fmt.Errorf("redis: payload length %d out of range", length)
+	}
+	req := newRequest("*3\r\n$3\r\nSET\r\n$")
+	req.addStringPayloadSize(key, length)
+	return c.commandOKPayload(req, r, length)
+}
+
+// SETWithOptions executes <https://redis.io/commands/set> with options.
+// The return is false if the SET operation was not performed due to an NX or XX
+// condition.
+func (c *Client) SETWithOptions(key string, value []byte, o SETOptions) (bool, error) {
+	existArg, expireArg, expire, err := o.args()
+	if err != nil {
+		return false, err
+	}
+	r := newSETRequest(existArg, expireArg, false)
+	r.addStringBytes(key, value)
+	r.addSETArgs(existArg, expireArg, expire, false)
+
+	err = c.commandOK(r)
+	if err == ErrNil {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// BytesSETWithOptions executes <https://redis.io/commands/set> with options.
+// The return is false if the SET operation was not performed due to an NX or XX
+// condition.
+func (c *Client) BytesSETWithOptions(key, value []byte, o SETOptions) (bool, error) {
+	existArg, expireArg, expire, err := o.args()
+	if err != nil {
+		return false, err
+	}
+	r := newSETRequest(existArg, expireArg, false)
+	r.addBytesBytes(key, value)
+	r.addSETArgs(existArg, expireArg, expire, false)
+
+	err = c.commandOK(r)
+	if err == ErrNil {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// SETStringWithOptions executes <https://redis.io/commands/set> with options.
+// The return is false if the SET operation was not performed due to an NX or XX
+// condition.
+func (c *Client) SETStringWithOptions(key, value string, o SETOptions) (bool, error) {
+	existArg, expireArg, expire, err := o.args()
+	if err != nil {
+		return false, err
+	}
+	r := newSETRequest(existArg, expireArg, false)
+	r.addStringString(key, value)
+	r.addSETArgs(existArg, expireArg, expire, false)
+
+	err = c.commandOK(r)
+	if err == ErrNil {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// SETGET executes <https://redis.io/commands/set> with the GET argument and
+// options. The old value is returned, if any. Boolean ok is false when key did
+// not exist. Thus, the SET operation was performed with NX when ok is false,
+// and with XX when ok is true. Redis versions before 7 reject GET in
+// combination with NX.
+func (c *Client) SETGET(key string, value []byte, o SETOptions) (old []byte, ok bool, err error) {
+	existArg, expireArg, expire, err := o.args()
+	if err != nil {
+		return nil, false, err
+	}
+	r := newSETRequest(existArg, expireArg, true)
+	r.addStringBytes(key, value)
+	r.addSETArgs(existArg, expireArg, expire, true)
+
+	old, err = c.commandBlobBytes(r)
+	return old, old != nil, err
+}
+
+// BytesSETGET executes <https://redis.io/commands/set> with the GET argument
+// and options. The old value is returned, if any. Boolean ok is false when key
+// did not exist. Thus, the SET operation was performed with NX when ok is
+// false, and with XX when ok is true. Redis versions before 7 reject GET in
+// combination with NX.
+func (c *Client) BytesSETGET(key, value []byte, o SETOptions) (old []byte, ok bool, err error) {
+	existArg, expireArg, expire, err := o.args()
+	if err != nil {
+		return nil, false, err
+	}
+	r := newSETRequest(existArg, expireArg, true)
+	r.addBytesBytes(key, value)
+	r.addSETArgs(existArg, expireArg, expire, true)
+
+	old, err = c.commandBlobBytes(r)
+	return old, old != nil, err
+}
+
+// SETGETString executes <https://redis.io/commands/set> with the GET argument
+// and options. The old value is returned, if any. Boolean ok is false when key
+// did not exist. Thus, the SET operation was performed with NX when ok is
+// false, and with XX when ok is true. Redis versions before 7 reject GET in
+// combination with NX.
+func (c *Client) SETGETString(key, value string, o SETOptions) (old string, ok bool, err error) {
+	existArg, expireArg, expire, err := o.args()
+	if err != nil {
+		return "", false, err
+	}
+	r := newSETRequest(existArg, expireArg, true)
+	r.addStringString(key, value)
+	r.addSETArgs(existArg, expireArg, expire, true)
+	return c.commandBlobString(r)
+}
+
+// MSET executes <https://redis.io/commands/mset>.
+func (c *Client) MSET(keys []string, values [][]byte) error {
+	if size := c.chunkSize(len(keys)); size != 0 {
+		if len(keys) != len(values) {
+			return errMapSlices
+		}
+		return serialChunks(len(keys), size, func(start, end int) error {
+			return c.MSET(keys[start:end], values[start:end])
+		})
+	}
+	r := newRequestSize(len(keys)*2+1, "\r\n$4\r\nMSET")
+	err := r.addStringBytesMapLists(keys, values)
+	if err != nil {
+		r.free()
+		return err
+	}
+	return c.commandOK(r)
+}
+
+// BytesMSET executes <https://redis.io/commands/mset>.
+func (c *Client) BytesMSET(keys, values [][]byte) error {
+	if size := c.chunkSize(len(keys)); size != 0 {
+		if len(keys) != len(values) {
+			return errMapSlices
+		}
+		return serialChunks(len(keys), size, func(start, end int) error {
+			return c.BytesMSET(keys[start:end], values[start:end])
+		})
+	}
+	r := newRequestSize(len(keys)*2+1, "\r\n$4\r\nMSET")
+	err := r.addBytesBytesMapLists(keys, values)
+	if err != nil {
+		r.free()
+		return err
+	}
+	return c.commandOK(r)
+}
+
+// MSETString executes <https://redis.io/commands/mset>.
+func (c *Client) MSETString(keys, values []string) error {
+	if size := c.chunkSize(len(keys)); size != 0 {
+		if len(keys) != len(values) {
+			return errMapSlices
+		}
+		return serialChunks(len(keys), size, func(start, end int) error {
+			return c.MSETString(keys[start:end], values[start:end])
+		})
+	}
+	r := newRequestSize(len(keys)*2+1, "\r\n$4\r\nMSET")
+	err := r.addStringStringMapLists(keys, values)
+	if err != nil {
+		r.free()
+		return err
+	}
+	return c.commandOK(r)
+}
+
+// MSETMap executes <https://redis.io/commands/mset>.
+func (c *Client) MSETMap(pairs map[string][]byte) error {
+	if c.chunkSize(len(pairs)) != 0 {
+		keys := make([]string, 0, len(pairs))
+		values := make([][]byte, 0, len(pairs))
+		for k, v := range pairs {
+			keys = append(keys, k)
+			values = append(values, v)
+		}
+		return c.MSET(keys, values)
+	}
+	r := newRequestSize(len(pairs)*2+1, "\r\n$4\r\nMSET")
+	r.addStringBytesMap(pairs)
+	return c.commandOK(r)
+}
+
+// MSETStringMap executes <https://redis.io/commands/mset>.
+func (c *Client) MSETStringMap(pairs map[string]string) error {
+	if c.chunkSize(len(pairs)) != 0 {
+		keys := make([]string, 0, len(pairs))
+		values := make([]string, 0, len(pairs))
+		for k, v := range pairs {
+			keys = append(keys, k)
+			values = append(values, v)
+		}
+		return c.MSETString(keys, values)
+	}
+	r := newRequestSize(len(pairs)*2+1, "\r\n$4\r\nMSET")
+	r.addStringStringMap(pairs)
+	return c.commandOK(r)
+}
+
+// MSETNX executes <https://redis.io/commands/msetnx>.
+// The return is false if any of the keys exist, in which case none is set.
+func (c *Client) MSETNX(keys []string, values [][]byte) (bool, error) {
+	r := newRequestSize(len(keys)*2+1, "\r\n$6\r\nMSETNX")
+	err := r.addStringBytesMapLists(keys, values)
+	if err != nil {
+		r.free()
+		return false, err
+	}
+	n, err := c.commandInteger(r)
+	return n != 0, err
+}
+
+// BytesMSETNX executes <https://redis.io/commands/msetnx>.
+// The return is false if any of the keys exist, in which case none is set.
+func (c *Client) BytesMSETNX(keys, values [][]byte) (bool, error) {
+	r := newRequestSize(len(keys)*2+1, "\r\n$6\r\nMSETNX")
+	err := r.addBytesBytesMapLists(keys, values)
+	if err != nil {
+		r.free()
+		return false, err
+	}
+	n, err := c.commandInteger(r)
+	return n != 0, err
+}
+
+// MSETNXString executes <https://redis.io/commands/msetnx>.
+// The return is false if any of the keys exist, in which case none is set.
+func (c *Client) MSETNXString(keys, values []string) (bool, error) {
+	r := newRequestSize(len(keys)*2+1, "\r\n$6\r\nMSETNX")
+	err := r.addStringStringMapLists(keys, values)
+	if err != nil {
+		r.free()
+		return false, err
+	}
+	n, err := c.commandInteger(r)
+	return n != 0, err
+}
+
+// MSETNXMap executes <https://redis.io/commands/msetnx>.
+// The return is false if any of the keys exist, in which case none is set.
+func (c *Client) MSETNXMap(pairs map[string][]byte) (bool, error) {
+	r := newRequestSize(len(pairs)*2+1, "\r\n$6\r\nMSETNX")
+	r.addStringBytesMap(pairs)
+	n, err := c.commandInteger(r)
+	return n != 0, err
+}
+
+// MSETNXStringMap executes <https://redis.io/commands/msetnx>.
+// The return is false if any of the keys exist, in which case none is set.
+func (c *Client) MSETNXStringMap(pairs map[string]string) (bool, error) {
+	r := newRequestSize(len(pairs)*2+1, "\r\n$6\r\nMSETNX")
+	r.addStringStringMap(pairs)
+	n, err := c.commandInteger(r)
+	return n != 0, err
+}
+
+// DEL executes <https://redis.io/commands/del>. The server frees the memory
+// before it replies. See UNLINK for large values.
+func (c *Client) DEL(key string) (bool, error) {
+	r := newRequest("*2\r\n$3\r\nDEL\r\n$")
+	r.addString(key)
+	removed, err := c.commandInteger(r)
+	return removed != 0, err
+}
+
+// DELArgs executes <https://redis.io/commands/del>. The return is the number
+// of keys removed. Zero keys return zero, without submission.
+func (c *Client) DELArgs(keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	if size := c.chunkSize(len(keys)); size != 0 {
+		var total int64
+		err := c.pipelineChunks(len(keys), size, func(start, end int) error {
+			n, err := c.DELArgs(keys[start:end]...)
+			atomic.AddInt64(&total, n)
+			return err
+		})
+		return total, err
+	}
+	r := newRequestSize(1+len(keys), "\r\n$3\r\nDEL")
+	r.addStringList(keys)
+	return c.commandInteger(r)
+}
+
+// BytesDEL executes <https://redis.io/commands/del>. The server frees the
+// memory before it replies. See BytesUNLINK for large values.
+func (c *Client) BytesDEL(key []byte) (bool, error) {
+	r := newRequest("*2\r\n$3\r\nDEL\r\n$")
+	r.addBytes(key)
+	removed, err := c.commandInteger(r)
+	return removed != 0, err
+}
+
+// BytesDELArgs executes <https://redis.io/commands/del>. The return is the
+// number of keys removed. Zero keys return zero, without submission.
+func (c *Client) BytesDELArgs(keys ...[]byte) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	if size := c.chunkSize(len(keys)); size != 0 {
+		var total int64
+		err := c.pipelineChunks(len(keys), size, func(start, end int) error {
+			n, err := c.BytesDELArgs(keys[start:end]...)
+			atomic.AddInt64(&total, n)
+			return err
+		})
+		return total, err
+	}
+	r := newRequestSize(1+len(keys), "\r\n$3\r\nDEL")
+	r.addBytesList(keys)
+	return c.commandInteger(r)
+}
+
+// UNLINK executes <https://redis.io/commands/unlink>. The return is the number
+// of keys removed. Zero keys return zero, without submission. Unlike DEL, the
+// server frees the memory in the background, which keeps the removal of large
+// values from blocking other clients.
+func (c *Client) UNLINK(keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	if size := c.chunkSize(len(keys)); size != 0 {
+		var total int64
+		err := c.pipelineChunks(len(keys), size, func(start, end int) error {
+			n, err := c.UNLINK(keys[start:end]...)
+			atomic.AddInt64(&total, n)
+			return err
+		})
+		return total, err
+	}
+	r := newRequestSize(1+len(keys), "\r\n$6\r\nUNLINK")
+	r.addStringList(keys)
+	return c.commandInteger(r)
+}
+
+// BytesUNLINK executes <https://redis.io/commands/unlink>. The return is the
+// number of keys removed. Zero keys return zero, without submission. Unlike
+// DEL, the server frees the memory in the background, which keeps the removal
+// of large values from blocking other clients.
+func (c *Client) BytesUNLINK(keys ...[]byte) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	if size := c.chunkSize(len(keys)); size != 0 {
+		var total int64
+		err := c.pipelineChunks(len(keys), size, func(start, end int) error {
+			n, err := c.BytesUNLINK(keys[start:end]...)
+			atomic.AddInt64(&total, n)
+			return err
+		})
+		return total, err
+	}
+	r := newRequestSize(1+len(keys), "\r\n$6\r\nUNLINK")
+	r.addBytesList(keys)
+	return c.commandInteger(r)
+}
+
+// EXISTS executes <https://redis.io/commands/exists>. The return is the number
+// of keys that exist, with each occurrence of the same key counted. Zero keys
+// return zero, without submission.
+func (c *Client) EXISTS(keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	r := newRequestSize(1+len(keys), "\r\n$6\r\nEXISTS")
+	r.readOnly = true
+	r.addStringList(keys)
+	return c.commandInteger(r)
+}
+
+// BytesEXISTS executes <https://redis.io/commands/exists>. The return is the
+// number of keys that exist, with each occurrence of the same key counted.
+// Zero keys return zero, without submission.
+func (c *Client) BytesEXISTS(keys ...[]byte) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	r := newRequestSize(1+len(keys), "\r\n$6\r\nEXISTS")
+	r.readOnly = true
+	r.addBytesList(keys)
+	return c.commandInteger(r)
+}
+
+// TOUCH executes <https://redis.io/commands/touch>. The return is the number
+// of keys that exist. Zero keys return zero, without submission.
+func (c *Client) TOUCH(keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	r := newRequestSize(1+len(keys), "\r\n$5\r\nTOUCH")
+	r.addStringList(keys)
+	return c.commandInteger(r)
+}
+
+// BytesTOUCH executes <https://redis.io/commands/touch>. The return is the
+// number of keys that exist. Zero keys return zero, without submission.
+func (c *Client) BytesTOUCH(keys ...[]byte) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	r := newRequestSize(1+len(keys), "\r\n$5\r\nTOUCH")
+	r.addBytesList(keys)
+	return c.commandInteger(r)
+}
+
+// PERSIST executes <https://redis.io/commands/persist>. The return is false
+// if key does not exist, or if key has no expiry.
+func (c *Client) PERSIST(key string) (bool, error) {
+	r := newRequest("*2\r\n$7\r\nPERSIST\r\n$")
+	r.addString(key)
+	n, err := c.commandInteger(r)
+	return n != 0, err
 }
 
-// SET executes <https://redis.io/commands/set>.
-func (c *Client) SET(key string, value []byte) error {
-	r := newRequest("*3\r\n$3\r\nSET\r\n$")
-	r.addStringBytes(key, value)
-	return c.commandOK(r)
+// BytesPERSIST executes <https://redis.io/commands/persist>. The return is
+// false if key does not exist, or if key has no expiry.
+func (c *Client) BytesPERSIST(key []byte) (bool, error) {
+	r := newRequest("*2\r\n$7\r\nPERSIST\r\n$")
+	r.addBytes(key)
+	n, err := c.commandInteger(r)
+	return n != 0, err
 }
 
-// BytesSET executes <https://redis.io/commands/set>.
-func (c *Client) BytesSET(key, value []byte) error {
-	r := newRequest("*3\r\n$3\r\nSET\r\n$")
-	r.addBytesBytes(key, value)
-	return c.commandOK(r)
+// NoTTL is the time to live of keys without expiry.
+const NoTTL time.Duration = -1
+
+// TTL executes <https://redis.io/commands/ttl>. Boolean ok is false if key
+// does not exist. The ttl is NoTTL if key has no expiry. Otherwise, the ttl is
+// in whole seconds.
+func (c *Client) TTL(key string) (ttl time.Duration, ok bool, err error) {
+	r := newRequest("*2\r\n$3\r\nTTL\r\n$")
+	r.addString(key)
+	n, err := c.commandInteger(r)
+	return ttlOf(n, time.Second, err)
 }
 
-// SETString executes <https://redis.io/commands/set>.
-func (c *Client) SETString(key, value string) error {
-	r := newRequest("*3\r\n$3\r\nSET\r\n$")
-	r.addStringString(key, value)
-	return c.commandOK(r)
+// BytesTTL executes <https://redis.io/commands/ttl>. Boolean ok is false if
+// key does not exist. The ttl is NoTTL if key has no expiry. Otherwise, the ttl
+// is in whole seconds.
+func (c *Client) BytesTTL(key []byte) (ttl time.Duration, ok bool, err error) {
+	r := newRequest("*2\r\n$3\r\nTTL\r\n$")
+	r.addBytes(key)
+	n, err := c.commandInteger(r)
+	return ttlOf(n, time.Second, err)
 }
 
-// SETWithOptions executes <https://redis.io/commands/set> with options.
-// The return is false if the SET operation was not performed due to an NX or XX
-// condition.
-func (c *Client) SETWithOptions(key string, value []byte, o SETOptions) (bool, error) {
-	existArg, expireArg, expire, err := o.args()
-	if err != nil {
-		return false, err
-	}
+// PTTL executes <https://redis.io/commands/pttl>. Boolean ok is false if key
+// does not exist. The ttl is NoTTL if key has no expiry. Otherwise, the ttl is
+// in whole milliseconds.
+func (c *Client) PTTL(key string) (ttl time.Duration, ok bool, err error) {
+	r := newRequest("*2\r\n$4\r\nPTTL\r\n$")
+	r.addString(key)
+	n, err := c.commandInteger(r)
+	return ttlOf(n, time.Millisecond, err)
+}
 
-	var r *request
-	switch {
-	case existArg != "" && expireArg == "":
-		r = newRequest("*4\r\n$3\r\nSET\r\n$")
-		r.addStringBytesString(key, value, existArg)
-	case existArg == "" && expireArg != "":
-		r = newRequest("*5\r\n$3\r\nSET\r\n$")
-		r.addStringBytesStringInt(key, value, expireArg, expire)
-	case existArg != "" && expireArg != "":
-		r = newRequest("*6\r\n$3\r\nSET\r\n$")
-		r.addStringBytesStringStringInt(key, value, existArg, expireArg, expire)
-	default:
-		err := c.SET(key, value)
-		return err == nil, err
-	}
+// BytesPTTL executes <https://redis.io/commands/pttl>. Boolean ok is false if
+// key does not exist. The ttl is NoTTL if key has no expiry. Otherwise, the ttl
+// is in whole milliseconds.
+func (c *Client) BytesPTTL(key []byte) (ttl time.Duration, ok bool, err error) {
+	r := newRequest("*2\r\n$4\r\nPTTL\r\n$")
+	r.addBytes(key)
+	n, err := c.commandInteger(r)
+	return ttlOf(n, time.Millisecond, err)
+}
 
-	err = c.commandOK(r)
-	if err == errNull {
-		return false, nil
+// TtlOf maps the reply of TTL and PTTL, in unit. Negative replies are -2 for
+// absence, and -1 for no expiry.
+func ttlOf(n int64, unit time.Duration, err error) (time.Duration, bool, error) {
+	switch {
+	case err != nil:
+		return 0, false, err
+	case n == -2:
+		return 0, false, nil
+	case n < 0:
+		return NoTTL, true, nil
 	}
-	return err == nil, err
+	return time.Duration(n) * unit, true, nil
 }
 
-// BytesSETWithOptions executes <https://redis.io/commands/set> with options.
-// The return is false if the SET operation was not performed due to an NX or XX
-// condition.
-func (c *Client) BytesSETWithOptions(key, value []byte, o SETOptions) (bool, error) {
-	existArg, expireArg, expire, err := o.args()
-	if err != nil {
-		return false, err
-	}
+// SORTOptions are extra arguments for the SORT command.
+type SORTOptions struct {
+	// By is an optional pattern for external keys to sort by. The first
+	// "*" in the pattern is substituted with each element. The special
+	// pattern "nosort" skips the sorting altogether.
+	By string
 
-	var r *request
-	switch {
-	case existArg != "" && expireArg == "":
-		r = newRequest("*4\r\n$3\r\nSET\r\n$")
-		r.addBytesBytesString(key, value, existArg)
-	case existArg == "" && expireArg != "":
-		r = newRequest("*5\r\n$3\r\nSET\r\n$")
-		r.addBytesBytesStringInt(key, value, expireArg, expire)
-	case existArg != "" && expireArg != "":
-		r = newRequest("*6\r\n$3\r\nSET\r\n$")
-		r.addBytesBytesStringStringInt(key, value, existArg, expireArg, expire)
-	default:
-		err := c.BytesSET(key, value)
-		return err == nil, err
-	}
+	// Get has optional patterns for external keys to return instead of
+	// the elements, in order of appearance. Pattern "#" is the element
+	// itself.
+	Get []string
 
-	err = c.commandOK(r)
-	if err == errNull {
-		return false, nil
-	}
-	return err == nil, err
+	// Count limits the number of elements when positive, starting with
+	// the one at Offset. Offset without Count skips elements only.
+	Offset, Count int64
+
+	// Desc sorts from large to small.
+	Desc bool
+
+	// Alpha sorts lexicographically instead of numerically.
+	Alpha bool
 }
 
-// SETStringWithOptions executes <https://redis.io/commands/set> with options.
-// The return is false if the SET operation was not performed due to an NX or XX
-// condition.
-func (c *Client) SETStringWithOptions(key, value string, o SETOptions) (bool, error) {
-	existArg, expireArg, expire, err := o.args()
-	if err != nil {
-		return false, err
+func (o *SORTOptions) args() []string {
+	args := make([]string, 0, 8+2*len(o.Get))
+	if o.By != "" {
+		args = append(args, "BY", o.By)
 	}
-
-	var r *request
-	switch {
-	case existArg != "" && expireArg == "":
-		r = newRequest("*4\r\n$3\r\nSET\r\n$")
-		r.addStringStringString(key, value, existArg)
-	case existArg == "" && expireArg != "":
-		r = newRequest("*5\r\n$3\r\nSET\r\n$")
-		r.addStringStringStringInt(key, value, expireArg, expire)
-	case existArg != "" && expireArg != "":
-		r = newRequest("*6\r\n$3\r\nSET\r\n$")
-		r.addStringStringStringStringInt(key, value, existArg, expireArg, expire)
-	default:
-		err := c.SETString(key, value)
-		return err == nil, err
+	if o.Offset != 0 || o.Count > 0 {
+		count := o.Count
+		if count <= 0 {
+			count = -1
+		}
+		args = append(args, "LIMIT", strconv.FormatInt(o.Offset, 10), strconv.FormatInt(count, 10))
 	}
-
-	err = c.commandOK(r)
-	if err == errNull {
-		return false, nil
+	for _, pattern := range o.Get {
+		args = append(args, "GET", pattern)
 	}
-	return err == nil, err
+	if o.Desc {
+		args = append(args, "DESC")
+	}
+	if o.Alpha {
+		args = append(args, "ALPHA")
+	}
+	return args
 }
 
-// MSET executes <https://redis.io/commands/mset>.
-func (c *Client) MSET(keys []string, values [][]byte) error {
-	r := newRequestSize(len(keys)*2+1, "\r\n$4\r\nMSET")
-	r.addStringBytesMapLists(keys, values)
-	return c.commandOK(r)
+// SORT executes <https://redis.io/commands/sort> on a list, a set or a sorted
+// set. With Get patterns, a nil value is returned for each external key that
+// does not exist. The return is empty if key does not exist.
+func (c *Client) SORT(key string, o SORTOptions) ([][]byte, error) {
+	args := o.args()
+	r := newRequestSize(2+len(args), "\r\n$4\r\nSORT\r\n$")
+	r.addStringStringList(key, args)
+	return c.commandBytesArray(r)
 }
 
-// BytesMSET executes <https://redis.io/commands/mset>.
-func (c *Client) BytesMSET(keys, values [][]byte) error {
-	r := newRequestSize(len(keys)*2+1, "\r\n$4\r\nMSET")
-	r.addBytesBytesMapLists(keys, values)
+// SORTString executes <https://redis.io/commands/sort> on a list, a set or a
+// sorted set. With Get patterns, an empty string is returned for each external
+// key that does not exist. The return is empty if key does not exist.
+func (c *Client) SORTString(key string, o SORTOptions) ([]string, error) {
+	args := o.args()
+	r := newRequestSize(2+len(args), "\r\n$4\r\nSORT\r\n$")
+	r.addStringStringList(key, args)
+	return c.commandStringArray(r)
+}
+
+// SORTSTORE executes <https://redis.io/commands/sort> with STORE. The result
+// replaces any value at destination as a list. The return is the number of
+// elements stored.
+func (c *Client) SORTSTORE(key, destination string, o SORTOptions) (int64, error) {
+	args := append(o.args(), "STORE", destination)
+	r := newRequestSize(2+len(args), "\r\n$4\r\nSORT\r\n$")
+	r.addStringStringList(key, args)
+	return c.commandInteger(r)
+}
+
+// RENAME executes <https://redis.io/commands/rename>.
+// An absent key gets a ServerError.
+func (c *Client) RENAME(key, newKey string) error {
+	r := newRequest("*3\r\n$6\r\nRENAME\r\n$")
+	r.addStringString(key, newKey)
 	return c.commandOK(r)
 }
 
-// MSETString executes <https://redis.io/commands/mset>.
-func (c *Client) MSETString(keys, values []string) error {
-	r := newRequestSize(len(keys)*2+1, "\r\n$4\r\nMSET")
-	r.addStringStringMapLists(keys, values)
+// BytesRENAME executes <https://redis.io/commands/rename>.
+// An absent key gets a ServerError.
+func (c *Client) BytesRENAME(key, newKey []byte) error {
+	r := newRequest("*3\r\n$6\r\nRENAME\r\n$")
+	r.addBytesBytes(key, newKey)
 	return c.commandOK(r)
 }
 
-// DEL executes <https://redis.io/commands/del>.
-func (c *Client) DEL(key string) (bool, error) {
-	r := newRequest("*2\r\n$3\r\nDEL\r\n$")
-	r.addString(key)
-	removed, err := c.commandInteger(r)
-	return removed != 0, err
+// RENAMENX executes <https://redis.io/commands/renamenx>. The return is false
+// if newKey exists. An absent key gets a ServerError.
+func (c *Client) RENAMENX(key, newKey string) (bool, error) {
+	r := newRequest("*3\r\n$8\r\nRENAMENX\r\n$")
+	r.addStringString(key, newKey)
+	n, err := c.commandInteger(r)
+	return n != 0, err
 }
 
-// DELArgs executes <https://redis.io/commands/del>.
-func (c *Client) DELArgs(keys ...string) (int64, error) {
-	r := newRequestSize(1+len(keys), "\r\n$3\r\nDEL")
-	r.addStringList(keys)
-	return c.commandInteger(r)
+// BytesRENAMENX executes <https://redis.io/commands/renamenx>. The return is
+// false if newKey exists. An absent key gets a ServerError.
+func (c *Client) BytesRENAMENX(key, newKey []byte) (bool, error) {
+	r := newRequest("*3\r\n$8\r\nRENAMENX\r\n$")
+	r.addBytesBytes(key, newKey)
+	n, err := c.commandInteger(r)
+	return n != 0, err
 }
 
-// BytesDEL executes <https://redis.io/commands/del>.
-func (c *Client) BytesDEL(key []byte) (bool, error) {
-	r := newRequest("*2\r\n$3\r\nDEL\r\n$")
-	r.addBytes(key)
-	removed, err := c.commandInteger(r)
-	return removed != 0, err
+// KeyType is the data structure of a key's value.
+type KeyType string
+
+// TYPE Replies
+const (
+	TypeNone   KeyType = "none" // key does not exist
+	TypeString KeyType = "string"
+	TypeList   KeyType = "list"
+	TypeSet    KeyType = "set"
+	TypeZSet   KeyType = "zset"
+	TypeHash   KeyType = "hash"
+	TypeStream KeyType = "stream"
+)
+
+// TYPE executes <https://redis.io/commands/type>.
+// The return is TypeNone if key does not exist.
+func (c *Client) TYPE(key string) (KeyType, error) {
+	r := newRequest("*2\r\n$4\r\nTYPE\r\n$")
+	r.addString(key)
+	s, err := c.commandSimpleString(r)
+	return KeyType(s), err
 }
 
-// BytesDELArgs executes <https://redis.io/commands/del>.
-func (c *Client) BytesDELArgs(keys ...[]byte) (int64, error) {
-	r := newRequestSize(1+len(keys), "\r\n$3\r\nDEL")
-	r.addBytesList(keys)
-	return c.commandInteger(r)
+// BytesTYPE executes <https://redis.io/commands/type>.
+// The return is TypeNone if key does not exist.
+func (c *Client) BytesTYPE(key []byte) (KeyType, error) {
+	r := newRequest("*2\r\n$4\r\nTYPE\r\n$")
+	r.addBytes(key)
+	s, err := c.commandSimpleString(r)
+	return KeyType(s), err
 }
 
 // INCR executes <https://redis.io/commands/incr>.
@@ -372,6 +1643,22 @@ func (c *Client) BytesINCRBY(key []byte, increment int64) (newValue int64, err e
 	return c.commandInteger(r)
 }
 
+// INCRBYFLOAT executes <https://redis.io/commands/incrbyfloat>.
+func (c *Client) INCRBYFLOAT(key string, increment float64) (newValue float64, err error) {
+	r := newRequest("*3\r\n$11\r\nINCRBYFLOAT\r\n$")
+	r.addStringString(key, formatFloat(increment))
+	newValue, _, err = c.commandFloat(r)
+	return
+}
+
+// BytesINCRBYFLOAT executes <https://redis.io/commands/incrbyfloat>.
+func (c *Client) BytesINCRBYFLOAT(key []byte, increment float64) (newValue float64, err error) {
+	r := newRequest("*3\r\n$11\r\nINCRBYFLOAT\r\n$")
+	r.addBytesString(key, formatFloat(increment))
+	newValue, _, err = c.commandFloat(r)
+	return
+}
+
 // STRLEN executes <https://redis.io/commands/strlen>.
 func (c *Client) STRLEN(key string) (int64, error) {
 	r := newRequest("*2\r\n$6\r\nSTRLEN\r\n$")
@@ -408,6 +1695,143 @@ func (c *Client) BytesGETRANGE(key []byte, start, end int64) ([]byte, error) {
 	return c.commandBlobBytes(r)
 }
 
+// GETRANGEInto executes <https://redis.io/commands/getrange>, with the value
+// copied into dst, e.g., to read fixed-width records without allocation. When
+// dst is too small, then the return is io.ErrShortBuffer, with n set to the
+// size required.
+func (c *Client) GETRANGEInto(key string, start, end int64, dst []byte) (n int, err error) {
+	r := newRequest("*4\r\n$8\r\nGETRANGE\r\n$")
+	r.addStringIntInt(key, start, end)
+	n, _, err = c.commandBlobInto(r, dst)
+	return n, err
+}
+
+// SETRANGE executes <https://redis.io/commands/setrange>. The offset plus the
+// length of value can not exceed SizeMax.
+func (c *Client) SETRANGE(key string, offset int64, value []byte) (newLen int64, err error) {
+	if offset < 0 || offset > SizeMax-int64(len(value)) {
+		return 0, fmt.Errorf("redis: offset %d out of range", offset)
+	}
+	r := newRequest("*4\r\n$8\r\nSETRANGE\r\n$")
+	r.addStringIntBytes(key, offset, value)
+	return c.commandInteger(r)
+}
+
+// BytesSETRANGE executes <https://redis.io/commands/setrange>. The offset plus
+// the length of value can not exceed SizeMax.
+func (c *Client) BytesSETRANGE(key []byte, offset int64, value []byte) (newLen int64, err error) {
+	if offset < 0 || offset > SizeMax-int64(len(value)) {
+		return 0, fmt.Errorf("redis: offset %d out of range", offset)
+	}
+	r := newRequest("*4\r\n$8\r\nSETRANGE\r\n$")
+	r.addBytesIntBytes(key, offset, value)
+	return c.commandInteger(r)
+}
+
+// SETRANGEString executes <https://redis.io/commands/setrange>. The offset
+// plus the length of value can not exceed SizeMax.
+func (c *Client) SETRANGEString(key string, offset int64, value string) (newLen int64, err error) {
+	if offset < 0 || offset > SizeMax-int64(len(value)) {
+		return 0, fmt.Errorf("redis: offset %d out of range", offset)
+	}
+	r := newRequest("*4\r\n$8\r\nSETRANGE\r\n$")
+	r.addStringIntString(key, offset, value)
+	return c.commandInteger(r)
+}
+
+// GETDEL executes <https://redis.io/commands/getdel>.
+// The return is nil if key does not exist.
+func (c *Client) GETDEL(key string) (value []byte, err error) {
+	r := newRequest("*2\r\n$6\r\nGETDEL\r\n$")
+	r.addString(key)
+	return c.commandBlobBytes(r)
+}
+
+// GETDELString executes <https://redis.io/commands/getdel>.
+// Boolean ok is false if key does not exist.
+func (c *Client) GETDELString(key string) (value string, ok bool, err error) {
+	r := newRequest("*2\r\n$6\r\nGETDEL\r\n$")
+	r.addString(key)
+	return c.commandBlobString(r)
+}
+
+// BytesGETDEL executes <https://redis.io/commands/getdel>.
+// The return is nil if key does not exist.
+func (c *Client) BytesGETDEL(key []byte) (value []byte, err error) {
+	r := newRequest("*2\r\n$6\r\nGETDEL\r\n$")
+	r.addBytes(key)
+	return c.commandBlobBytes(r)
+}
+
+// GETEX executes <https://redis.io/commands/getex> with options.
+// The return is nil if key does not exist.
+func (c *Client) GETEX(key string, o GETEXOptions) (value []byte, err error) {
+	expireArg, expire, err := o.args()
+	if err != nil {
+		return nil, err
+	}
+
+	var r *request
+	switch expireArg {
+	case "":
+		r = newRequest("*2\r\n$5\r\nGETEX\r\n$")
+		r.addString(key)
+	case "PERSIST":
+		r = newRequest("*3\r\n$5\r\nGETEX\r\n$")
+		r.addStringString(key, expireArg)
+	default:
+		r = newRequest("*4\r\n$5\r\nGETEX\r\n$")
+		r.addStringStringInt(key, expireArg, expire)
+	}
+	return c.commandBlobBytes(r)
+}
+
+// GETEXString executes <https://redis.io/commands/getex> with options.
+// Boolean ok is false if key does not exist.
+func (c *Client) GETEXString(key string, o GETEXOptions) (value string, ok bool, err error) {
+	expireArg, expire, err := o.args()
+	if err != nil {
+		return "", false, err
+	}
+
+	var r *request
+	switch expireArg {
+	case "":
+		r = newRequest("*2\r\n$5\r\nGETEX\r\n$")
+		r.addString(key)
+	case "PERSIST":
+		r = newRequest("*3\r\n$5\r\nGETEX\r\n$")
+		r.addStringString(key, expireArg)
+	default:
+		r = newRequest("*4\r\n$5\r\nGETEX\r\n$")
+		r.addStringStringInt(key, expireArg, expire)
+	}
+	return c.commandBlobString(r)
+}
+
+// BytesGETEX executes <https://redis.io/commands/getex> with options.
+// The return is nil if key does not exist.
+func (c *Client) BytesGETEX(key []byte, o GETEXOptions) (value []byte, err error) {
+	expireArg, expire, err := o.args()
+	if err != nil {
+		return nil, err
+	}
+
+	var r *request
+	switch expireArg {
+	case "":
+		r = newRequest("*2\r\n$5\r\nGETEX\r\n$")
+		r.addBytes(key)
+	case "PERSIST":
+		r = newRequest("*3\r\n$5\r\nGETEX\r\n$")
+		r.addBytesString(key, expireArg)
+	default:
+		r = newRequest("*4\r\n$5\r\nGETEX\r\n$")
+		r.addBytesStringInt(key, expireArg, expire)
+	}
+	return c.commandBlobBytes(r)
+}
+
 // APPEND executes <https://redis.io/commands/append>.
 func (c *Client) APPEND(key string, value []byte) (newLen int64, err error) {
 	r := newRequest("*3\r\n$6\r\nAPPEND\r\n$")
@@ -429,6 +1853,22 @@ func (c *Client) APPENDString(key, value string) (newLen int64, err error) {
 	return c.commandInteger(r)
 }
 
+// APPENDFrom executes <https://redis.io/commands/append> with a value of length
+// bytes read from r. The value is streamed to the network connection instead of
+// buffered in memory. The command timeout, if any, applies to the transfer as a
+// whole. A reader with less or more than length bytes breaks the connection,
+// which causes a reconnect and an error return. The check for more reads ahead
+// with the last byte only, such that readers without EOF, like io.Pipe, don't
+// block.
+func (c *Client) APPENDFrom(key string, r io.Reader, length int64) (newLen int64, err error) {
+	if length < 0 || length > SizeMax {
+		return 0, fmt.Errorf("redis: payload length %d out of range", length)
+	}
+	req := newRequest("*3\r\n$6\r\nAPPEND\r\n$")
+	req.addStringPayloadSize(key, length)
+	return c.commandIntegerPayload(req, r, length)
+}
+
 // LLEN executes <https://redis.io/commands/llen>.
 // The return is 0 if key does not exist.
 func (c *Client) LLEN(key string) (int64, error) {
@@ -472,10 +1912,31 @@ func (c *Client) BytesLINDEX(key []byte, index int64) (value []byte, err error)
 	return c.commandBlobBytes(r)
 }
 
+// LINDEXInto executes <https://redis.io/commands/lindex>, with the value copied
+// into dst. Boolean ok is false if key does not exist, or if index is out of
+// range. When dst is too small, then the return is io.ErrShortBuffer, with n
+// set to the size required.
+func (c *Client) LINDEXInto(key string, index int64, dst []byte) (n int, ok bool, err error) {
+	r := newRequest("*3\r\n$6\r\nLINDEX\r\n$")
+	r.addStringInt(key, index)
+	return c.commandBlobInto(r, dst)
+}
+
+// BytesLINDEXInto executes <https://redis.io/commands/lindex>, with the value
+// copied into dst. Boolean ok is false if key does not exist, or if index is out
+// of range. When dst is too small, then the return is io.ErrShortBuffer, with n
+// set to the size required.
+func (c *Client) BytesLINDEXInto(key []byte, index int64, dst []byte) (n int, ok bool, err error) {
+	r := newRequest("*3\r\n$6\r\nLINDEX\r\n$")
+	r.addBytesInt(key, index)
+	return c.commandBlobInto(r, dst)
+}
+
 // LRANGE executes <https://redis.io/commands/lrange>.
 // The return is empty if key does not exist.
 func (c *Client) LRANGE(key string, start, stop int64) (values [][]byte, err error) {
 	r := newRequest("*4\r\n$6\r\nLRANGE\r\n$")
+	r.readOnly = true
 	r.addStringIntInt(key, start, stop)
 	return c.commandBytesArray(r)
 }
@@ -484,14 +1945,28 @@ func (c *Client) LRANGE(key string, start, stop int64) (values [][]byte, err err
 // The return is empty if key does not exist.
 func (c *Client) LRANGEString(key string, start, stop int64) (values []string, err error) {
 	r := newRequest("*4\r\n$6\r\nLRANGE\r\n$")
+	r.readOnly = true
 	r.addStringIntInt(key, start, stop)
 	return c.commandStringArray(r)
 }
 
+// LRANGEFunc executes <https://redis.io/commands/lrange>, with each value
+// passed to fn, in order, as the response streams in. Fn must not retain value
+// after return—make a copy if the bytes are used later. An error from fn skips
+// the remaining values, and it returns as is. The command does not retry, as fn
+// may have received values already.
+func (c *Client) LRANGEFunc(key string, start, stop int64, fn func(value []byte) error) error {
+	r := newRequest("*4\r\n$6\r\nLRANGE\r\n$")
+	r.readOnly = true
+	r.addStringIntInt(key, start, stop)
+	return c.commandBytesArrayFunc(r, fn)
+}
+
 // BytesLRANGE executes <https://redis.io/commands/lrange>.
 // The return is empty if key does not exist.
 func (c *Client) BytesLRANGE(key []byte, start, stop int64) (values [][]byte, err error) {
 	r := newRequest("*4\r\n$6\r\nLRANGE\r\n$")
+	r.readOnly = true
 	r.addBytesIntInt(key, start, stop)
 	return c.commandBytesArray(r)
 }
@@ -528,22 +2003,270 @@ func (c *Client) RPOP(key string) (value []byte, err error) {
 	return c.commandBlobBytes(r)
 }
 
-// RPOPString executes <https://redis.io/commands/rpop>.
-// Boolean ok is false if key does not exist.
-func (c *Client) RPOPString(key string) (value string, ok bool, err error) {
-	r := newRequest("*2\r\n$4\r\nRPOP\r\n$")
-	r.addString(key)
+// RPOPString executes <https://redis.io/commands/rpop>.
+// Boolean ok is false if key does not exist.
+func (c *Client) RPOPString(key string) (value string, ok bool, err error) {
+	r := newRequest("*2\r\n$4\r\nRPOP\r\n$")
+	r.addString(key)
+	return c.commandBlobString(r)
+}
+
+// BytesRPOP executes <https://redis.io/commands/rpop>.
+// The return is nil if key does not exist.
+func (c *Client) BytesRPOP(key []byte) (value []byte, err error) {
+	r := newRequest("*2\r\n$4\r\nRPOP\r\n$")
+	r.addBytes(key)
+	return c.commandBlobBytes(r)
+}
+
+// LPOPCount executes <https://redis.io/commands/lpop> with a count, for up to
+// count values. The return is nil if key does not exist.
+func (c *Client) LPOPCount(key string, count int64) (values [][]byte, err error) {
+	r := newRequest("*3\r\n$4\r\nLPOP\r\n$")
+	r.addStringInt(key, count)
+	return c.commandBytesArray(r)
+}
+
+// LPOPCountString executes <https://redis.io/commands/lpop> with a count, for up
+// to count values. The return is nil if key does not exist.
+func (c *Client) LPOPCountString(key string, count int64) (values []string, err error) {
+	r := newRequest("*3\r\n$4\r\nLPOP\r\n$")
+	r.addStringInt(key, count)
+	return c.commandStringArray(r)
+}
+
+// BytesLPOPCount executes <https://redis.io/commands/lpop> with a count, for up
+// to count values. The return is nil if key does not exist.
+func (c *Client) BytesLPOPCount(key []byte, count int64) (values [][]byte, err error) {
+	r := newRequest("*3\r\n$4\r\nLPOP\r\n$")
+	r.addBytesInt(key, count)
+	return c.commandBytesArray(r)
+}
+
+// RPOPCount executes <https://redis.io/commands/rpop> with a count, for up to
+// count values. The return is nil if key does not exist.
+func (c *Client) RPOPCount(key string, count int64) (values [][]byte, err error) {
+	r := newRequest("*3\r\n$4\r\nRPOP\r\n$")
+	r.addStringInt(key, count)
+	return c.commandBytesArray(r)
+}
+
+// RPOPCountString executes <https://redis.io/commands/rpop> with a count, for up
+// to count values. The return is nil if key does not exist.
+func (c *Client) RPOPCountString(key string, count int64) (values []string, err error) {
+	r := newRequest("*3\r\n$4\r\nRPOP\r\n$")
+	r.addStringInt(key, count)
+	return c.commandStringArray(r)
+}
+
+// BytesRPOPCount executes <https://redis.io/commands/rpop> with a count, for up
+// to count values. The return is nil if key does not exist.
+func (c *Client) BytesRPOPCount(key []byte, count int64) (values [][]byte, err error) {
+	r := newRequest("*3\r\n$4\r\nRPOP\r\n$")
+	r.addBytesInt(key, count)
+	return c.commandBytesArray(r)
+}
+
+// List Ends For LMOVE
+const (
+	// LEFT is the head of a list.
+	LEFT = "LEFT"
+	// RIGHT is the tail of a list.
+	RIGHT = "RIGHT"
+)
+
+// LMOVE executes <https://redis.io/commands/lmove>. The from and to arguments
+// are either LEFT or RIGHT. The return is nil if source does not exist.
+func (c *Client) LMOVE(source, destination, from, to string) (value []byte, err error) {
+	r := newRequest("*5\r\n$5\r\nLMOVE\r\n$")
+	r.addStringStringStringString(source, destination, from, to)
+	return c.commandBlobBytes(r)
+}
+
+// LMOVEString executes <https://redis.io/commands/lmove>. The from and to
+// arguments are either LEFT or RIGHT. Boolean ok is false if source does not
+// exist.
+func (c *Client) LMOVEString(source, destination, from, to string) (value string, ok bool, err error) {
+	r := newRequest("*5\r\n$5\r\nLMOVE\r\n$")
+	r.addStringStringStringString(source, destination, from, to)
 	return c.commandBlobString(r)
 }
 
-// BytesRPOP executes <https://redis.io/commands/rpop>.
-// The return is nil if key does not exist.
-func (c *Client) BytesRPOP(key []byte) (value []byte, err error) {
-	r := newRequest("*2\r\n$4\r\nRPOP\r\n$")
-	r.addBytes(key)
+// BytesLMOVE executes <https://redis.io/commands/lmove>. The from and to
+// arguments are either LEFT or RIGHT. The return is nil if source does not
+// exist.
+func (c *Client) BytesLMOVE(source, destination []byte, from, to string) (value []byte, err error) {
+	r := newRequest("*5\r\n$5\r\nLMOVE\r\n$")
+	r.addBytesBytesStringString(source, destination, from, to)
 	return c.commandBlobBytes(r)
 }
 
+// LMPOP executes <https://redis.io/commands/lmpop> on the first non-empty key.
+// The where argument is either LEFT or RIGHT. The return has the key popped
+// from, which is the empty string when all keys are empty. LMPOP requires
+// Redis 7.
+func (c *Client) LMPOP(where string, count int64, keys ...string) (key string, values [][]byte, err error) {
+	return c.lmpop(0, where, count, keys)
+}
+
+// BLMPOP executes <https://redis.io/commands/blmpop>. The return has the key
+// popped from, which is the empty string on timeout. See BZPOPMIN for the
+// blocking semantics. BLMPOP requires Redis 7.
+func (c *Client) BLMPOP(timeout time.Duration, where string, count int64, keys ...string) (key string, values [][]byte, err error) {
+	return c.lmpop(blockOf(timeout), where, count, keys)
+}
+
+func (c *Client) lmpop(block time.Duration, where string, count int64, keys []string) (string, [][]byte, error) {
+	args := make([]string, 0, 5+len(keys))
+	var r *request
+	if block == 0 {
+		r = newRequestSize(5+len(keys), "\r\n$5\r\nLMPOP")
+	} else {
+		r = newRequestSize(6+len(keys), "\r\n$6\r\nBLMPOP")
+		args = append(args, blockSeconds(block))
+	}
+	args = append(args, strconv.Itoa(len(keys)))
+	args = append(args, keys...)
+	args = append(args, where, "COUNT", strconv.FormatInt(count, 10))
+	r.addStringList(args)
+	return c.commandLMPop(r, block)
+}
+
+func insertArg(before bool) string {
+	if before {
+		return "BEFORE"
+	}
+	return "AFTER"
+}
+
+// LINSERT executes <https://redis.io/commands/linsert>, with element placed
+// either before or after the first occurrence of pivot. Boolean ok is false if
+// pivot was not found, which includes the case where key does not exist.
+func (c *Client) LINSERT(key string, before bool, pivot, element []byte) (newLen int64, ok bool, err error) {
+	r := newRequest("*5\r\n$7\r\nLINSERT\r\n$")
+	r.addStringStringBytesBytes(key, insertArg(before), pivot, element)
+	newLen, err = c.commandInteger(r)
+	if newLen <= 0 {
+		return 0, false, err
+	}
+	return newLen, true, err
+}
+
+// LINSERTString executes <https://redis.io/commands/linsert>, with element
+// placed either before or after the first occurrence of pivot. Boolean ok is
+// false if pivot was not found, which includes the case where key does not
+// exist.
+func (c *Client) LINSERTString(key string, before bool, pivot, element string) (newLen int64, ok bool, err error) {
+	r := newRequest("*5\r\n$7\r\nLINSERT\r\n$")
+	r.addStringStringStringString(key, insertArg(before), pivot, element)
+	newLen, err = c.commandInteger(r)
+	if newLen <= 0 {
+		return 0, false, err
+	}
+	return newLen, true, err
+}
+
+// BytesLINSERT executes <https://redis.io/commands/linsert>, with element
+// placed either before or after the first occurrence of pivot. Boolean ok is
+// false if pivot was not found, which includes the case where key does not
+// exist.
+func (c *Client) BytesLINSERT(key []byte, before bool, pivot, element []byte) (newLen int64, ok bool, err error) {
+	r := newRequest("*5\r\n$7\r\nLINSERT\r\n$")
+	r.addBytesStringBytesBytes(key, insertArg(before), pivot, element)
+	newLen, err = c.commandInteger(r)
+	if newLen <= 0 {
+		return 0, false, err
+	}
+	return newLen, true, err
+}
+
+// newLPOSRequest returns a request with room for the LPOS arguments. The RANK
+// option is omitted when zero, and COUNT is omitted when negative.
+func newLPOSRequest(rank, count int64) *request {
+	n := 3
+	if rank != 0 {
+		n += 2
+	}
+	if count >= 0 {
+		n += 2
+	}
+	return newRequestSize(n, "\r\n$4\r\nLPOS\r\n$")
+}
+
+// LPOS executes <https://redis.io/commands/lpos>. A non-zero rank selects the
+// nth match, with negative values counting from the tail. Boolean ok is false
+// if element was not found, which includes the case where key does not exist.
+func (c *Client) LPOS(key string, element []byte, rank int64) (index int64, ok bool, err error) {
+	r := newLPOSRequest(rank, -1)
+	r.addStringBytes(key, element)
+	r.addLPOSArgs(rank, -1)
+	return c.commandIntegerOK(r)
+}
+
+// LPOSString executes <https://redis.io/commands/lpos>. A non-zero rank selects
+// the nth match, with negative values counting from the tail. Boolean ok is
+// false if element was not found, which includes the case where key does not
+// exist.
+func (c *Client) LPOSString(key, element string, rank int64) (index int64, ok bool, err error) {
+	r := newLPOSRequest(rank, -1)
+	r.addStringString(key, element)
+	r.addLPOSArgs(rank, -1)
+	return c.commandIntegerOK(r)
+}
+
+// BytesLPOS executes <https://redis.io/commands/lpos>. A non-zero rank selects
+// the nth match, with negative values counting from the tail. Boolean ok is
+// false if element was not found, which includes the case where key does not
+// exist.
+func (c *Client) BytesLPOS(key, element []byte, rank int64) (index int64, ok bool, err error) {
+	r := newLPOSRequest(rank, -1)
+	r.addBytesBytes(key, element)
+	r.addLPOSArgs(rank, -1)
+	return c.commandIntegerOK(r)
+}
+
+// LPOSCount executes <https://redis.io/commands/lpos> with a count, for the
+// indices of up to count matches. Zero count returns all matches. A non-zero
+// rank selects the nth match to start from, with negative values counting from
+// the tail. The return is empty if key does not exist.
+func (c *Client) LPOSCount(key string, element []byte, rank, count int64) (indices []int64, err error) {
+	if count < 0 {
+		return nil, fmt.Errorf("redis: LPOS count %d is negative", count)
+	}
+	r := newLPOSRequest(rank, count)
+	r.addStringBytes(key, element)
+	r.addLPOSArgs(rank, count)
+	return c.commandIntegerArray(r)
+}
+
+// LPOSCountString executes <https://redis.io/commands/lpos> with a count, for
+// the indices of up to count matches. Zero count returns all matches. A non-zero
+// rank selects the nth match to start from, with negative values counting from
+// the tail. The return is empty if key does not exist.
+func (c *Client) LPOSCountString(key, element string, rank, count int64) (indices []int64, err error) {
+	if count < 0 {
+		return nil, fmt.Errorf("redis: LPOS count %d is negative", count)
+	}
+	r := newLPOSRequest(rank, count)
+	r.addStringString(key, element)
+	r.addLPOSArgs(rank, count)
+	return c.commandIntegerArray(r)
+}
+
+// BytesLPOSCount executes <https://redis.io/commands/lpos> with a count, for
+// the indices of up to count matches. Zero count returns all matches. A non-zero
+// rank selects the nth match to start from, with negative values counting from
+// the tail. The return is empty if key does not exist.
+func (c *Client) BytesLPOSCount(key, element []byte, rank, count int64) (indices []int64, err error) {
+	if count < 0 {
+		return nil, fmt.Errorf("redis: LPOS count %d is negative", count)
+	}
+	r := newLPOSRequest(rank, count)
+	r.addBytesBytes(key, element)
+	r.addLPOSArgs(rank, count)
+	return c.commandIntegerArray(r)
+}
+
 // LTRIM executes <https://redis.io/commands/ltrim>.
 func (c *Client) LTRIM(key string, start, stop int64) error {
 	r := newRequest("*4\r\n$5\r\nLTRIM\r\n$")
@@ -625,6 +2348,7 @@ func (c *Client) RPUSHString(key, value string) (newLen int64, err error) {
 // The return is nil if key does not exist.
 func (c *Client) HGET(key, field string) (value []byte, err error) {
 	r := newRequest("*3\r\n$4\r\nHGET\r\n$")
+	r.readOnly = true
 	r.addStringString(key, field)
 	return c.commandBlobBytes(r)
 }
@@ -633,6 +2357,7 @@ func (c *Client) HGET(key, field string) (value []byte, err error) {
 // Boolean ok is false if key does not exist.
 func (c *Client) HGETString(key, field string) (value string, ok bool, err error) {
 	r := newRequest("*3\r\n$4\r\nHGET\r\n$")
+	r.readOnly = true
 	r.addStringString(key, field)
 	return c.commandBlobString(r)
 }
@@ -641,10 +2366,74 @@ func (c *Client) HGETString(key, field string) (value string, ok bool, err error
 // The return is nil if key does not exist.
 func (c *Client) BytesHGET(key, field []byte) (value []byte, err error) {
 	r := newRequest("*3\r\n$4\r\nHGET\r\n$")
+	r.readOnly = true
 	r.addBytesBytes(key, field)
 	return c.commandBlobBytes(r)
 }
 
+// HGETInto executes <https://redis.io/commands/hget>, with the value copied into
+// dst. Boolean ok is false if key does not exist. When dst is too small, then
+// the return is io.ErrShortBuffer, with n set to the size required.
+func (c *Client) HGETInto(key, field string, dst []byte) (n int, ok bool, err error) {
+	r := newRequest("*3\r\n$4\r\nHGET\r\n$")
+	r.readOnly = true
+	r.addStringString(key, field)
+	return c.commandBlobInto(r, dst)
+}
+
+// BytesHGETInto executes <https://redis.io/commands/hget>, with the value copied
+// into dst. Boolean ok is false if key does not exist. When dst is too small,
+// then the return is io.ErrShortBuffer, with n set to the size required.
+func (c *Client) BytesHGETInto(key, field, dst []byte) (n int, ok bool, err error) {
+	r := newRequest("*3\r\n$4\r\nHGET\r\n$")
+	r.readOnly = true
+	r.addBytesBytes(key, field)
+	return c.commandBlobInto(r, dst)
+}
+
+// HGETALLMap executes <https://redis.io/commands/hgetall>.
+// The return is empty (and not nil) if key does not exist.
+func (c *Client) HGETALLMap(key string) (map[string][]byte, error) {
+	r := newRequest("*2\r\n$7\r\nHGETALL\r\n$")
+	r.addString(key)
+	return c.commandBytesMap(r)
+}
+
+// HGETALLStringMap executes <https://redis.io/commands/hgetall>.
+// The return is empty (and not nil) if key does not exist.
+func (c *Client) HGETALLStringMap(key string) (map[string]string, error) {
+	r := newRequest("*2\r\n$7\r\nHGETALL\r\n$")
+	r.addString(key)
+	return c.commandStringMap(r)
+}
+
+// HGETALLFunc executes <https://redis.io/commands/hgetall>, with each field and
+// value passed to fn, like LRANGEFunc. Fn must not retain value after return.
+func (c *Client) HGETALLFunc(key string, fn func(field string, value []byte) error) error {
+	r := newRequest("*2\r\n$7\r\nHGETALL\r\n$")
+	r.addString(key)
+	return c.commandStringBytesPairsFunc(r, fn)
+}
+
+// HRANDFIELD executes <https://redis.io/commands/hrandfield> with a count.
+// A negative count allows for the same field multiple times. The return is
+// empty if key does not exist.
+func (c *Client) HRANDFIELD(key string, count int64) (fields []string, err error) {
+	r := newRequest("*3\r\n$10\r\nHRANDFIELD\r\n$")
+	r.addStringInt(key, count)
+	return c.commandStringArray(r)
+}
+
+// HRANDFIELDWithValues executes <https://redis.io/commands/hrandfield> with a
+// count and WITHVALUES. A negative count allows for the same field multiple
+// times. Each value is at the same index as its field. The return is empty if
+// key does not exist.
+func (c *Client) HRANDFIELDWithValues(key string, count int64) (fields []string, values [][]byte, err error) {
+	r := newRequest("*4\r\n$10\r\nHRANDFIELD\r\n$")
+	r.addStringIntString(key, count, "WITHVALUES")
+	return c.commandStringBytesPairs(r)
+}
+
 // HKEYS executes <https://redis.io/commands/hkeys>.
 func (c *Client) HKEYS(key string) (values [][]byte, err error) {
 	r := newRequest("*2\r\n$5\r\nHKEYS\r\n$")
@@ -690,6 +2479,22 @@ func (c *Client) HSETString(key, field, value string) (updated bool, err error)
 	return replaced != 0, err
 }
 
+// HSETMulti executes <https://redis.io/commands/hset> with all fields at once.
+// The return is the number of fields added, i.e., excluding updates.
+func (c *Client) HSETMulti(key string, fields map[string][]byte) (int64, error) {
+	r := newRequestSize(2+len(fields)*2, "\r\n$4\r\nHSET\r\n$")
+	r.addStringStringBytesMap(key, fields)
+	return c.commandInteger(r)
+}
+
+// HSETMultiString executes <https://redis.io/commands/hset> with all fields at
+// once. The return is the number of fields added, i.e., excluding updates.
+func (c *Client) HSETMultiString(key string, fields map[string]string) (int64, error) {
+	r := newRequestSize(2+len(fields)*2, "\r\n$4\r\nHSET\r\n$")
+	r.addStringStringStringMap(key, fields)
+	return c.commandInteger(r)
+}
+
 // HDEL executes <https://redis.io/commands/hdel>.
 func (c *Client) HDEL(key, field string) (bool, error) {
 	r := newRequest("*3\r\n$4\r\nHDEL\r\n$")
@@ -698,6 +2503,22 @@ func (c *Client) HDEL(key, field string) (bool, error) {
 	return removed != 0, err
 }
 
+// HINCRBYFLOAT executes <https://redis.io/commands/hincrbyfloat>.
+func (c *Client) HINCRBYFLOAT(key, field string, increment float64) (newValue float64, err error) {
+	r := newRequest("*4\r\n$12\r\nHINCRBYFLOAT\r\n$")
+	r.addStringStringString(key, field, formatFloat(increment))
+	newValue, _, err = c.commandFloat(r)
+	return
+}
+
+// BytesHINCRBYFLOAT executes <https://redis.io/commands/hincrbyfloat>.
+func (c *Client) BytesHINCRBYFLOAT(key, field []byte, increment float64) (newValue float64, err error) {
+	r := newRequest("*4\r\n$12\r\nHINCRBYFLOAT\r\n$")
+	r.addBytesBytesString(key, field, formatFloat(increment))
+	newValue, _, err = c.commandFloat(r)
+	return
+}
+
 // HLEN executes <https://redis.io/commands/hlen>.
 func (c *Client) HLEN(key string) (int64, error) {
 	r := newRequest("*2\r\n$4\r\nHLEN\r\n$")
@@ -731,6 +2552,7 @@ func (c *Client) BytesHDELArgs(key []byte, fields ...[]byte) (int64, error) {
 // For every field that does not exist, a nil value is returned.
 func (c *Client) HMGET(key string, fields ...string) (values [][]byte, err error) {
 	r := newRequestSize(2+len(fields), "\r\n$5\r\nHMGET\r\n$")
+	r.readOnly = true
 	r.addStringStringList(key, fields)
 	return c.commandBytesArray(r)
 }
@@ -739,6 +2561,7 @@ func (c *Client) HMGET(key string, fields ...string) (values [][]byte, err error
 // For every field that does not exist, an empty string is returned.
 func (c *Client) HMGETString(key string, fields ...string) (values []string, err error) {
 	r := newRequestSize(2+len(fields), "\r\n$5\r\nHMGET\r\n$")
+	r.readOnly = true
 	r.addStringStringList(key, fields)
 	return c.commandStringArray(r)
 }
@@ -747,6 +2570,7 @@ func (c *Client) HMGETString(key string, fields ...string) (values []string, err
 // For every field that does not exist, a nil value is returned.
 func (c *Client) BytesHMGET(key []byte, fields ...[]byte) (values [][]byte, err error) {
 	r := newRequestSize(2+len(fields), "\r\n$5\r\nHMGET\r\n$")
+	r.readOnly = true
 	r.addBytesBytesList(key, fields)
 	return c.commandBytesArray(r)
 }
@@ -756,6 +2580,7 @@ func (c *Client) BytesHMSET(key []byte, fields, values [][]byte) error {
 	r := newRequestSize(2+len(fields)*2, "\r\n$5\r\nHMSET\r\n$")
 	err := r.addBytesBytesBytesMapLists(key, fields, values)
 	if err != nil {
+		r.free()
 		return err
 	}
 	return c.commandOK(r)
@@ -766,6 +2591,7 @@ func (c *Client) HMSET(key string, fields []string, values [][]byte) error {
 	r := newRequestSize(2+len(fields)*2, "\r\n$5\r\nHMSET\r\n$")
 	err := r.addStringStringBytesMapLists(key, fields, values)
 	if err != nil {
+		r.free()
 		return err
 	}
 	return c.commandOK(r)
@@ -776,11 +2602,385 @@ func (c *Client) HMSETString(key string, fields, values []string) error {
 	r := newRequestSize(2+len(fields)*2, "\r\n$5\r\nHMSET\r\n$")
 	err := r.addStringStringStringMapLists(key, fields, values)
 	if err != nil {
+		r.free()
 		return err
 	}
 	return c.commandOK(r)
 }
 
+// SADD executes <https://redis.io/commands/sadd>.
+// The return is the number of members added, i.e., excluding existing ones.
+func (c *Client) SADD(key string, members ...[]byte) (int64, error) {
+	r := newRequestSize(2+len(members), "\r\n$4\r\nSADD\r\n$")
+	r.addStringBytesList(key, members)
+	return c.commandInteger(r)
+}
+
+// SADDString executes <https://redis.io/commands/sadd>.
+// The return is the number of members added, i.e., excluding existing ones.
+func (c *Client) SADDString(key string, members ...string) (int64, error) {
+	r := newRequestSize(2+len(members), "\r\n$4\r\nSADD\r\n$")
+	r.addStringStringList(key, members)
+	return c.commandInteger(r)
+}
+
+// BytesSADD executes <https://redis.io/commands/sadd>.
+// The return is the number of members added, i.e., excluding existing ones.
+func (c *Client) BytesSADD(key []byte, members ...[]byte) (int64, error) {
+	r := newRequestSize(2+len(members), "\r\n$4\r\nSADD\r\n$")
+	r.addBytesBytesList(key, members)
+	return c.commandInteger(r)
+}
+
+// SREM executes <https://redis.io/commands/srem>.
+// The return is the number of members removed.
+func (c *Client) SREM(key string, members ...[]byte) (int64, error) {
+	r := newRequestSize(2+len(members), "\r\n$4\r\nSREM\r\n$")
+	r.addStringBytesList(key, members)
+	return c.commandInteger(r)
+}
+
+// SREMString executes <https://redis.io/commands/srem>.
+// The return is the number of members removed.
+func (c *Client) SREMString(key string, members ...string) (int64, error) {
+	r := newRequestSize(2+len(members), "\r\n$4\r\nSREM\r\n$")
+	r.addStringStringList(key, members)
+	return c.commandInteger(r)
+}
+
+// BytesSREM executes <https://redis.io/commands/srem>.
+// The return is the number of members removed.
+func (c *Client) BytesSREM(key []byte, members ...[]byte) (int64, error) {
+	r := newRequestSize(2+len(members), "\r\n$4\r\nSREM\r\n$")
+	r.addBytesBytesList(key, members)
+	return c.commandInteger(r)
+}
+
+// SCARD executes <https://redis.io/commands/scard>.
+// The return is 0 if key does not exist.
+func (c *Client) SCARD(key string) (int64, error) {
+	r := newRequest("*2\r\n$5\r\nSCARD\r\n$")
+	r.addString(key)
+	return c.commandInteger(r)
+}
+
+// BytesSCARD executes <https://redis.io/commands/scard>.
+// The return is 0 if key does not exist.
+func (c *Client) BytesSCARD(key []byte) (int64, error) {
+	r := newRequest("*2\r\n$5\r\nSCARD\r\n$")
+	r.addBytes(key)
+	return c.commandInteger(r)
+}
+
+// SMEMBERS executes <https://redis.io/commands/smembers>.
+// The return is empty if key does not exist.
+func (c *Client) SMEMBERS(key string) (members [][]byte, err error) {
+	r := newRequest("*2\r\n$8\r\nSMEMBERS\r\n$")
+	r.addString(key)
+	return c.commandBytesArray(r)
+}
+
+// SMEMBERSString executes <https://redis.io/commands/smembers>.
+// The return is empty if key does not exist.
+func (c *Client) SMEMBERSString(key string) (members []string, err error) {
+	r := newRequest("*2\r\n$8\r\nSMEMBERS\r\n$")
+	r.addString(key)
+	return c.commandStringArray(r)
+}
+
+// SMEMBERSFunc executes <https://redis.io/commands/smembers>, with each member
+// passed to fn, like LRANGEFunc.
+func (c *Client) SMEMBERSFunc(key string, fn func(member []byte) error) error {
+	r := newRequest("*2\r\n$8\r\nSMEMBERS\r\n$")
+	r.addString(key)
+	return c.commandBytesArrayFunc(r, fn)
+}
+
+// BytesSMEMBERS executes <https://redis.io/commands/smembers>.
+// The return is empty if key does not exist.
+func (c *Client) BytesSMEMBERS(key []byte) (members [][]byte, err error) {
+	r := newRequest("*2\r\n$8\r\nSMEMBERS\r\n$")
+	r.addBytes(key)
+	return c.commandBytesArray(r)
+}
+
+// SISMEMBER executes <https://redis.io/commands/sismember>.
+func (c *Client) SISMEMBER(key string, member []byte) (bool, error) {
+	r := newRequest("*3\r\n$9\r\nSISMEMBER\r\n$")
+	r.addStringBytes(key, member)
+	is, err := c.commandInteger(r)
+	return is != 0, err
+}
+
+// SISMEMBERString executes <https://redis.io/commands/sismember>.
+func (c *Client) SISMEMBERString(key, member string) (bool, error) {
+	r := newRequest("*3\r\n$9\r\nSISMEMBER\r\n$")
+	r.addStringString(key, member)
+	is, err := c.commandInteger(r)
+	return is != 0, err
+}
+
+// BytesSISMEMBER executes <https://redis.io/commands/sismember>.
+func (c *Client) BytesSISMEMBER(key, member []byte) (bool, error) {
+	r := newRequest("*3\r\n$9\r\nSISMEMBER\r\n$")
+	r.addBytesBytes(key, member)
+	is, err := c.commandInteger(r)
+	return is != 0, err
+}
+
+func flagsOf(a []int64) []bool {
+	if a == nil {
+		return nil
+	}
+	flags := make([]bool, len(a))
+	for i, v := range a {
+		flags[i] = v != 0
+	}
+	return flags
+}
+
+// SMISMEMBER executes <https://redis.io/commands/smismember>.
+// The return has an entry for each member, in order of appearance.
+func (c *Client) SMISMEMBER(key string, members ...[]byte) ([]bool, error) {
+	r := newRequestSize(2+len(members), "\r\n$10\r\nSMISMEMBER\r\n$")
+	r.addStringBytesList(key, members)
+	a, err := c.commandIntegerArray(r)
+	return flagsOf(a), err
+}
+
+// SMISMEMBERString executes <https://redis.io/commands/smismember>.
+// The return has an entry for each member, in order of appearance.
+func (c *Client) SMISMEMBERString(key string, members ...string) ([]bool, error) {
+	r := newRequestSize(2+len(members), "\r\n$10\r\nSMISMEMBER\r\n$")
+	r.addStringStringList(key, members)
+	a, err := c.commandIntegerArray(r)
+	return flagsOf(a), err
+}
+
+// BytesSMISMEMBER executes <https://redis.io/commands/smismember>.
+// The return has an entry for each member, in order of appearance.
+func (c *Client) BytesSMISMEMBER(key []byte, members ...[]byte) ([]bool, error) {
+	r := newRequestSize(2+len(members), "\r\n$10\r\nSMISMEMBER\r\n$")
+	r.addBytesBytesList(key, members)
+	a, err := c.commandIntegerArray(r)
+	return flagsOf(a), err
+}
+
+// SMOVE executes <https://redis.io/commands/smove>.
+// The return is false if member was not found in source.
+func (c *Client) SMOVE(source, destination string, member []byte) (bool, error) {
+	r := newRequest("*4\r\n$5\r\nSMOVE\r\n$")
+	r.addStringStringBytes(source, destination, member)
+	moved, err := c.commandInteger(r)
+	return moved != 0, err
+}
+
+// SMOVEString executes <https://redis.io/commands/smove>.
+// The return is false if member was not found in source.
+func (c *Client) SMOVEString(source, destination, member string) (bool, error) {
+	r := newRequest("*4\r\n$5\r\nSMOVE\r\n$")
+	r.addStringStringString(source, destination, member)
+	moved, err := c.commandInteger(r)
+	return moved != 0, err
+}
+
+// BytesSMOVE executes <https://redis.io/commands/smove>.
+// The return is false if member was not found in source.
+func (c *Client) BytesSMOVE(source, destination, member []byte) (bool, error) {
+	r := newRequest("*4\r\n$5\r\nSMOVE\r\n$")
+	r.addBytesBytesBytes(source, destination, member)
+	moved, err := c.commandInteger(r)
+	return moved != 0, err
+}
+
+// SPOP executes <https://redis.io/commands/spop>.
+// The return is nil if key does not exist.
+func (c *Client) SPOP(key string) (member []byte, err error) {
+	r := newRequest("*2\r\n$4\r\nSPOP\r\n$")
+	r.addString(key)
+	return c.commandBlobBytes(r)
+}
+
+// SPOPString executes <https://redis.io/commands/spop>.
+// Boolean ok is false if key does not exist.
+func (c *Client) SPOPString(key string) (member string, ok bool, err error) {
+	r := newRequest("*2\r\n$4\r\nSPOP\r\n$")
+	r.addString(key)
+	return c.commandBlobString(r)
+}
+
+// BytesSPOP executes <https://redis.io/commands/spop>.
+// The return is nil if key does not exist.
+func (c *Client) BytesSPOP(key []byte) (member []byte, err error) {
+	r := newRequest("*2\r\n$4\r\nSPOP\r\n$")
+	r.addBytes(key)
+	return c.commandBlobBytes(r)
+}
+
+// SPOPCount executes <https://redis.io/commands/spop> with a count, for up to
+// count members. The return is empty if key does not exist.
+func (c *Client) SPOPCount(key string, count int64) (members [][]byte, err error) {
+	r := newRequest("*3\r\n$4\r\nSPOP\r\n$")
+	r.addStringInt(key, count)
+	return c.commandBytesArray(r)
+}
+
+// SPOPCountString executes <https://redis.io/commands/spop> with a count, for
+// up to count members. The return is empty if key does not exist.
+func (c *Client) SPOPCountString(key string, count int64) (members []string, err error) {
+	r := newRequest("*3\r\n$4\r\nSPOP\r\n$")
+	r.addStringInt(key, count)
+	return c.commandStringArray(r)
+}
+
+// BytesSPOPCount executes <https://redis.io/commands/spop> with a count, for up
+// to count members. The return is empty if key does not exist.
+func (c *Client) BytesSPOPCount(key []byte, count int64) (members [][]byte, err error) {
+	r := newRequest("*3\r\n$4\r\nSPOP\r\n$")
+	r.addBytesInt(key, count)
+	return c.commandBytesArray(r)
+}
+
+// SRANDMEMBER executes <https://redis.io/commands/srandmember>.
+// The return is nil if key does not exist.
+func (c *Client) SRANDMEMBER(key string) (member []byte, err error) {
+	r := newRequest("*2\r\n$11\r\nSRANDMEMBER\r\n$")
+	r.addString(key)
+	return c.commandBlobBytes(r)
+}
+
+// SRANDMEMBERString executes <https://redis.io/commands/srandmember>.
+// Boolean ok is false if key does not exist.
+func (c *Client) SRANDMEMBERString(key string) (member string, ok bool, err error) {
+	r := newRequest("*2\r\n$11\r\nSRANDMEMBER\r\n$")
+	r.addString(key)
+	return c.commandBlobString(r)
+}
+
+// BytesSRANDMEMBER executes <https://redis.io/commands/srandmember>.
+// The return is nil if key does not exist.
+func (c *Client) BytesSRANDMEMBER(key []byte) (member []byte, err error) {
+	r := newRequest("*2\r\n$11\r\nSRANDMEMBER\r\n$")
+	r.addBytes(key)
+	return c.commandBlobBytes(r)
+}
+
+// SRANDMEMBERCount executes <https://redis.io/commands/srandmember> with a
+// count. A positive count returns up to count distinct members. A negative count
+// returns exactly -count members, which may include duplicates. The return is
+// empty if key does not exist.
+func (c *Client) SRANDMEMBERCount(key string, count int64) (members [][]byte, err error) {
+	r := newRequest("*3\r\n$11\r\nSRANDMEMBER\r\n$")
+	r.addStringInt(key, count)
+	return c.commandBytesArray(r)
+}
+
+// SRANDMEMBERCountString executes <https://redis.io/commands/srandmember> with
+// a count. A positive count returns up to count distinct members. A negative
+// count returns exactly -count members, which may include duplicates. The return
+// is empty if key does not exist.
+func (c *Client) SRANDMEMBERCountString(key string, count int64) (members []string, err error) {
+	r := newRequest("*3\r\n$11\r\nSRANDMEMBER\r\n$")
+	r.addStringInt(key, count)
+	return c.commandStringArray(r)
+}
+
+// BytesSRANDMEMBERCount executes <https://redis.io/commands/srandmember> with a
+// count. A positive count returns up to count distinct members. A negative count
+// returns exactly -count members, which may include duplicates. The return is
+// empty if key does not exist.
+func (c *Client) BytesSRANDMEMBERCount(key []byte, count int64) (members [][]byte, err error) {
+	r := newRequest("*3\r\n$11\r\nSRANDMEMBER\r\n$")
+	r.addBytesInt(key, count)
+	return c.commandBytesArray(r)
+}
+
+// SINTERCARD executes <https://redis.io/commands/sintercard>. The cardinality
+// of the intersection is counted up to limit, when positive.
+func (c *Client) SINTERCARD(limit int64, keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, errNoKeys
+	}
+	n := 2 + len(keys)
+	if limit > 0 {
+		n += 2
+	}
+	r := newRequestSize(n, "\r\n$10\r\nSINTERCARD\r\n$")
+	r.decimal(int64(len(keys)))
+	r.addStringList(keys)
+	if limit > 0 {
+		r.addOptionInt("LIMIT", limit)
+	}
+	return c.commandInteger(r)
+}
+
+// BytesSINTERCARD executes <https://redis.io/commands/sintercard>. The
+// cardinality of the intersection is counted up to limit, when positive.
+func (c *Client) BytesSINTERCARD(limit int64, keys ...[]byte) (int64, error) {
+	if len(keys) == 0 {
+		return 0, errNoKeys
+	}
+	n := 2 + len(keys)
+	if limit > 0 {
+		n += 2
+	}
+	r := newRequestSize(n, "\r\n$10\r\nSINTERCARD\r\n$")
+	r.decimal(int64(len(keys)))
+	r.addBytesList(keys)
+	if limit > 0 {
+		r.addOptionInt("LIMIT", limit)
+	}
+	return c.commandInteger(r)
+}
+
+// SDIFFSTORE executes <https://redis.io/commands/sdiffstore>.
+// The return is the number of members in destination.
+func (c *Client) SDIFFSTORE(destination string, keys ...string) (int64, error) {
+	r := newRequestSize(2+len(keys), "\r\n$10\r\nSDIFFSTORE\r\n$")
+	r.addStringStringList(destination, keys)
+	return c.commandInteger(r)
+}
+
+// BytesSDIFFSTORE executes <https://redis.io/commands/sdiffstore>.
+// The return is the number of members in destination.
+func (c *Client) BytesSDIFFSTORE(destination []byte, keys ...[]byte) (int64, error) {
+	r := newRequestSize(2+len(keys), "\r\n$10\r\nSDIFFSTORE\r\n$")
+	r.addBytesBytesList(destination, keys)
+	return c.commandInteger(r)
+}
+
+// SINTERSTORE executes <https://redis.io/commands/sinterstore>.
+// The return is the number of members in destination.
+func (c *Client) SINTERSTORE(destination string, keys ...string) (int64, error) {
+	r := newRequestSize(2+len(keys), "\r\n$11\r\nSINTERSTORE\r\n$")
+	r.addStringStringList(destination, keys)
+	return c.commandInteger(r)
+}
+
+// BytesSINTERSTORE executes <https://redis.io/commands/sinterstore>.
+// The return is the number of members in destination.
+func (c *Client) BytesSINTERSTORE(destination []byte, keys ...[]byte) (int64, error) {
+	r := newRequestSize(2+len(keys), "\r\n$11\r\nSINTERSTORE\r\n$")
+	r.addBytesBytesList(destination, keys)
+	return c.commandInteger(r)
+}
+
+// SUNIONSTORE executes <https://redis.io/commands/sunionstore>.
+// The return is the number of members in destination.
+func (c *Client) SUNIONSTORE(destination string, keys ...string) (int64, error) {
+	r := newRequestSize(2+len(keys), "\r\n$11\r\nSUNIONSTORE\r\n$")
+	r.addStringStringList(destination, keys)
+	return c.commandInteger(r)
+}
+
+// BytesSUNIONSTORE executes <https://redis.io/commands/sunionstore>.
+// The return is the number of members in destination.
+func (c *Client) BytesSUNIONSTORE(destination []byte, keys ...[]byte) (int64, error) {
+	r := newRequestSize(2+len(keys), "\r\n$11\r\nSUNIONSTORE\r\n$")
+	r.addBytesBytesList(destination, keys)
+	return c.commandInteger(r)
+}
+
 // ZADD executes <https://redis.io/commands/zadd>.
 func (c *Client) ZADD(key string, score int64, value []byte) (bool, error) {
 	r := newRequest("*4\r\n$4\r\nZADD\r\n$")
@@ -810,6 +3010,7 @@ func (c *Client) ZADDArgs(key string, scores []int64, values [][]byte) (int64, e
 	r := newRequestSize(2+len(scores)*2, "\r\n$4\r\nZADD\r\n$")
 	err := r.addStringIntBytesMapLists(key, scores, values)
 	if err != nil {
+		r.free()
 		return 0, err
 	}
 	return c.commandInteger(r)
@@ -820,6 +3021,7 @@ func (c *Client) BytesZADDArgs(key []byte, scores []int64, values [][]byte) (int
 	r := newRequestSize(2+len(scores)*2, "\r\n$4\r\nZADD\r\n$")
 	err := r.addBytesIntBytesMapLists(key, scores, values)
 	if err != nil {
+		r.free()
 		return 0, err
 	}
 	return c.commandInteger(r)
@@ -830,11 +3032,131 @@ func (c *Client) ZADDStringArgs(key string, scores []int64, values []string) (in
 	r := newRequestSize(2+len(scores)*2, "\r\n$4\r\nZADD\r\n$")
 	err := r.addStringIntStringMapLists(key, scores, values)
 	if err != nil {
+		r.free()
 		return 0, err
 	}
 	return c.commandInteger(r)
 }
 
+// ZINCRBY executes <https://redis.io/commands/zincrby>. The member is added,
+// with increment as its score, when absent.
+func (c *Client) ZINCRBY(key string, increment float64, member []byte) (newScore float64, err error) {
+	r := newRequest("*4\r\n$7\r\nZINCRBY\r\n$")
+	r.addStringStringBytes(key, formatFloat(increment), member)
+	newScore, _, err = c.commandFloat(r)
+	return
+}
+
+// BytesZINCRBY executes <https://redis.io/commands/zincrby>. The member is
+// added, with increment as its score, when absent.
+func (c *Client) BytesZINCRBY(key []byte, increment float64, member []byte) (newScore float64, err error) {
+	r := newRequest("*4\r\n$7\r\nZINCRBY\r\n$")
+	r.addBytesBytesBytes(key, []byte(formatFloat(increment)), member)
+	newScore, _, err = c.commandFloat(r)
+	return
+}
+
+// ZINCRBYString executes <https://redis.io/commands/zincrby>. The member is
+// added, with increment as its score, when absent.
+func (c *Client) ZINCRBYString(key string, increment float64, member string) (newScore float64, err error) {
+	r := newRequest("*4\r\n$7\r\nZINCRBY\r\n$")
+	r.addStringStringString(key, formatFloat(increment), member)
+	newScore, _, err = c.commandFloat(r)
+	return
+}
+
+// ZMember is a sorted set element.
+type ZMember struct {
+	Member []byte
+	Score  float64
+}
+
+// ZPOPMIN executes <https://redis.io/commands/zpopmin> with a count.
+// The return is empty if key does not exist.
+func (c *Client) ZPOPMIN(key string, count int64) ([]ZMember, error) {
+	r := newRequest("*3\r\n$7\r\nZPOPMIN\r\n$")
+	r.addStringInt(key, count)
+	return c.commandZMembers(r)
+}
+
+// ZPOPMAX executes <https://redis.io/commands/zpopmax> with a count.
+// The return is empty if key does not exist.
+func (c *Client) ZPOPMAX(key string, count int64) ([]ZMember, error) {
+	r := newRequest("*3\r\n$7\r\nZPOPMAX\r\n$")
+	r.addStringInt(key, count)
+	return c.commandZMembers(r)
+}
+
+// BZPOPMIN executes <https://redis.io/commands/bzpopmin>. When all keys are
+// empty, then the server holds the response for up to timeout, or indefinitely
+// with a zero timeout. Boolean ok is false on timeout. The command timeout of
+// the Client extends with the blocking timeout. Commands submitted after a
+// blocking one wait for its completion, so use a dedicated Client for blocking
+// pops.
+func (c *Client) BZPOPMIN(timeout time.Duration, keys ...string) (key string, m ZMember, ok bool, err error) {
+	block := blockOf(timeout)
+	r := newRequestSize(2+len(keys), "\r\n$8\r\nBZPOPMIN")
+	r.addStringList(append(keys[:len(keys):len(keys)], blockSeconds(block)))
+	return c.commandZPop(r, block)
+}
+
+// BZPOPMAX executes <https://redis.io/commands/bzpopmax>. When all keys are
+// empty, then the server holds the response for up to timeout, or indefinitely
+// with a zero timeout. Boolean ok is false on timeout. The command timeout of
+// the Client extends with the blocking timeout. Commands submitted after a
+// blocking one wait for its completion, so use a dedicated Client for blocking
+// pops.
+func (c *Client) BZPOPMAX(timeout time.Duration, keys ...string) (key string, m ZMember, ok bool, err error) {
+	block := blockOf(timeout)
+	r := newRequestSize(2+len(keys), "\r\n$8\r\nBZPOPMAX")
+	r.addStringList(append(keys[:len(keys):len(keys)], blockSeconds(block)))
+	return c.commandZPop(r, block)
+}
+
+// ZMPOPMIN executes <https://redis.io/commands/zmpop> with MIN, on the first
+// non-empty key. The return has the key popped from, which is the empty string
+// when all keys are empty. ZMPOP requires Redis 7.
+func (c *Client) ZMPOPMIN(count int64, keys ...string) (key string, members []ZMember, err error) {
+	return c.zmpop(0, "MIN", count, keys)
+}
+
+// ZMPOPMAX executes <https://redis.io/commands/zmpop> with MAX, on the first
+// non-empty key. The return has the key popped from, which is the empty string
+// when all keys are empty. ZMPOP requires Redis 7.
+func (c *Client) ZMPOPMAX(count int64, keys ...string) (key string, members []ZMember, err error) {
+	return c.zmpop(0, "MAX", count, keys)
+}
+
+// BZMPOPMIN executes <https://redis.io/commands/bzmpop> with MIN. The return
+// has the key popped from, which is the empty string on timeout. See BZPOPMIN
+// for the blocking semantics. BZMPOP requires Redis 7.
+func (c *Client) BZMPOPMIN(timeout time.Duration, count int64, keys ...string) (key string, members []ZMember, err error) {
+	return c.zmpop(blockOf(timeout), "MIN", count, keys)
+}
+
+// BZMPOPMAX executes <https://redis.io/commands/bzmpop> with MAX. The return
+// has the key popped from, which is the empty string on timeout. See BZPOPMIN
+// for the blocking semantics. BZMPOP requires Redis 7.
+func (c *Client) BZMPOPMAX(timeout time.Duration, count int64, keys ...string) (key string, members []ZMember, err error) {
+	return c.zmpop(blockOf(timeout), "MAX", count, keys)
+}
+
+func (c *Client) zmpop(block time.Duration, where string, count int64, keys []string) (string, []ZMember, error) {
+	args := make([]string, 0, 5+len(keys))
+	var r *request
+	if block == 0 {
+		r = newRequestSize(5+len(keys), "\r\n$5\r\nZMPOP")
+	} else {
+		r = newRequestSize(6+len(keys), "\r\n$6\r\nBZMPOP")
+		args = append(args, blockSeconds(block))
+	}
+	args = append(args, strconv.Itoa(len(keys)))
+	args = append(args, keys...)
+	args = append(args, where, "COUNT", strconv.FormatInt(count, 10))
+	r.addStringList(args)
+	return c.commandZMPop(r, block)
+}
+
 // ZRANGE executes <https://redis.io/commands/zrange>.
 func (c *Client) ZRANGE(key string, start, stop int64) ([][]byte, error) {
 	r := newRequest("*4\r\n$6\r\nZRANGE\r\n$")
@@ -900,3 +3222,28 @@ func (c *Client) BytesZREMArgs(key []byte, members [][]byte) (int64, error) {
 	r.addBytesBytesList(key, members)
 	return c.commandInteger(r)
 }
+
+// PFADD executes <https://redis.io/commands/pfadd>.
+// The return is true if the cardinality estimate changed.
+func (c *Client) PFADD(key string, elements ...string) (bool, error) {
+	r := newRequestSize(2+len(elements), "\r\n$5\r\nPFADD\r\n$")
+	r.addStringStringList(key, elements)
+	changed, err := c.commandInteger(r)
+	return changed != 0, err
+}
+
+// PFCOUNT executes <https://redis.io/commands/pfcount>. The return is 0 if none
+// of the keys exist. Multiple keys cause a merge into a temporary structure on
+// the server, which is considerably slower than a single key.
+func (c *Client) PFCOUNT(keys ...string) (int64, error) {
+	r := newRequestSize(1+len(keys), "\r\n$7\r\nPFCOUNT")
+	r.addStringList(keys)
+	return c.commandInteger(r)
+}
+
+// PFMERGE executes <https://redis.io/commands/pfmerge>.
+func (c *Client) PFMERGE(dest string, srcs ...string) error {
+	r := newRequestSize(2+len(srcs), "\r\n$7\r\nPFMERGE\r\n$")
+	r.addStringStringList(dest, srcs)
+	return c.commandOK(r)
+}
@@ -1,8 +1,15 @@
 package redis
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -57,6 +64,512 @@ func TestDBSwitch(t *testing.T) {
 	}
 }
 
+func TestCLIENTName(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient(testClient.Addr, time.Second, 0)
+	defer c.Close()
+	if password != nil {
+		if err := c.AUTH(password); err != nil {
+			t.Fatal("AUTH error:", err)
+		}
+	}
+
+	if name, err := c.CLIENTGETNAME(); err != nil {
+		t.Error("CLIENT GETNAME error:", err)
+	} else if name != "" {
+		t.Errorf("CLIENT GETNAME got %q, want the empty string", name)
+	}
+	if err := c.CLIENTSETNAME("with space"); err != errClientName {
+		t.Errorf("CLIENT SETNAME with space got error %v, want %v", err, errClientName)
+	}
+	if err := c.CLIENTSETNAME("test-name"); err != nil {
+		t.Fatal("CLIENT SETNAME error:", err)
+	}
+	id, err := c.CLIENTID()
+	if err != nil {
+		t.Fatal("CLIENT ID error:", err)
+	}
+
+	// AUTH reconnects
+	if err := c.AUTH(password); err != nil {
+		t.Fatal("AUTH error:", err)
+	}
+	if newID, err := c.CLIENTID(); err != nil {
+		t.Error("CLIENT ID after reconnect error:", err)
+	} else if newID == id {
+		t.Errorf("CLIENT ID after reconnect got %d again", id)
+	}
+	if name, err := c.CLIENTGETNAME(); err != nil {
+		t.Error("CLIENT GETNAME after reconnect error:", err)
+	} else if name != "test-name" {
+		t.Errorf("CLIENT GETNAME after reconnect got %q, want %q", name, "test-name")
+	}
+
+	if err := c.CLIENTNOEVICT(true); err != nil {
+		t.Error("CLIENT NO-EVICT ON error:", err)
+	}
+	if err := c.CLIENTNOEVICT(false); err != nil {
+		t.Error("CLIENT NO-EVICT OFF error:", err)
+	}
+}
+
+func TestCOMMANDINFO(t *testing.T) {
+	t.Parallel()
+
+	if n, err := testClient.COMMANDCOUNT(); err != nil {
+		t.Error("COMMAND COUNT error:", err)
+	} else if n < 2 {
+		t.Errorf("COMMAND COUNT got %d", n)
+	}
+
+	specs, err := testClient.COMMANDINFO("get", "no-such-command", "mset")
+	if err != nil {
+		t.Fatal("COMMAND INFO error:", err)
+	}
+	if len(specs) != 3 {
+		t.Fatalf("COMMAND INFO got %d entries, want 3", len(specs))
+	}
+	if s := specs[0]; s.Name != "get" || s.Arity != 2 || s.FirstKey != 1 || s.LastKey != 1 || s.KeyStep != 1 {
+		t.Errorf("COMMAND INFO get got %+v", s)
+	}
+	if s := specs[1]; !reflect.DeepEqual(s, CommandSpec{}) {
+		t.Errorf("COMMAND INFO no-such-command got %+v, want a zero value", s)
+	}
+	if s := specs[2]; s.Name != "mset" || s.Arity != -3 || s.LastKey != -1 || s.KeyStep != 2 {
+		t.Errorf("COMMAND INFO mset got %+v", s)
+	}
+	var write bool
+	for _, flag := range specs[2].Flags {
+		write = write || flag == "write"
+	}
+	if !write {
+		t.Errorf("COMMAND INFO mset got flags %q, want write included", specs[2].Flags)
+	}
+}
+
+func TestWAIT(t *testing.T) {
+	t.Parallel()
+
+	// command timeout must not apply to the WAIT duration
+	c := NewClient(testClient.Addr, 100*time.Millisecond, 0)
+	defer c.Close()
+
+	start := time.Now()
+	if n, err := c.WAIT(1, 200*time.Millisecond); err != nil {
+		t.Fatal("WAIT 1 200 error:", err)
+	} else if n != 0 {
+		t.Errorf("WAIT 1 200 without replicas got %d, want 0", n)
+	}
+	if d := time.Since(start); d < 200*time.Millisecond {
+		t.Errorf("WAIT 1 200 returned after %s", d)
+	}
+}
+
+func TestFAILOVER(t *testing.T) {
+	t.Parallel()
+
+	if err := testClient.FAILOVER("no-port", false, 0); err == nil {
+		t.Error("FAILOVER to address without port got no error")
+	} else if _, ok := err.(ServerError); ok {
+		t.Errorf("FAILOVER to address without port got server error %q", err)
+	}
+	if err := testClient.FAILOVERAbort(); err == nil {
+		t.Error("FAILOVER ABORT without failover in progress got no error")
+	} else if _, ok := err.(ServerError); !ok {
+		t.Errorf("FAILOVER ABORT got error %q, want a ServerError", err)
+	}
+}
+
+func TestDEBUGSLEEP(t *testing.T) {
+	t.Parallel()
+
+	// command timeout shorter than the sleep
+	c := NewClient(testClient.Addr, 20*time.Millisecond, 0)
+	defer c.Close()
+	if password != nil {
+		if err := c.AUTH(password); err != nil {
+			t.Fatal("AUTH error:", err)
+		}
+	}
+
+	start := time.Now()
+	if err := c.DEBUGSLEEP(100 * time.Millisecond); err != nil {
+		t.Fatal("DEBUG SLEEP error:", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("DEBUG SLEEP returned after %s, want 100ms or more", elapsed)
+	}
+	// deadline restored
+	if err := c.PING(); err != nil {
+		t.Error("PING after DEBUG SLEEP error:", err)
+	}
+}
+
+func TestKeyManagement(t *testing.T) {
+	t.Parallel()
+	key1, key2, key3 := randomKey("test-key"), randomKey("test-key"), randomKey("test-key")
+
+	if err := testClient.SETString(key1, "one"); err != nil {
+		t.Fatalf("SET %q error: %s", key1, err)
+	}
+	if _, err := testClient.RPUSHString(key2, "two"); err != nil {
+		t.Fatalf("RPUSH %q error: %s", key2, err)
+	}
+
+	if n, err := testClient.EXISTS(key1, key2, key3, key1); err != nil {
+		t.Errorf("EXISTS error: %s", err)
+	} else if n != 3 {
+		t.Errorf("EXISTS %q %q %q %q got %d, want 3", key1, key2, key3, key1, n)
+	}
+
+	if typ, err := testClient.TYPE(key1); err != nil || typ != TypeString {
+		t.Errorf("TYPE %q got %q, %v, want %q", key1, typ, err, TypeString)
+	}
+	if typ, err := testClient.BytesTYPE([]byte(key2)); err != nil || typ != TypeList {
+		t.Errorf("TYPE %q got %q, %v, want %q", key2, typ, err, TypeList)
+	}
+	if typ, err := testClient.TYPE(key3); err != nil || typ != TypeNone {
+		t.Errorf("TYPE %q got %q, %v, want %q", key3, typ, err, TypeNone)
+	}
+
+	if ok, err := testClient.RENAMENX(key1, key2); err != nil || ok {
+		t.Errorf("RENAMENX %q %q onto existing got %t, %v, want false, nil", key1, key2, ok, err)
+	}
+	if ok, err := testClient.BytesRENAMENX([]byte(key1), []byte(key3)); err != nil || !ok {
+		t.Errorf("RENAMENX %q %q got %t, %v, want true, nil", key1, key3, ok, err)
+	}
+	if err := testClient.RENAME(key3, key1); err != nil {
+		t.Errorf("RENAME %q %q error: %s", key3, key1, err)
+	}
+	if err := testClient.RENAME(key3, key1); err == nil {
+		t.Errorf("RENAME absent %q got no error", key3)
+	} else if _, ok := err.(ServerError); !ok {
+		t.Errorf("RENAME absent %q got error %q, want a ServerError", key3, err)
+	}
+
+	if n, err := testClient.TOUCH(key1, key2, key3); err != nil {
+		t.Errorf("TOUCH error: %s", err)
+	} else if n != 2 {
+		t.Errorf("TOUCH %q %q %q got %d, want 2", key1, key2, key3, n)
+	}
+	if ok, err := testClient.PERSIST(key1); err != nil || ok {
+		t.Errorf("PERSIST %q without expiry got %t, %v, want false, nil", key1, ok, err)
+	}
+	if ok, err := testClient.BytesPERSIST([]byte(key3)); err != nil || ok {
+		t.Errorf("PERSIST absent %q got %t, %v, want false, nil", key3, ok, err)
+	}
+
+	if n, err := testClient.UNLINK(key1, key2, key3); err != nil {
+		t.Errorf("UNLINK error: %s", err)
+	} else if n != 2 {
+		t.Errorf("UNLINK %q %q %q got %d, want 2", key1, key2, key3, n)
+	}
+
+	noKeys := map[string]func() (int64, error){
+		"DEL":    func() (int64, error) { return testClient.DELArgs() },
+		"UNLINK": func() (int64, error) { return testClient.UNLINK() },
+		"EXISTS": func() (int64, error) { return testClient.EXISTS() },
+		"TOUCH":  func() (int64, error) { return testClient.TOUCH() },
+	}
+	for name, f := range noKeys {
+		if n, err := f(); err != nil || n != 0 {
+			t.Errorf("%s without keys got (%d, %v), want (0, <nil>)", name, n, err)
+		}
+	}
+}
+
+func TestDatabaseKeys(t *testing.T) {
+	t.Parallel()
+
+	// own connection with a dedicated database
+	c := NewClient(testClient.Addr, time.Second, 0)
+	defer c.Close()
+	if password != nil {
+		if err := c.AUTH(password); err != nil {
+			t.Fatal("AUTH error:", err)
+		}
+	}
+	if err := c.SELECT(15); err != nil {
+		t.Fatal("SELECT 15 error:", err)
+	}
+	if err := c.FLUSHDBSync(); err != nil {
+		t.Fatal("FLUSHDB SYNC error:", err)
+	}
+
+	if key, ok, err := c.RANDOMKEY(); err != nil || ok {
+		t.Errorf("RANDOMKEY on empty database got %q, %t, %v, want not ok", key, ok, err)
+	}
+	if key, err := c.BytesRANDOMKEY(); err != nil || key != nil {
+		t.Errorf("RANDOMKEY on empty database got %q, %v, want nil", key, err)
+	}
+	if n, err := c.DBSIZE(); err != nil || n != 0 {
+		t.Errorf("DBSIZE on empty database got %d, %v, want 0", n, err)
+	}
+
+	key := randomKey("test-key")
+	if err := c.SETString(key, "v"); err != nil {
+		t.Fatalf("SET %q error: %s", key, err)
+	}
+	if got, ok, err := c.RANDOMKEY(); err != nil || !ok || got != key {
+		t.Errorf("RANDOMKEY got %q, %t, %v, want %q", got, ok, err, key)
+	}
+	if n, err := c.DBSIZE(); err != nil || n != 1 {
+		t.Errorf("DBSIZE got %d, %v, want 1", n, err)
+	}
+
+	if err := c.FLUSHDB(false); err != nil {
+		t.Fatal("FLUSHDB error:", err)
+	}
+	if n, err := c.DBSIZE(); err != nil || n != 0 {
+		t.Errorf("DBSIZE after FLUSHDB got %d, %v, want 0", n, err)
+	}
+}
+
+func TestCONFIG(t *testing.T) {
+	t.Parallel()
+
+	m, err := testClient.CONFIGGET("maxmemory-policy")
+	if err != nil {
+		t.Fatal("CONFIG GET maxmemory-policy error:", err)
+	}
+	original, ok := m["maxmemory-policy"]
+	if !ok || len(m) != 1 {
+		t.Fatalf("CONFIG GET maxmemory-policy got %q, want the one parameter", m)
+	}
+	defer func() {
+		if err := testClient.CONFIGSET("maxmemory-policy", original); err != nil {
+			t.Errorf("CONFIG SET maxmemory-policy %q error: %s", original, err)
+		}
+	}()
+
+	if err := testClient.CONFIGSET("maxmemory-policy", "allkeys-lru"); err != nil {
+		t.Fatal("CONFIG SET maxmemory-policy allkeys-lru error:", err)
+	}
+	if m, err := testClient.CONFIGGET("maxmemory-*"); err != nil {
+		t.Error("CONFIG GET maxmemory-* error:", err)
+	} else if got := m["maxmemory-policy"]; got != "allkeys-lru" {
+		t.Errorf("CONFIG GET maxmemory-* got maxmemory-policy %q, want %q", got, "allkeys-lru")
+	}
+
+	if m, err := testClient.CONFIGGET("no-such-parameter-*"); err != nil {
+		t.Error("CONFIG GET without match error:", err)
+	} else if m == nil || len(m) != 0 {
+		t.Errorf("CONFIG GET without match got %q, want an empty map", m)
+	}
+
+	if err := testClient.CONFIGSET("no-such-parameter", "1"); err == nil {
+		t.Error("CONFIG SET of unknown parameter got no error")
+	} else if !errors.As(err, new(ServerError)) {
+		t.Errorf("CONFIG SET of unknown parameter got error %v, want a ServerError", err)
+	}
+	if _, err := testClient.CONFIGGETArgs(); err != errNoKeys {
+		t.Errorf("CONFIG GET without patterns got error %v, want %v", err, errNoKeys)
+	}
+	if err := testClient.CONFIGRESETSTAT(); err != nil {
+		t.Error("CONFIG RESETSTAT error:", err)
+	}
+}
+
+func TestCOPY(t *testing.T) {
+	t.Parallel()
+	src, dst, absent := randomKey("test-key"), randomKey("test-key"), randomKey("absent")
+
+	// own connection, as testClient may have another database selected
+	c := NewClient(testClient.Addr, time.Second, 0)
+	defer c.Close()
+
+	if err := c.SETString(src, "one"); err != nil {
+		t.Fatalf("SET %q error: %s", src, err)
+	}
+	if ok, err := c.COPY(src, dst, false); err != nil || !ok {
+		t.Errorf("COPY %q %q got %t, %v, want true, nil", src, dst, ok, err)
+	}
+	if err := c.SETString(src, "two"); err != nil {
+		t.Fatalf("SET %q error: %s", src, err)
+	}
+	if ok, err := c.BytesCOPY([]byte(src), []byte(dst), false); err != nil || ok {
+		t.Errorf("COPY %q %q on existing destination got %t, %v, want false, nil", src, dst, ok, err)
+	}
+	if value, _, err := c.GETString(dst); err != nil || value != "one" {
+		t.Errorf("GET %q after COPY without REPLACE got %q, %v, want one", dst, value, err)
+	}
+	if ok, err := c.COPY(src, dst, true); err != nil || !ok {
+		t.Errorf("COPY %q %q REPLACE got %t, %v, want true, nil", src, dst, ok, err)
+	}
+	if value, _, err := c.GETString(dst); err != nil || value != "two" {
+		t.Errorf("GET %q after COPY REPLACE got %q, %v, want two", dst, value, err)
+	}
+	if ok, err := c.COPY(absent, dst, true); err != nil || ok {
+		t.Errorf("COPY %q %q got %t, %v, want false, nil", absent, dst, ok, err)
+	}
+
+	if ok, err := c.COPYToDB(src, src, 11, false); err != nil || !ok {
+		t.Errorf("COPY %q %q DB 11 got %t, %v, want true, nil", src, src, ok, err)
+	}
+	if ok, err := c.BytesCOPYToDB([]byte(src), []byte(src), 11, true); err != nil || !ok {
+		t.Errorf("COPY %q %q DB 11 REPLACE got %t, %v, want true, nil", src, src, ok, err)
+	}
+	if err := c.SELECT(11); err != nil {
+		t.Fatal("SELECT 11 error:", err)
+	}
+	if value, _, err := c.GETString(src); err != nil || value != "two" {
+		t.Errorf("GET %q in DB 11 got %q, %v, want two", src, value, err)
+	}
+	if _, err := c.DEL(src); err != nil {
+		t.Errorf("DEL %q in DB 11 error: %s", src, err)
+	}
+}
+
+func TestDUMPRestore(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test-key")
+	value := []byte("binary\r\n\x00\xff")
+
+	// own connections, as testClient may have another database selected
+	src := NewClient(testClient.Addr, time.Second, 0)
+	defer src.Close()
+	dst := NewClient(testClient.Addr, time.Second, 0)
+	defer dst.Close()
+	if err := dst.SELECT(12); err != nil {
+		t.Fatal("SELECT 12 error:", err)
+	}
+
+	if payload, err := src.DUMP(key); err != nil {
+		t.Errorf("DUMP %q absent error: %s", key, err)
+	} else if payload != nil {
+		t.Errorf("DUMP %q absent got %q, want nil", key, payload)
+	}
+	if ok, err := src.MigrateKey(dst, key, 0); err != nil || ok {
+		t.Errorf("MigrateKey %q absent got %t, %v, want false, nil", key, ok, err)
+	}
+
+	if err := src.SET(key, value); err != nil {
+		t.Fatalf("SET %q error: %s", key, err)
+	}
+	if ok, err := src.MigrateKey(dst, key, time.Minute); err != nil {
+		t.Fatalf("MigrateKey %q error: %s", key, err)
+	} else if !ok {
+		t.Fatalf("MigrateKey %q got false", key)
+	}
+	if got, err := dst.GET(key); err != nil {
+		t.Errorf("GET %q after migrate error: %s", key, err)
+	} else if string(got) != string(value) {
+		t.Errorf("GET %q after migrate got %q, want %q", key, got, value)
+	}
+
+	payload, err := src.DUMP(key)
+	if err != nil {
+		t.Fatalf("DUMP %q error: %s", key, err)
+	}
+	if err := dst.RESTORE(key, 0, payload, false); err == nil {
+		t.Errorf("RESTORE %q on existing key got no error", key)
+	} else if e, ok := err.(ServerError); !ok || e.Prefix() != "BUSYKEY" {
+		t.Errorf("RESTORE %q on existing key got error %q, want a BUSYKEY ServerError", key, err)
+	}
+	if err := dst.RESTOREAt(key, time.Now().Add(time.Hour), payload, true); err != nil {
+		t.Errorf("RESTORE %q REPLACE ABSTTL error: %s", key, err)
+	}
+	if _, err := dst.DEL(key); err != nil {
+		t.Errorf("DEL %q error: %s", key, err)
+	}
+}
+
+func TestKeyIntrospection(t *testing.T) {
+	t.Parallel()
+	key, absent := randomKey("test-key"), randomKey("absent")
+
+	if err := testClient.SETString(key, "value"); err != nil {
+		t.Fatalf("SET %q error: %s", key, err)
+	}
+
+	if encoding, ok, err := testClient.OBJECTENCODING(key); err != nil {
+		t.Errorf("OBJECT ENCODING %q error: %s", key, err)
+	} else if !ok || encoding == "" {
+		t.Errorf("OBJECT ENCODING %q got %q, %t", key, encoding, ok)
+	}
+	if idle, ok, err := testClient.OBJECTIDLETIME(key); err != nil {
+		t.Errorf("OBJECT IDLETIME %q error: %s", key, err)
+	} else if !ok || idle < 0 {
+		t.Errorf("OBJECT IDLETIME %q got %s, %t", key, idle, ok)
+	}
+	if n, ok, err := testClient.OBJECTREFCOUNT(key); err != nil {
+		t.Errorf("OBJECT REFCOUNT %q error: %s", key, err)
+	} else if !ok || n < 1 {
+		t.Errorf("OBJECT REFCOUNT %q got %d, %t", key, n, ok)
+	}
+	if _, _, err := testClient.OBJECTFREQ(key); err != nil {
+		if _, ok := err.(ServerError); !ok {
+			t.Errorf("OBJECT FREQ %q got error %q, want a ServerError if any", key, err)
+		}
+	}
+	if info, ok, err := testClient.DEBUGOBJECT(key); err != nil {
+		if _, ok := err.(ServerError); !ok {
+			t.Errorf("DEBUG OBJECT %q got error %q, want a ServerError if any", key, err)
+		}
+	} else if !ok || info["encoding"] == "" {
+		t.Errorf("DEBUG OBJECT %q got %q, %t", key, info, ok)
+	}
+	if n, ok, err := testClient.MEMORYUSAGE(key, 0); err != nil {
+		t.Errorf("MEMORY USAGE %q error: %s", key, err)
+	} else if !ok || n < 5 {
+		t.Errorf("MEMORY USAGE %q got %d, %t", key, n, ok)
+	}
+
+	if _, ok, err := testClient.OBJECTENCODING(absent); err != nil || ok {
+		t.Errorf("OBJECT ENCODING %q got %t, %v, want false, nil", absent, ok, err)
+	}
+	if _, ok, err := testClient.OBJECTIDLETIME(absent); err != nil || ok {
+		t.Errorf("OBJECT IDLETIME %q got %t, %v, want false, nil", absent, ok, err)
+	}
+	if _, ok, err := testClient.DEBUGOBJECT(absent); err != nil {
+		if _, isServerErr := err.(ServerError); !isServerErr {
+			t.Errorf("DEBUG OBJECT %q got error %q, want a ServerError if any", absent, err)
+		}
+	} else if ok {
+		t.Errorf("DEBUG OBJECT %q got ok, want false", absent)
+	}
+	if _, ok, err := testClient.MEMORYUSAGE(absent, -1); err != nil || ok {
+		t.Errorf("MEMORY USAGE %q SAMPLES 0 got %t, %v, want false, nil", absent, ok, err)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	t.Parallel()
+	key, absent := randomKey("test"), randomKey("absent")
+
+	if _, ok, err := testClient.TTL(absent); err != nil || ok {
+		t.Errorf("TTL %q got %t, %v, want false, nil", absent, ok, err)
+	}
+	if _, ok, err := testClient.BytesPTTL([]byte(absent)); err != nil || ok {
+		t.Errorf("PTTL %q got %t, %v, want false, nil", absent, ok, err)
+	}
+
+	if err := testClient.SETString(key, "v"); err != nil {
+		t.Fatal("SET error:", err)
+	}
+	if ttl, ok, err := testClient.PTTL(key); err != nil || !ok || ttl != NoTTL {
+		t.Errorf("PTTL %q without expiry got %s, %t, %v, want NoTTL, true, nil", key, ttl, ok, err)
+	}
+
+	if _, err := testClient.SETStringWithOptions(key, "v", SETOptions{Flags: EX, Expire: time.Minute}); err != nil {
+		t.Fatal("SET with EX error:", err)
+	}
+	if ttl, ok, err := testClient.BytesTTL([]byte(key)); err != nil || !ok || ttl != time.Minute {
+		t.Errorf("TTL %q got %s, %t, %v, want 1m0s, true, nil", key, ttl, ok, err)
+	}
+	if ttl, ok, err := testClient.PTTL(key); err != nil || !ok || ttl <= 59*time.Second || ttl > time.Minute || ttl%time.Millisecond != 0 {
+		t.Errorf("PTTL %q got %s, %t, %v, want about a minute in milliseconds", key, ttl, ok, err)
+	}
+
+	if ok, err := testClient.PERSIST(key); err != nil || !ok {
+		t.Errorf("PERSIST %q got %t, %v, want true, nil", key, ok, err)
+	}
+	if ttl, ok, err := testClient.TTL(key); err != nil || !ok || ttl != NoTTL {
+		t.Errorf("TTL %q after PERSIST got %s, %t, %v, want NoTTL, true, nil", key, ttl, ok, err)
+	}
+}
+
 func TestKeyCRUD(t *testing.T) {
 	t.Parallel()
 	key := randomKey("test-key")
@@ -152,6 +665,40 @@ func TestBatchKeyCRUD(t *testing.T) {
 	}
 }
 
+func TestBatchKeyMap(t *testing.T) {
+	t.Parallel()
+	key1, key2, key3 := randomKey("test-key"), randomKey("test-key"), randomKey("test-key")
+
+	if err := testClient.MSETMap(map[string][]byte{key1: []byte("one"), key2: nil}); err != nil {
+		t.Fatalf("MSET %q %q error: %s", key1, key2, err)
+	}
+	if ok, err := testClient.MSETNXStringMap(map[string]string{key2: "two", key3: "three"}); err != nil {
+		t.Errorf("MSETNX %q %q error: %s", key2, key3, err)
+	} else if ok {
+		t.Errorf("MSETNX %q %q with %q present got true", key2, key3, key2)
+	}
+	if ok, err := testClient.MSETNXString([]string{key3}, []string{"three"}); err != nil {
+		t.Errorf("MSETNX %q error: %s", key3, err)
+	} else if !ok {
+		t.Errorf("MSETNX %q got false", key3)
+	}
+	if _, err := testClient.MSETNX([]string{key1, key2}, [][]byte{nil}); err != errMapSlices {
+		t.Errorf("MSETNX with 2 keys and 1 value got error %v, want %v", err, errMapSlices)
+	}
+
+	absentKey := randomKey("absent")
+	values, ok, err := testClient.MGETStringOK(key1, absentKey, key2, key3)
+	if err != nil {
+		t.Fatalf("MGET %q %q %q %q error: %s", key1, absentKey, key2, key3, err)
+	}
+	if want := []string{"one", "", "", "three"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("MGET %q %q %q %q got %q, want %q", key1, absentKey, key2, key3, values, want)
+	}
+	if want := []bool{true, false, true, true}; !reflect.DeepEqual(ok, want) {
+		t.Errorf("MGET %q %q %q %q got presence %t, want %t", key1, absentKey, key2, key3, ok, want)
+	}
+}
+
 func TestBatchBytesKeyCRD(t *testing.T) {
 	t.Parallel()
 	key1, key2 := []byte(randomKey("test-key")), []byte(randomKey("test-key"))
@@ -176,6 +723,71 @@ func TestBatchBytesKeyCRD(t *testing.T) {
 	}
 }
 
+func TestBatchChunks(t *testing.T) {
+	t.Parallel()
+
+	c := NewClientWithOptions(testClient.Addr, time.Second, 0, ClientOptions{ChunkSize: 3})
+	defer c.Close()
+	if password != nil {
+		if err := c.AUTH(password); err != nil {
+			t.Fatal("AUTH error:", err)
+		}
+	}
+
+	// every third key absent, such that nils land on each chunk boundary
+	keys := make([]string, 10)
+	var setKeys, setValues []string
+	want := make([]string, len(keys))
+	wantOK := make([]bool, len(keys))
+	for i := range keys {
+		keys[i] = randomKey("test-chunk")
+		if i%3 != 2 {
+			setKeys = append(setKeys, keys[i])
+			setValues = append(setValues, strconv.Itoa(i))
+			want[i] = strconv.Itoa(i)
+			wantOK[i] = true
+		}
+	}
+
+	if err := c.MSETString(setKeys, setValues); err != nil {
+		t.Fatalf("MSET %d keys error: %s", len(setKeys), err)
+	}
+	if err := c.MSETString(setKeys, setValues[1:]); err != errMapSlices {
+		t.Errorf("MSET with %d keys and %d values got error %v, want %v", len(setKeys), len(setValues)-1, err, errMapSlices)
+	}
+
+	values, ok, err := c.MGETStringOK(keys...)
+	if err != nil {
+		t.Fatalf("MGET %d keys error: %s", len(keys), err)
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("MGET %d keys got %q, want %q", len(keys), values, want)
+	}
+	if !reflect.DeepEqual(ok, wantOK) {
+		t.Errorf("MGET %d keys got presence %t, want %t", len(keys), ok, wantOK)
+	}
+	if bytes, err := c.MGET(keys...); err != nil {
+		t.Errorf("MGET %d keys error: %s", len(keys), err)
+	} else {
+		for i := range keys {
+			if wantOK[i] != (bytes[i] != nil) || string(bytes[i]) != want[i] {
+				t.Errorf("MGET %d keys got %q at index %d, want %q", len(keys), bytes[i], i, want[i])
+			}
+		}
+	}
+
+	if n, err := c.DELArgs(keys[:5]...); err != nil {
+		t.Errorf("DEL %d keys error: %s", 5, err)
+	} else if n != 4 {
+		t.Errorf("DEL %d keys got %d, want 4", 5, n)
+	}
+	if n, err := c.UNLINK(keys...); err != nil {
+		t.Errorf("UNLINK %d keys error: %s", len(keys), err)
+	} else if n != int64(len(setKeys)-4) {
+		t.Errorf("UNLINK %d keys got %d, want %d", len(keys), n, len(setKeys)-4)
+	}
+}
+
 func TestKeyAbsent(t *testing.T) {
 	t.Parallel()
 	const key = "doesn't exist"
@@ -271,28 +883,406 @@ func TestKeyOptions(t *testing.T) {
 	}
 }
 
+func TestSETGET(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test")
+
+	if old, ok, err := testClient.SETGET(key, []byte("first"), SETOptions{Flags: KEEPTTL}); err != nil {
+		t.Fatalf(`SET %q "first" KEEPTTL GET error: %s`, key, err)
+	} else if ok {
+		t.Errorf(`SET %q "first" KEEPTTL GET got %q, want not ok`, key, old)
+	}
+	if old, ok, err := testClient.SETGET(key, []byte("second"), SETOptions{Flags: XX | EXAT, ExpireAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf(`SET %q "second" XX EXAT GET error: %s`, key, err)
+	} else if !ok || string(old) != "first" {
+		t.Errorf(`SET %q "second" XX EXAT GET got %q, %t, want "first", true`, key, old, ok)
+	}
+	if old, ok, err := testClient.SETGETString(key, "third", SETOptions{Flags: NX}); err != nil {
+		t.Fatalf(`SET %q "third" NX GET error: %s`, key, err)
+	} else if !ok || old != "second" {
+		t.Errorf(`SET %q "third" NX GET got %q, %t, want "second", true`, key, old, ok)
+	}
+	if value, _, err := testClient.GETString(key); err != nil {
+		t.Fatalf("GET %q error: %s", key, err)
+	} else if value != "second" {
+		t.Errorf(`GET %q got %q, want "second"`, key, value)
+	}
+	if old, _, err := testClient.BytesSETGET([]byte(key), []byte("fourth"), SETOptions{Flags: XX}); err != nil {
+		t.Fatalf(`SET %q "fourth" XX GET error: %s`, key, err)
+	} else if string(old) != "second" {
+		t.Errorf(`SET %q "fourth" XX GET got %q, want "second"`, key, old)
+	}
+
+	// conditions on absence
+	absent := randomKey("absent")
+	if old, ok, err := testClient.SETGET(absent, []byte("v"), SETOptions{Flags: XX}); err != nil {
+		t.Fatalf(`SET %q "v" XX GET error: %s`, absent, err)
+	} else if ok {
+		t.Errorf(`SET %q "v" XX GET got %q, want not ok`, absent, old)
+	}
+	if n, err := testClient.EXISTS(absent); err != nil || n != 0 {
+		t.Errorf("EXISTS %q after XX got %d, %v; want 0", absent, n, err)
+	}
+	if old, ok, err := testClient.SETGETString(absent, "v", SETOptions{Flags: NX}); err != nil {
+		t.Fatalf(`SET %q "v" NX GET error: %s`, absent, err)
+	} else if ok {
+		t.Errorf(`SET %q "v" NX GET got %q, want not ok`, absent, old)
+	}
+	if value, _, err := testClient.GETString(absent); err != nil || value != "v" {
+		t.Errorf(`GET %q after NX got %q, %v; want "v"`, absent, value, err)
+	}
+}
+
+func TestSETNXEX(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test")
+
+	if ok, err := testClient.SETNXString(key, "first"); err != nil {
+		t.Fatalf(`SETNX %q "first" error: %s`, key, err)
+	} else if !ok {
+		t.Errorf(`SETNX %q "first" got false, want true`, key)
+	}
+	if ok, err := testClient.SETNX(key, []byte("second")); err != nil {
+		t.Fatalf(`SETNX %q "second" error: %s`, key, err)
+	} else if ok {
+		t.Errorf(`SETNX %q "second" got true, want false`, key)
+	}
+	if value, _, err := testClient.GETString(key); err != nil {
+		t.Fatalf("GET %q error: %s", key, err)
+	} else if value != "first" {
+		t.Errorf(`GET %q got %q, want "first"`, key, value)
+	}
+
+	if err := testClient.SETEXString(key, time.Hour, "third"); err != nil {
+		t.Fatalf(`SETEX %q 3600 "third" error: %s`, key, err)
+	}
+	if value, _, err := testClient.GETString(key); err != nil {
+		t.Fatalf("GET %q error: %s", key, err)
+	} else if value != "third" {
+		t.Errorf(`GET %q got %q, want "third"`, key, value)
+	}
+	if err := testClient.BytesPSETEX([]byte(key), 1500*time.Millisecond, []byte("fourth")); err != nil {
+		t.Fatalf(`PSETEX %q 1500 "fourth" error: %s`, key, err)
+	}
+	if value, _, err := testClient.GETString(key); err != nil {
+		t.Fatalf("GET %q error: %s", key, err)
+	} else if value != "fourth" {
+		t.Errorf(`GET %q got %q, want "fourth"`, key, value)
+	}
+
+	if err := testClient.SETEX(key, time.Millisecond, nil); err == nil {
+		t.Errorf("SETEX %q with sub-second expiry got no error", key)
+	} else if !errors.As(err, new(ServerError)) {
+		t.Errorf("SETEX %q with sub-second expiry got error %v, want a ServerError", key, err)
+	}
+}
+
+func TestSETOptionsConflict(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test")
+
+	for _, o := range []SETOptions{
+		{Flags: NX | XX},
+		{Flags: EX | KEEPTTL, Expire: time.Second},
+		{Flags: EX | EXAT, Expire: time.Second, ExpireAt: time.Now()},
+		{Flags: PX, Expire: time.Second, ExpireAt: time.Now()},
+		{Flags: KEEPTTL, Expire: time.Second},
+		{Flags: PXAT, Expire: time.Second, ExpireAt: time.Now()},
+		{ExpireAt: time.Now()},
+	} {
+		if _, err := testClient.SETWithOptions(key, nil, o); err == nil {
+			t.Errorf("SET with %+v got no error", o)
+		}
+		if _, _, err := testClient.SETGET(key, nil, o); err == nil {
+			t.Errorf("SET GET with %+v got no error", o)
+		}
+	}
+	if n, err := testClient.EXISTS(key); err != nil {
+		t.Fatal("EXISTS error:", err)
+	} else if n != 0 {
+		t.Errorf("EXISTS %q got %d after rejected SETs, want 0", key, n)
+	}
+}
+
+func TestKeyPayload(t *testing.T) {
+	key := randomKey("test")
+	const value = "streamed"
+
+	if err := testClient.SETFrom(key, strings.NewReader(value), int64(len(value))); err != nil {
+		t.Fatalf("SET %q from reader error: %s", key, err)
+	}
+	if newLen, err := testClient.APPENDFrom(key, strings.NewReader(value), int64(len(value))); err != nil {
+		t.Errorf("APPEND %q from reader error: %s", key, err)
+	} else if newLen != 2*int64(len(value)) {
+		t.Errorf("APPEND %q from reader got length %d, want %d", key, newLen, 2*len(value))
+	}
+	if bytes, err := testClient.GET(key); err != nil {
+		t.Errorf("GET %q error: %s", key, err)
+	} else if string(bytes) != value+value {
+		t.Errorf("GET %q got %q, want %q", key, bytes, value+value)
+	}
+
+	if err := testClient.SETFrom(key, strings.NewReader(value), SizeMax+1); err == nil {
+		t.Error("SET from reader beyond SizeMax got no error")
+	}
+	if err := testClient.SETFrom(key, strings.NewReader(value), int64(len(value))+1); err == nil {
+		t.Error("SET from short reader got no error")
+	}
+	if err := testClient.SETFrom(key, strings.NewReader(value), int64(len(value))-1); err == nil {
+		t.Error("SET from long reader got no error")
+	}
+
+	// await reconnect
+	time.Sleep(100 * time.Millisecond)
+	if bytes, err := testClient.GET(key); err != nil {
+		t.Errorf("GET %q after payload mismatch error: %s", key, err)
+	} else if string(bytes) != value+value {
+		t.Errorf("GET %q after payload mismatch got %q, want %q", key, bytes, value+value)
+	}
+
+	// no EOF after length
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go pw.Write([]byte(value))
+	if err := testClient.SETFrom(key, pr, int64(len(value))); err != nil {
+		t.Errorf("SET %q from open pipe error: %s", key, err)
+	}
+}
+
+func TestGETReader(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test")
+
+	// value larger than the read buffer
+	c := NewClientWithOptions(testClient.Addr, time.Second, 0, ClientOptions{ReadBufferSize: 1024})
+	defer c.Close()
+	value := strings.Repeat("0123456789", 1000)
+	if err := c.SETFrom(key, strings.NewReader(value), int64(len(value))); err != nil {
+		t.Fatal("SET from reader error:", err)
+	}
+
+	r, length, err := c.GETReader(key)
+	if err != nil {
+		t.Fatal("GET reader error:", err)
+	}
+	if length != int64(len(value)) {
+		t.Errorf("got length %d, want %d", length, len(value))
+	}
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Error("read error:", err)
+	} else if string(got) != value {
+		t.Errorf("got %d bytes, want %d", len(got), len(value))
+	}
+	if err := r.Close(); err != nil {
+		t.Error("close error:", err)
+	}
+
+	// partial read
+	r, _, err = c.GETReader(key)
+	if err != nil {
+		t.Fatal("GET reader error:", err)
+	}
+	buf := make([]byte, 10)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Error("partial read error:", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Error("close after partial read error:", err)
+	}
+	if _, err := r.Read(buf); err == nil {
+		t.Error("read after close got no error")
+	}
+	// connection in sync
+	if n, err := c.STRLEN(key); err != nil || n != int64(len(value)) {
+		t.Errorf("STRLEN got %d, %v; want %d", n, err, len(value))
+	}
+
+	if r, _, err := c.GETReader(randomKey("absent")); err != nil || r != nil {
+		t.Errorf("GET reader on absent key got %v, %v; want nil", r, err)
+	}
+}
+
+func TestKeyInto(t *testing.T) {
+	t.Parallel()
+	key, hash, list := randomKey("test"), randomKey("hash"), randomKey("list")
+	const value = "copied"
+
+	if err := testClient.SETString(key, value); err != nil {
+		t.Fatal("SET error:", err)
+	}
+	if _, err := testClient.HSETString(hash, "field", value); err != nil {
+		t.Fatal("HSET error:", err)
+	}
+	if _, err := testClient.RPUSHString(list, value); err != nil {
+		t.Fatal("RPUSH error:", err)
+	}
+
+	buf := make([]byte, 16)
+	if n, ok, err := testClient.GETInto(key, buf); err != nil {
+		t.Errorf("GET %q into buffer error: %s", key, err)
+	} else if !ok || string(buf[:n]) != value {
+		t.Errorf("GET %q into buffer got %q, %t, want %q, true", key, buf[:n], ok, value)
+	}
+	if n, ok, err := testClient.HGETInto(hash, "field", buf); err != nil {
+		t.Errorf("HGET %q into buffer error: %s", hash, err)
+	} else if !ok || string(buf[:n]) != value {
+		t.Errorf("HGET %q into buffer got %q, %t, want %q, true", hash, buf[:n], ok, value)
+	}
+	if n, ok, err := testClient.LINDEXInto(list, 0, buf); err != nil {
+		t.Errorf("LINDEX %q into buffer error: %s", list, err)
+	} else if !ok || string(buf[:n]) != value {
+		t.Errorf("LINDEX %q into buffer got %q, %t, want %q, true", list, buf[:n], ok, value)
+	}
+
+	if n, ok, err := testClient.GETInto(key, buf[:2]); err != io.ErrShortBuffer {
+		t.Errorf("GET %q into short buffer got error %v, want io.ErrShortBuffer", key, err)
+	} else if !ok || n != len(value) {
+		t.Errorf("GET %q into short buffer got %d, %t, want %d, true", key, n, ok, len(value))
+	}
+	// connection must remain in sync
+	if n, ok, err := testClient.GETInto(key, buf); err != nil || !ok || string(buf[:n]) != value {
+		t.Errorf("GET %q after short buffer got %q, %t, %v, want %q, true, nil", key, buf[:n], ok, err, value)
+	}
+
+	if n, ok, err := testClient.GETInto(randomKey("absent"), buf); err != nil || ok || n != 0 {
+		t.Errorf("GET absent into buffer got %d, %t, %v, want 0, false, nil", n, ok, err)
+	}
+	if n, ok, err := testClient.LINDEXInto(list, 9, buf); err != nil || ok || n != 0 {
+		t.Errorf("LINDEX out of range into buffer got %d, %t, %v, want 0, false, nil", n, ok, err)
+	}
+}
+
 func TestStrings(t *testing.T) {
 	t.Parallel()
 	key := randomKey("test")
 
-	if err := testClient.SETString(key, "abc"); err != nil {
-		t.Errorf("INCR %q error: %s", key, err)
+	if err := testClient.SETString(key, "abc"); err != nil {
+		t.Errorf("INCR %q error: %s", key, err)
+	}
+
+	if l, err := testClient.STRLEN(key); err != nil {
+		t.Errorf("STRLEN %q error: %s", key, err)
+	} else if l != 3 {
+		t.Errorf("STRLEN %q got %d, want 3", key, l)
+	}
+	if bytes, err := testClient.GETRANGE(key, 2, 3); err != nil {
+		t.Errorf("GETRANGE %q 2 3 error: %s", key, err)
+	} else if string(bytes) != "c" {
+		t.Errorf(`GETRANGE %q 2 3 got %q, want "c"`, key, bytes)
+	}
+	if bytes, err := testClient.GETRANGE(key, -3, -2); err != nil {
+		t.Errorf("GETRANGE %q -3 -2 error: %s", key, err)
+	} else if string(bytes) != "ab" {
+		t.Errorf(`GETRANGE %q -3 -2 got %q, want "ab"`, key, bytes)
+	}
+}
+
+func TestStringsGETDELGETEX(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test")
+
+	if err := testClient.SETString(key, "abc"); err != nil {
+		t.Fatalf("SET %q error: %s", key, err)
+	}
+	if newLen, err := testClient.SETRANGE(key, 1, []byte("xyz")); err != nil {
+		t.Errorf(`SETRANGE %q 1 "xyz" error: %s`, key, err)
+	} else if newLen != 4 {
+		t.Errorf(`SETRANGE %q 1 "xyz" got %d, want 4`, key, newLen)
+	}
+	if value, err := testClient.GETEX(key, GETEXOptions{Flags: EX, Expire: time.Hour}); err != nil {
+		t.Errorf("GETEX %q EX 3600 error: %s", key, err)
+	} else if string(value) != "axyz" {
+		t.Errorf(`GETEX %q EX 3600 got %q, want "axyz"`, key, value)
+	}
+	if ttl, _, err := testClient.TTL(key); err != nil || ttl != time.Hour {
+		t.Errorf("TTL %q after GETEX EX 3600 got %s, %v; want 1h0m0s", key, ttl, err)
+	}
+	if value, ok, err := testClient.GETEXString(key, GETEXOptions{Flags: PERSIST}); err != nil {
+		t.Errorf("GETEX %q PERSIST error: %s", key, err)
+	} else if !ok || value != "axyz" {
+		t.Errorf(`GETEX %q PERSIST got %q, %t, want "axyz", true`, key, value, ok)
+	}
+	if ttl, _, err := testClient.TTL(key); err != nil || ttl != NoTTL {
+		t.Errorf("TTL %q after GETEX PERSIST got %s, %v; want NoTTL", key, ttl, err)
+	}
+	if value, err := testClient.GETDEL(key); err != nil {
+		t.Errorf("GETDEL %q error: %s", key, err)
+	} else if string(value) != "axyz" {
+		t.Errorf(`GETDEL %q got %q, want "axyz"`, key, value)
+	}
+	if value, ok, err := testClient.GETDELString(key); err != nil {
+		t.Errorf("GETDEL %q again error: %s", key, err)
+	} else if ok {
+		t.Errorf("GETDEL %q again got %q, want not ok", key, value)
+	}
+	if value, err := testClient.BytesGETEX([]byte(key), GETEXOptions{}); err != nil {
+		t.Errorf("GETEX %q error: %s", key, err)
+	} else if value != nil {
+		t.Errorf("GETEX %q got %q, want nil", key, value)
+	}
+
+	if _, err := testClient.GETEX(key, GETEXOptions{Flags: EX | PERSIST}); err == nil {
+		t.Error("GETEX with EX and PERSIST got no error")
+	}
+	if _, err := testClient.GETEX(key, GETEXOptions{Flags: KEEPTTL}); err == nil {
+		t.Error("GETEX with KEEPTTL got no error")
+	}
+}
+
+func TestStringsRange(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test")
+
+	if newLen, err := testClient.SETRANGEString(key, 2, "cd"); err != nil {
+		t.Fatalf(`SETRANGE %q 2 "cd" error: %s`, key, err)
+	} else if newLen != 4 {
+		t.Errorf(`SETRANGE %q 2 "cd" got %d, want 4`, key, newLen)
+	}
+	if newLen, err := testClient.BytesSETRANGE([]byte(key), 0, []byte("ab")); err != nil {
+		t.Fatalf(`SETRANGE %q 0 "ab" error: %s`, key, err)
+	} else if newLen != 4 {
+		t.Errorf(`SETRANGE %q 0 "ab" got %d, want 4`, key, newLen)
+	}
+
+	golden := []struct {
+		start, end int64
+		want       string
+	}{
+		{0, -1, "abcd"},
+		{-2, -1, "cd"},
+		{-100, 1, "ab"},
+		{3, 100, "d"},
+		{4, 10, ""},
+		{-1, -2, ""},
+	}
+	for _, gold := range golden {
+		if bytes, err := testClient.GETRANGE(key, gold.start, gold.end); err != nil {
+			t.Errorf("GETRANGE %q %d %d error: %s", key, gold.start, gold.end, err)
+		} else if bytes == nil || string(bytes) != gold.want {
+			t.Errorf("GETRANGE %q %d %d got %q, want %q", key, gold.start, gold.end, bytes, gold.want)
+		}
+	}
+
+	buf := make([]byte, 2)
+	if n, err := testClient.GETRANGEInto(key, 1, 2, buf); err != nil {
+		t.Errorf("GETRANGE %q 1 2 into buffer error: %s", key, err)
+	} else if string(buf[:n]) != "bc" {
+		t.Errorf(`GETRANGE %q 1 2 into buffer got %q, want "bc"`, key, buf[:n])
+	}
+	if n, err := testClient.GETRANGEInto(key, 0, -1, buf); err != io.ErrShortBuffer || n != 4 {
+		t.Errorf("GETRANGE %q 0 -1 into short buffer got %d, %v; want 4, io.ErrShortBuffer", key, n, err)
 	}
 
+	for _, offset := range []int64{-1, SizeMax} {
+		if _, err := testClient.SETRANGE(key, offset, []byte("x")); err == nil {
+			t.Errorf("SETRANGE %q %d got no error", key, offset)
+		}
+	}
 	if l, err := testClient.STRLEN(key); err != nil {
 		t.Errorf("STRLEN %q error: %s", key, err)
-	} else if l != 3 {
-		t.Errorf("STRLEN %q got %d, want 3", key, l)
-	}
-	if bytes, err := testClient.GETRANGE(key, 2, 3); err != nil {
-		t.Errorf("GETRANGE %q 2 3 error: %s", key, err)
-	} else if string(bytes) != "c" {
-		t.Errorf(`GETRANGE %q 2 3 got %q, want "c"`, key, bytes)
-	}
-	if bytes, err := testClient.GETRANGE(key, -3, -2); err != nil {
-		t.Errorf("GETRANGE %q -3 -2 error: %s", key, err)
-	} else if string(bytes) != "ab" {
-		t.Errorf(`GETRANGE %q -3 -2 got %q, want "ab"`, key, bytes)
+	} else if l != 4 {
+		t.Errorf("STRLEN %q got %d, want 4", key, l)
 	}
 }
 
@@ -402,6 +1392,162 @@ func TestListRight(t *testing.T) {
 	}
 }
 
+func TestListPopCount(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test-list")
+
+	for _, v := range []string{"a", "b", "c", "d"} {
+		if _, err := testClient.RPUSHString(key, v); err != nil {
+			t.Fatalf("RPUSH %q %q error: %s", key, v, err)
+		}
+	}
+	if values, err := testClient.LPOPCountString(key, 2); err != nil {
+		t.Errorf("LPOP %q 2 error: %s", key, err)
+	} else if !reflect.DeepEqual(values, []string{"a", "b"}) {
+		t.Errorf(`LPOP %q 2 got %q, want ["a" "b"]`, key, values)
+	}
+	if values, err := testClient.BytesRPOPCount([]byte(key), 5); err != nil {
+		t.Errorf("RPOP %q 5 error: %s", key, err)
+	} else if len(values) != 2 || string(values[0]) != "d" || string(values[1]) != "c" {
+		t.Errorf(`RPOP %q 5 got %q, want ["d" "c"]`, key, values)
+	}
+	if values, err := testClient.LPOPCount(key, 1); err != nil {
+		t.Errorf("LPOP %q 1 on absent key error: %s", key, err)
+	} else if values != nil {
+		t.Errorf("LPOP %q 1 on absent key got %q, want nil", key, values)
+	}
+}
+
+func TestListMPop(t *testing.T) {
+	t.Parallel()
+	key1, key2 := randomKey("test-list"), randomKey("test-list")
+
+	for _, v := range []string{"a", "b", "c"} {
+		if _, err := testClient.RPUSHString(key2, v); err != nil {
+			t.Fatalf("RPUSH %q %q error: %s", key2, v, err)
+		}
+	}
+
+	if k, values, err := testClient.LMPOP(RIGHT, 2, key1, key2); err != nil {
+		t.Errorf("LMPOP %q %q RIGHT COUNT 2 error: %s", key1, key2, err)
+	} else if want := [][]byte{[]byte("c"), []byte("b")}; k != key2 || !reflect.DeepEqual(values, want) {
+		t.Errorf("LMPOP %q %q RIGHT COUNT 2 got %q %q, want %q %q", key1, key2, k, values, key2, want)
+	}
+	if k, values, err := testClient.BLMPOP(time.Second, LEFT, 5, key1, key2); err != nil {
+		t.Errorf("BLMPOP %q %q LEFT COUNT 5 error: %s", key1, key2, err)
+	} else if want := [][]byte{[]byte("a")}; k != key2 || !reflect.DeepEqual(values, want) {
+		t.Errorf("BLMPOP %q %q LEFT COUNT 5 got %q %q, want %q %q", key1, key2, k, values, key2, want)
+	}
+	if k, values, err := testClient.LMPOP(LEFT, 1, key1, key2); err != nil {
+		t.Errorf("LMPOP %q %q on empty error: %s", key1, key2, err)
+	} else if k != "" || values != nil {
+		t.Errorf("LMPOP %q %q on empty got %q %q, want none", key1, key2, k, values)
+	}
+}
+
+func TestListMoveInsert(t *testing.T) {
+	t.Parallel()
+	src, dst := randomKey("test-list"), randomKey("test-list")
+
+	for _, v := range []string{"a", "b", "c"} {
+		if _, err := testClient.RPUSHString(src, v); err != nil {
+			t.Fatalf("RPUSH %q %q error: %s", src, v, err)
+		}
+	}
+	if value, err := testClient.LMOVE(src, dst, RIGHT, LEFT); err != nil {
+		t.Errorf("LMOVE %q %q RIGHT LEFT error: %s", src, dst, err)
+	} else if string(value) != "c" {
+		t.Errorf(`LMOVE %q %q RIGHT LEFT got %q, want "c"`, src, dst, value)
+	}
+	if value, ok, err := testClient.LMOVEString(src, dst, LEFT, RIGHT); err != nil {
+		t.Errorf("LMOVE %q %q LEFT RIGHT error: %s", src, dst, err)
+	} else if !ok || value != "a" {
+		t.Errorf(`LMOVE %q %q LEFT RIGHT got %q, %t, want "a", true`, src, dst, value, ok)
+	}
+
+	if newLen, ok, err := testClient.LINSERTString(dst, true, "a", "x"); err != nil {
+		t.Errorf(`LINSERT %q BEFORE "a" "x" error: %s`, dst, err)
+	} else if !ok || newLen != 3 {
+		t.Errorf(`LINSERT %q BEFORE "a" "x" got %d, %t, want 3, true`, dst, newLen, ok)
+	}
+	if newLen, ok, err := testClient.LINSERT(dst, false, []byte("a"), []byte("y")); err != nil {
+		t.Errorf(`LINSERT %q AFTER "a" "y" error: %s`, dst, err)
+	} else if !ok || newLen != 4 {
+		t.Errorf(`LINSERT %q AFTER "a" "y" got %d, %t, want 4, true`, dst, newLen, ok)
+	}
+	if newLen, ok, err := testClient.BytesLINSERT([]byte(dst), true, []byte("none"), []byte("z")); err != nil {
+		t.Errorf(`LINSERT %q BEFORE "none" "z" error: %s`, dst, err)
+	} else if ok {
+		t.Errorf(`LINSERT %q BEFORE "none" "z" got %d, want not ok`, dst, newLen)
+	}
+	if values, err := testClient.LRANGEString(dst, 0, -1); err != nil {
+		t.Errorf("LRANGE %q 0 -1 error: %s", dst, err)
+	} else if want := []string{"c", "x", "a", "y"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("LRANGE %q 0 -1 got %q, want %q", dst, values, want)
+	}
+
+	if err := testClient.LSETString(dst, 1, "X"); err != nil {
+		t.Errorf(`LSET %q 1 "X" error: %s`, dst, err)
+	}
+	if err := testClient.LSETString(dst, 9, "X"); err == nil {
+		t.Errorf(`LSET %q 9 "X" got no error`, dst)
+	} else if e, ok := err.(ServerError); !ok || e.Prefix() != "ERR" {
+		t.Errorf(`LSET %q 9 "X" got error %q, want a server error`, dst, err)
+	}
+}
+
+func TestListPosition(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test-list")
+
+	for _, v := range []string{"a", "b", "a", "c", "a"} {
+		if _, err := testClient.RPUSHString(key, v); err != nil {
+			t.Fatalf("RPUSH %q %q error: %s", key, v, err)
+		}
+	}
+	golden := []struct {
+		rank  int64
+		index int64
+		ok    bool
+	}{
+		{0, 0, true},
+		{2, 2, true},
+		{-1, 4, true},
+		{4, 0, false},
+	}
+	for _, gold := range golden {
+		if index, ok, err := testClient.LPOSString(key, "a", gold.rank); err != nil {
+			t.Errorf(`LPOS %q "a" RANK %d error: %s`, key, gold.rank, err)
+		} else if index != gold.index || ok != gold.ok {
+			t.Errorf(`LPOS %q "a" RANK %d got %d, %t, want %d, %t`, key, gold.rank, index, ok, gold.index, gold.ok)
+		}
+	}
+	if index, ok, err := testClient.BytesLPOS([]byte(key), []byte("c"), 0); err != nil {
+		t.Errorf(`LPOS %q "c" error: %s`, key, err)
+	} else if !ok || index != 3 {
+		t.Errorf(`LPOS %q "c" got %d, %t, want 3, true`, key, index, ok)
+	}
+
+	if indices, err := testClient.LPOSCount(key, []byte("a"), 0, 0); err != nil {
+		t.Errorf(`LPOS %q "a" COUNT 0 error: %s`, key, err)
+	} else if want := []int64{0, 2, 4}; !reflect.DeepEqual(indices, want) {
+		t.Errorf(`LPOS %q "a" COUNT 0 got %d, want %d`, key, indices, want)
+	}
+	if indices, err := testClient.LPOSCountString(key, "a", -1, 2); err != nil {
+		t.Errorf(`LPOS %q "a" RANK -1 COUNT 2 error: %s`, key, err)
+	} else if want := []int64{4, 2}; !reflect.DeepEqual(indices, want) {
+		t.Errorf(`LPOS %q "a" RANK -1 COUNT 2 got %d, want %d`, key, indices, want)
+	}
+	if indices, err := testClient.BytesLPOSCount([]byte(key), []byte("none"), 0, 1); err != nil {
+		t.Errorf(`LPOS %q "none" COUNT 1 error: %s`, key, err)
+	} else if indices == nil || len(indices) != 0 {
+		t.Errorf(`LPOS %q "none" COUNT 1 got %d, want empty`, key, indices)
+	}
+	if _, err := testClient.LPOSCount(key, []byte("a"), 0, -1); err == nil {
+		t.Errorf(`LPOS %q "a" COUNT -1 got no error`, key)
+	}
+}
+
 func TestListAbsent(t *testing.T) {
 	const key = "doesn't exist"
 
@@ -519,6 +1665,228 @@ func TestListRemove(t *testing.T) {
 	}
 }
 
+func TestFuncVisitors(t *testing.T) {
+	t.Parallel()
+	listKey := randomKey("array")
+	setKey := randomKey("set")
+	hashKey := randomKey("hash")
+
+	// values larger than the read buffer
+	large := strings.Repeat("x", 5000)
+	c := NewClientWithOptions(testClient.Addr, time.Second, 0, ClientOptions{ReadBufferSize: 1024})
+	defer c.Close()
+	for _, value := range []string{"a", large, "c"} {
+		if _, err := c.RPUSHString(listKey, value); err != nil {
+			t.Fatal("population error:", err)
+		}
+	}
+
+	var got []string
+	err := c.LRANGEFunc(listKey, 0, -1, func(value []byte) error {
+		got = append(got, string(value))
+		return nil
+	})
+	if err != nil {
+		t.Fatal("LRANGEFunc error:", err)
+	}
+	if want := []string{"a", large, "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LRANGEFunc got %d values, want %d", len(got), len(want))
+	}
+
+	// callback error
+	stop := errors.New("test stop")
+	got = got[:0]
+	err = c.LRANGEFunc(listKey, 0, -1, func(value []byte) error {
+		got = append(got, string(value))
+		return stop
+	})
+	if err != stop {
+		t.Errorf("LRANGEFunc got error %v, want the error of fn", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("LRANGEFunc got %d invocations after error, want 1", len(got))
+	}
+	// connection in sync
+	if n, err := c.LLEN(listKey); err != nil || n != 3 {
+		t.Errorf("LLEN got %d, %v; want 3", n, err)
+	}
+
+	if _, err := c.SADDString(setKey, "m1", "m2"); err != nil {
+		t.Fatal("population error:", err)
+	}
+	members := make(map[string]bool)
+	err = c.SMEMBERSFunc(setKey, func(member []byte) error {
+		members[string(member)] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal("SMEMBERSFunc error:", err)
+	}
+	if want := map[string]bool{"m1": true, "m2": true}; !reflect.DeepEqual(members, want) {
+		t.Errorf("SMEMBERSFunc got %v, want %v", members, want)
+	}
+
+	if _, err := c.HSETString(hashKey, "f1", "v1"); err != nil {
+		t.Fatal("population error:", err)
+	}
+	if _, err := c.HSETString(hashKey, "f2", large); err != nil {
+		t.Fatal("population error:", err)
+	}
+	fields := make(map[string]string)
+	err = c.HGETALLFunc(hashKey, func(field string, value []byte) error {
+		fields[field] = string(value)
+		return nil
+	})
+	if err != nil {
+		t.Fatal("HGETALLFunc error:", err)
+	}
+	if want := map[string]string{"f1": "v1", "f2": large}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("HGETALLFunc got %d fields, want %d", len(fields), len(want))
+	}
+
+	// absent keys
+	err = c.HGETALLFunc(randomKey("absent"), func(string, []byte) error {
+		t.Error("HGETALLFunc invocation on absent key")
+		return nil
+	})
+	if err != nil {
+		t.Error("HGETALLFunc on absent key error:", err)
+	}
+}
+
+func TestFloatIncrements(t *testing.T) {
+	t.Parallel()
+	key, hashKey, zKey := randomKey("test-float"), randomKey("test-hash"), randomKey("test-zset")
+	defer testClient.DELArgs(key, hashKey, zKey)
+
+	if got, err := testClient.INCRBYFLOAT(key, 1e300); err != nil {
+		t.Error("INCRBYFLOAT 1e300 error:", err)
+	} else if got != 1e300 {
+		t.Errorf("INCRBYFLOAT 1e300 got %g, want 1e300", got)
+	}
+	if got, err := testClient.BytesINCRBYFLOAT([]byte(key), -1e300); err != nil {
+		t.Error("INCRBYFLOAT -1e300 error:", err)
+	} else if got != 0 {
+		t.Errorf("INCRBYFLOAT -1e300 got %g, want 0", got)
+	}
+	if got, err := testClient.INCRBYFLOAT(key, 0.1); err != nil {
+		t.Error("INCRBYFLOAT 0.1 error:", err)
+	} else if got != 0.1 {
+		t.Errorf("INCRBYFLOAT 0.1 got %g, want 0.1", got)
+	}
+
+	if got, err := testClient.HINCRBYFLOAT(hashKey, "f", 10.5); err != nil {
+		t.Error("HINCRBYFLOAT 10.5 error:", err)
+	} else if got != 10.5 {
+		t.Errorf("HINCRBYFLOAT 10.5 got %g, want 10.5", got)
+	}
+	if got, err := testClient.BytesHINCRBYFLOAT([]byte(hashKey), []byte("f"), -0.25); err != nil {
+		t.Error("HINCRBYFLOAT -0.25 error:", err)
+	} else if got != 10.25 {
+		t.Errorf("HINCRBYFLOAT -0.25 got %g, want 10.25", got)
+	}
+
+	// rejects
+	if _, err := testClient.INCRBYFLOAT(key, math.Inf(1)); err == nil {
+		t.Error("INCRBYFLOAT +inf got no error")
+	} else if _, ok := err.(ServerError); !ok {
+		t.Errorf("INCRBYFLOAT +inf got error %v, want a ServerError", err)
+	}
+	if _, err := testClient.HSETString(hashKey, "s", "abc"); err != nil {
+		t.Fatal("HSET error:", err)
+	}
+	if _, err := testClient.HINCRBYFLOAT(hashKey, "s", 1); err == nil {
+		t.Error("HINCRBYFLOAT on non-float got no error")
+	} else if _, ok := err.(ServerError); !ok {
+		t.Errorf("HINCRBYFLOAT on non-float got error %v, want a ServerError", err)
+	}
+	if got, err := testClient.INCRBYFLOAT(key, 0); err != nil || got != 0.1 {
+		t.Errorf("INCRBYFLOAT 0 after rejects got %g, %v; want 0.1", got, err)
+	}
+
+	if got, err := testClient.ZINCRBYString(zKey, 2.5, "m"); err != nil {
+		t.Error("ZINCRBY 2.5 error:", err)
+	} else if got != 2.5 {
+		t.Errorf("ZINCRBY 2.5 got %g, want 2.5", got)
+	}
+	if got, err := testClient.ZINCRBY(zKey, -1e-3, []byte("m")); err != nil {
+		t.Error("ZINCRBY -1e-3 error:", err)
+	} else if got != 2.499 {
+		t.Errorf("ZINCRBY -1e-3 got %g, want 2.499", got)
+	}
+	if got, err := testClient.BytesZINCRBY([]byte(zKey), math.Inf(1), []byte("m")); err != nil {
+		t.Error("ZINCRBY +inf error:", err)
+	} else if !math.IsInf(got, 1) {
+		t.Errorf("ZINCRBY +inf got %g, want +inf", got)
+	}
+}
+
+func TestSORT(t *testing.T) {
+	t.Parallel()
+	key, dst := randomKey("test-list"), randomKey("test-list")
+
+	for _, v := range []string{"3", "10", "1", "2"} {
+		if _, err := testClient.RPUSHString(key, v); err != nil {
+			t.Fatalf("RPUSH %q %q error: %s", key, v, err)
+		}
+	}
+	// weights reverse the numeric order, and element 2 has no name
+	if err := testClient.MSETString(
+		[]string{key + "-weight-1", key + "-weight-2", key + "-weight-3", key + "-weight-10",
+			key + "-name-1", key + "-name-3", key + "-name-10"},
+		[]string{"40", "30", "20", "10", "one", "three", "ten"},
+	); err != nil {
+		t.Fatal("MSET error:", err)
+	}
+
+	golden := []struct {
+		o    SORTOptions
+		want []string
+	}{
+		{SORTOptions{}, []string{"1", "2", "3", "10"}},
+		{SORTOptions{Desc: true}, []string{"10", "3", "2", "1"}},
+		{SORTOptions{Alpha: true}, []string{"1", "10", "2", "3"}},
+		{SORTOptions{Offset: 1, Count: 2}, []string{"2", "3"}},
+		{SORTOptions{Offset: 3}, []string{"10"}},
+		{SORTOptions{By: key + "-weight-*"}, []string{"10", "3", "2", "1"}},
+		{SORTOptions{By: "nosort"}, []string{"3", "10", "1", "2"}},
+	}
+	for _, gold := range golden {
+		got, err := testClient.SORTString(key, gold.o)
+		if err != nil {
+			t.Errorf("SORT %q %+v error: %s", key, gold.o, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, gold.want) {
+			t.Errorf("SORT %q %+v got %q, want %q", key, gold.o, got, gold.want)
+		}
+	}
+
+	o := SORTOptions{Get: []string{"#", key + "-name-*"}, Count: 2}
+	if got, err := testClient.SORT(key, o); err != nil {
+		t.Errorf("SORT %q %+v error: %s", key, o, err)
+	} else if want := [][]byte{[]byte("1"), []byte("one"), []byte("2"), nil}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SORT %q %+v got %q, want %q", key, o, got, want)
+	}
+
+	if n, err := testClient.SORTSTORE(key, dst, SORTOptions{Desc: true}); err != nil {
+		t.Errorf("SORT %q DESC STORE %q error: %s", key, dst, err)
+	} else if n != 4 {
+		t.Errorf("SORT %q DESC STORE %q got %d, want 4", key, dst, n)
+	}
+	if got, err := testClient.LRANGEString(dst, 0, -1); err != nil {
+		t.Errorf("LRANGE %q error: %s", dst, err)
+	} else if want := []string{"10", "3", "2", "1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LRANGE %q got %q, want %q", dst, got, want)
+	}
+
+	if got, err := testClient.SORT(randomKey("absent"), SORTOptions{}); err != nil {
+		t.Error("SORT of absent key error:", err)
+	} else if len(got) != 0 {
+		t.Errorf("SORT of absent key got %q, want empty", got)
+	}
+}
+
 func TestHashCRUD(t *testing.T) {
 	t.Parallel()
 	key := randomKey("test-hash")
@@ -681,6 +2049,57 @@ func TestBytesBatchHashCRUD(t *testing.T) {
 	}
 }
 
+func TestHashMap(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test-hash")
+
+	if n, err := testClient.HSETMulti(key, map[string][]byte{"a": []byte("1"), "b": nil}); err != nil {
+		t.Fatalf("HSET %q a b error: %s", key, err)
+	} else if n != 2 {
+		t.Errorf("HSET %q a b got %d, want 2", key, n)
+	}
+	if n, err := testClient.HSETMultiString(key, map[string]string{"b": "2", "c": "3"}); err != nil {
+		t.Fatalf("HSET %q b c error: %s", key, err)
+	} else if n != 1 {
+		t.Errorf("HSET %q b c got %d, want 1", key, n)
+	}
+
+	if m, err := testClient.HGETALLMap(key); err != nil {
+		t.Errorf("HGETALL %q error: %s", key, err)
+	} else if want := map[string][]byte{"a": []byte("1"), "b": []byte("2"), "c": []byte("3")}; !reflect.DeepEqual(m, want) {
+		t.Errorf("HGETALL %q got %q, want %q", key, m, want)
+	}
+	if m, err := testClient.HGETALLStringMap(key); err != nil {
+		t.Errorf("HGETALL %q error: %s", key, err)
+	} else if want := map[string]string{"a": "1", "b": "2", "c": "3"}; !reflect.DeepEqual(m, want) {
+		t.Errorf("HGETALL %q got %q, want %q", key, m, want)
+	}
+
+	if fields, err := testClient.HRANDFIELD(key, 5); err != nil {
+		t.Errorf("HRANDFIELD %q 5 error: %s", key, err)
+	} else if len(fields) != 3 {
+		t.Errorf("HRANDFIELD %q 5 got %q, want all 3 fields", key, fields)
+	}
+	if fields, values, err := testClient.HRANDFIELDWithValues(key, -4); err != nil {
+		t.Errorf("HRANDFIELD %q -4 WITHVALUES error: %s", key, err)
+	} else if len(fields) != 4 || len(values) != 4 {
+		t.Errorf("HRANDFIELD %q -4 WITHVALUES got %q %q, want 4 pairs", key, fields, values)
+	} else {
+		for i, f := range fields {
+			if want := f[0] - 'a' + '1'; len(values[i]) != 1 || values[i][0] != want {
+				t.Errorf("HRANDFIELD %q -4 WITHVALUES got %q for field %q, want %q", key, values[i], f, want)
+			}
+		}
+	}
+
+	absent := randomKey("absent")
+	if m, err := testClient.HGETALLMap(absent); err != nil {
+		t.Errorf("HGETALL %q error: %s", absent, err)
+	} else if m == nil || len(m) != 0 {
+		t.Errorf("HGETALL %q got %q, want an empty map", absent, m)
+	}
+}
+
 func TestHashAbsent(t *testing.T) {
 	t.Parallel()
 	key, field := "doesn't exist", "also not set"
@@ -720,6 +2139,240 @@ func TestHashAbsent(t *testing.T) {
 	}
 }
 
+func TestSetCRUD(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test-set")
+
+	if added, err := testClient.SADDString(key, "a", "b", "c"); err != nil {
+		t.Fatalf("SADD %q error: %s", key, err)
+	} else if added != 3 {
+		t.Errorf("SADD %q got %d, want 3", key, added)
+	}
+	if added, err := testClient.SADD(key, []byte("c"), []byte("d")); err != nil {
+		t.Fatalf("SADD %q error: %s", key, err)
+	} else if added != 1 {
+		t.Errorf("SADD %q with 1 existing got %d, want 1", key, added)
+	}
+	if removed, err := testClient.BytesSREM([]byte(key), []byte("d"), []byte("e")); err != nil {
+		t.Errorf("SREM %q error: %s", key, err)
+	} else if removed != 1 {
+		t.Errorf("SREM %q got %d, want 1", key, removed)
+	}
+	if n, err := testClient.SCARD(key); err != nil {
+		t.Errorf("SCARD %q error: %s", key, err)
+	} else if n != 3 {
+		t.Errorf("SCARD %q got %d, want 3", key, n)
+	}
+	if members, err := testClient.SMEMBERSString(key); err != nil {
+		t.Errorf("SMEMBERS %q error: %s", key, err)
+	} else if sort.Strings(members); !reflect.DeepEqual(members, []string{"a", "b", "c"}) {
+		t.Errorf(`SMEMBERS %q got %q, want ["a" "b" "c"]`, key, members)
+	}
+
+	if is, err := testClient.SISMEMBERString(key, "b"); err != nil {
+		t.Errorf(`SISMEMBER %q "b" error: %s`, key, err)
+	} else if !is {
+		t.Errorf(`SISMEMBER %q "b" got false`, key)
+	}
+	if flags, err := testClient.SMISMEMBERString(key, "x", "a", "c", "a"); err != nil {
+		t.Errorf("SMISMEMBER %q error: %s", key, err)
+	} else if want := []bool{false, true, true, true}; !reflect.DeepEqual(flags, want) {
+		t.Errorf("SMISMEMBER %q got %t, want %t", key, flags, want)
+	}
+
+	dest := randomKey("test-set")
+	if moved, err := testClient.SMOVEString(key, dest, "a"); err != nil {
+		t.Errorf(`SMOVE %q %q "a" error: %s`, key, dest, err)
+	} else if !moved {
+		t.Errorf(`SMOVE %q %q "a" got false`, key, dest)
+	}
+	if moved, err := testClient.BytesSMOVE([]byte(key), []byte(dest), []byte("a")); err != nil {
+		t.Errorf(`SMOVE %q %q "a" again error: %s`, key, dest, err)
+	} else if moved {
+		t.Errorf(`SMOVE %q %q "a" again got true`, key, dest)
+	}
+	if flags, err := testClient.BytesSMISMEMBER([]byte(dest), []byte("a")); err != nil {
+		t.Errorf("SMISMEMBER %q error: %s", dest, err)
+	} else if !reflect.DeepEqual(flags, []bool{true}) {
+		t.Errorf(`SMISMEMBER %q "a" got %t, want [true]`, dest, flags)
+	}
+}
+
+func TestSetAlgebra(t *testing.T) {
+	t.Parallel()
+	k1, k2, dest := randomKey("test-set"), randomKey("test-set"), randomKey("test-set")
+
+	if _, err := testClient.SADDString(k1, "a", "b", "c"); err != nil {
+		t.Fatalf("SADD %q error: %s", k1, err)
+	}
+	if _, err := testClient.SADDString(k2, "b", "c", "d"); err != nil {
+		t.Fatalf("SADD %q error: %s", k2, err)
+	}
+
+	if n, err := testClient.SINTERCARD(0, k1, k2); err != nil {
+		t.Errorf("SINTERCARD 2 %q %q error: %s", k1, k2, err)
+	} else if n != 2 {
+		t.Errorf("SINTERCARD 2 %q %q got %d, want 2", k1, k2, n)
+	}
+	if n, err := testClient.BytesSINTERCARD(1, []byte(k1), []byte(k2)); err != nil {
+		t.Errorf("SINTERCARD 2 %q %q LIMIT 1 error: %s", k1, k2, err)
+	} else if n != 1 {
+		t.Errorf("SINTERCARD 2 %q %q LIMIT 1 got %d, want 1", k1, k2, n)
+	}
+	if _, err := testClient.SINTERCARD(0); err != errNoKeys {
+		t.Errorf("SINTERCARD without keys got error %v, want %v", err, errNoKeys)
+	}
+
+	golden := []struct {
+		name string
+		f    func(string, ...string) (int64, error)
+		want []string
+	}{
+		{"SDIFFSTORE", testClient.SDIFFSTORE, []string{"a"}},
+		{"SINTERSTORE", testClient.SINTERSTORE, []string{"b", "c"}},
+		{"SUNIONSTORE", testClient.SUNIONSTORE, []string{"a", "b", "c", "d"}},
+	}
+	for _, gold := range golden {
+		if n, err := gold.f(dest, k1, k2); err != nil {
+			t.Errorf("%s %q %q %q error: %s", gold.name, dest, k1, k2, err)
+		} else if n != int64(len(gold.want)) {
+			t.Errorf("%s %q %q %q got %d, want %d", gold.name, dest, k1, k2, n, len(gold.want))
+		}
+		if members, err := testClient.SMEMBERSString(dest); err != nil {
+			t.Errorf("SMEMBERS %q error: %s", dest, err)
+		} else if sort.Strings(members); !reflect.DeepEqual(members, gold.want) {
+			t.Errorf("SMEMBERS %q after %s got %q, want %q", dest, gold.name, members, gold.want)
+		}
+	}
+	if n, err := testClient.BytesSINTERSTORE([]byte(dest), []byte(k1), []byte(randomKey("test-set"))); err != nil {
+		t.Errorf("SINTERSTORE with absent key error: %s", err)
+	} else if n != 0 {
+		t.Errorf("SINTERSTORE with absent key got %d, want 0", n)
+	}
+}
+
+func TestSetPopRandom(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test-set")
+
+	if _, err := testClient.SADDString(key, "a", "b", "c"); err != nil {
+		t.Fatalf("SADD %q error: %s", key, err)
+	}
+	if members, err := testClient.SRANDMEMBERCountString(key, 5); err != nil {
+		t.Errorf("SRANDMEMBER %q 5 error: %s", key, err)
+	} else if len(members) != 3 {
+		t.Errorf("SRANDMEMBER %q 5 got %q, want all 3 members", key, members)
+	}
+	if members, err := testClient.SRANDMEMBERCount(key, -5); err != nil {
+		t.Errorf("SRANDMEMBER %q -5 error: %s", key, err)
+	} else if len(members) != 5 {
+		t.Errorf("SRANDMEMBER %q -5 got %q, want 5 members with duplicates", key, members)
+	}
+	if member, ok, err := testClient.SRANDMEMBERString(key); err != nil {
+		t.Errorf("SRANDMEMBER %q error: %s", key, err)
+	} else if !ok || member == "" {
+		t.Errorf("SRANDMEMBER %q got %q, %t", key, member, ok)
+	}
+
+	if members, err := testClient.SPOPCountString(key, 2); err != nil {
+		t.Errorf("SPOP %q 2 error: %s", key, err)
+	} else if len(members) != 2 {
+		t.Errorf("SPOP %q 2 got %q, want 2 members", key, members)
+	}
+	if member, err := testClient.SPOP(key); err != nil {
+		t.Errorf("SPOP %q error: %s", key, err)
+	} else if member == nil {
+		t.Errorf("SPOP %q got nil, want the last member", key)
+	}
+	if member, ok, err := testClient.SPOPString(key); err != nil {
+		t.Errorf("SPOP %q on absent key error: %s", key, err)
+	} else if ok {
+		t.Errorf("SPOP %q on absent key got %q", key, member)
+	}
+	if members, err := testClient.BytesSRANDMEMBERCount([]byte(key), 2); err != nil {
+		t.Errorf("SRANDMEMBER %q 2 on absent key error: %s", key, err)
+	} else if len(members) != 0 {
+		t.Errorf("SRANDMEMBER %q 2 on absent key got %q, want empty", key, members)
+	}
+}
+
+func TestSortedSetPop(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test-zset")
+
+	if _, err := testClient.ZADDStringArgs(key, []int64{3, 1, 2, 4}, []string{"c", "a", "b", "d"}); err != nil {
+		t.Fatalf("ZADD %q error: %s", key, err)
+	}
+
+	if members, err := testClient.ZPOPMIN(key, 2); err != nil {
+		t.Errorf("ZPOPMIN %q 2 error: %s", key, err)
+	} else if want := []ZMember{{[]byte("a"), 1}, {[]byte("b"), 2}}; !reflect.DeepEqual(members, want) {
+		t.Errorf("ZPOPMIN %q 2 got %+v, want %+v", key, members, want)
+	}
+	if members, err := testClient.ZPOPMAX(key, 1); err != nil {
+		t.Errorf("ZPOPMAX %q 1 error: %s", key, err)
+	} else if want := []ZMember{{[]byte("d"), 4}}; !reflect.DeepEqual(members, want) {
+		t.Errorf("ZPOPMAX %q 1 got %+v, want %+v", key, members, want)
+	}
+
+	absent := randomKey("absent")
+	if k, m, ok, err := testClient.BZPOPMIN(time.Second, absent, key); err != nil {
+		t.Errorf("BZPOPMIN %q %q error: %s", absent, key, err)
+	} else if !ok || k != key || string(m.Member) != "c" || m.Score != 3 {
+		t.Errorf("BZPOPMIN %q %q got %q, %+v, %t, want %q, c, true", absent, key, k, m, ok, key)
+	}
+
+	if members, err := testClient.ZPOPMIN(key, 1); err != nil {
+		t.Errorf("ZPOPMIN %q on empty error: %s", key, err)
+	} else if members == nil || len(members) != 0 {
+		t.Errorf("ZPOPMIN %q on empty got %+v, want an empty slice", key, members)
+	}
+}
+
+func TestBZPOPMINTimeout(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test-zset")
+
+	// command timeout must not apply to the blocking duration
+	c := NewClient(testClient.Addr, 100*time.Millisecond, 0)
+	defer c.Close()
+
+	start := time.Now()
+	if k, m, ok, err := c.BZPOPMAX(200*time.Millisecond, key); err != nil {
+		t.Fatalf("BZPOPMAX %q error: %s", key, err)
+	} else if ok {
+		t.Errorf("BZPOPMAX %q got %q, %+v, true, want timeout", key, k, m)
+	}
+	if d := time.Since(start); d < 200*time.Millisecond {
+		t.Errorf("BZPOPMAX 200 ms returned after %s", d)
+	}
+}
+
+func TestSortedSetMPop(t *testing.T) {
+	t.Parallel()
+	key1, key2 := randomKey("test-zset"), randomKey("test-zset")
+
+	if _, err := testClient.ZADDStringArgs(key2, []int64{1, 2, 3}, []string{"a", "b", "c"}); err != nil {
+		t.Fatalf("ZADD %q error: %s", key2, err)
+	}
+
+	if k, members, err := testClient.ZMPOPMAX(2, key1, key2); err != nil {
+		t.Errorf("ZMPOP %q %q MAX COUNT 2 error: %s", key1, key2, err)
+	} else if want := []ZMember{{[]byte("c"), 3}, {[]byte("b"), 2}}; k != key2 || !reflect.DeepEqual(members, want) {
+		t.Errorf("ZMPOP %q %q MAX COUNT 2 got %q %+v, want %q %+v", key1, key2, k, members, key2, want)
+	}
+	if k, members, err := testClient.BZMPOPMIN(time.Second, 5, key1, key2); err != nil {
+		t.Errorf("BZMPOP %q %q MIN COUNT 5 error: %s", key1, key2, err)
+	} else if want := []ZMember{{[]byte("a"), 1}}; k != key2 || !reflect.DeepEqual(members, want) {
+		t.Errorf("BZMPOP %q %q MIN COUNT 5 got %q %+v, want %q %+v", key1, key2, k, members, key2, want)
+	}
+	if k, members, err := testClient.ZMPOPMIN(1, key1, key2); err != nil {
+		t.Errorf("ZMPOP %q %q on empty error: %s", key1, key2, err)
+	} else if k != "" || members != nil {
+		t.Errorf("ZMPOP %q %q on empty got %q %+v, want none", key1, key2, k, members)
+	}
+}
+
 func TestSortedSetStringCRUD(t *testing.T) {
 	t.Parallel()
 	key := randomKey("test-zset")
@@ -756,3 +2409,71 @@ func TestSortedSetStringCRUD(t *testing.T) {
 		t.Errorf(`ZRANGE %q got %q, want %q`, key, array[0], update)
 	}
 }
+
+func TestHyperLogLog(t *testing.T) {
+	t.Parallel()
+	key1, key2, dest := randomKey("test-hll"), randomKey("test-hll"), randomKey("test-hll")
+
+	if changed, err := testClient.PFADD(key1, "a", "b", "c"); err != nil {
+		t.Fatalf("PFADD %q error: %s", key1, err)
+	} else if !changed {
+		t.Errorf("PFADD %q got false", key1)
+	}
+	if changed, err := testClient.PFADD(key1, "a"); err != nil {
+		t.Errorf("PFADD %q again error: %s", key1, err)
+	} else if changed {
+		t.Errorf("PFADD %q again got true", key1)
+	}
+	if _, err := testClient.PFADD(key2, "c", "d"); err != nil {
+		t.Fatalf("PFADD %q error: %s", key2, err)
+	}
+
+	if n, err := testClient.PFCOUNT(key1); err != nil {
+		t.Errorf("PFCOUNT %q error: %s", key1, err)
+	} else if n != 3 {
+		t.Errorf("PFCOUNT %q got %d, want 3", key1, n)
+	}
+	if n, err := testClient.PFCOUNT(key1, key2); err != nil {
+		t.Errorf("PFCOUNT %q %q error: %s", key1, key2, err)
+	} else if n != 4 {
+		t.Errorf("PFCOUNT %q %q got %d, want 4", key1, key2, n)
+	}
+
+	if err := testClient.PFMERGE(dest, key1, key2); err != nil {
+		t.Errorf("PFMERGE %q %q %q error: %s", dest, key1, key2, err)
+	} else if n, err := testClient.PFCOUNT(dest); err != nil {
+		t.Errorf("PFCOUNT %q error: %s", dest, err)
+	} else if n != 4 {
+		t.Errorf("PFCOUNT %q got %d, want 4", dest, n)
+	}
+
+	notHLL := randomKey("test-key")
+	if err := testClient.SETString(notHLL, "x"); err != nil {
+		t.Fatalf("SET %q error: %s", notHLL, err)
+	}
+	if _, err := testClient.PFCOUNT(notHLL); err == nil {
+		t.Errorf("PFCOUNT %q on string got no error", notHLL)
+	} else if e, ok := err.(ServerError); !ok || e.Prefix() != "WRONGTYPE" {
+		t.Errorf("PFCOUNT %q on string got error %q, want a WRONGTYPE ServerError", notHLL, err)
+	}
+}
+
+func TestBatchChunksQueueBound(t *testing.T) {
+	t.Parallel()
+
+	c := NewClientWithOptions(testClient.Addr, time.Second, 0, ClientOptions{ChunkSize: 1, QueueSize: 1, NoQueueBlock: true})
+	defer c.Close()
+	if password != nil {
+		if err := c.AUTH(password); err != nil {
+			t.Fatal("AUTH error:", err)
+		}
+	}
+
+	keys := make([]string, 100)
+	for i := range keys {
+		keys[i] = randomKey("test-chunk")
+	}
+	if _, err := c.MGET(keys...); err != nil {
+		t.Errorf("MGET %d keys with queue size 1 got error: %s", len(keys), err)
+	}
+}
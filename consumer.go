@@ -0,0 +1,200 @@
+package redis
+
+import (
+	"errors"
+	"time"
+)
+
+// ConsumerGroupConfig defines a ConsumerGroup setup.
+type ConsumerGroupConfig struct {
+	// Key is the stream, which is created on absence.
+	Key string
+
+	// Group is the consumer group name, which is created on absence.
+	Group string
+
+	// Consumer is the name within the group. Each ConsumerGroup instance
+	// should have a unique name.
+	Consumer string
+
+	// StartID is the position of a new group. The empty string defaults
+	// to "0", i.e., all entries in the stream. Use "$" for entries added
+	// after the group creation only. StartID has no effect on existing
+	// groups.
+	StartID string
+
+	// Handler processes entries in order of delivery. A nil return
+	// acknowledges the entry with XACK. Entries with an error remain
+	// pending, which makes them available for XAUTOCLAIM after ClaimIdle.
+	// Claimed entries have nil Fields when they were deleted from the
+	// stream in the mean time [Redis 6.2 only].
+	Handler func(StreamEntry) error
+
+	// ErrorFunc is an optional callback for command failures. The
+	// ConsumerGroup retries with an exponential backoff, up to
+	// DialDelayMax.
+	ErrorFunc func(error)
+
+	// Count is the maximum number of entries per read. Zero defaults
+	// to 10.
+	Count int64
+
+	// Block is the maximum duration of each XREADGROUP. Close awaits the
+	// read in progress, if any. Zero defaults to one second.
+	Block time.Duration
+
+	// ClaimIdle is the minimum duration for pending entries before they
+	// are taken over with XAUTOCLAIM, which includes those of consumers
+	// which are no longer in service. Zero disables claims.
+	ClaimIdle time.Duration
+
+	// ClaimInterval is the pause between complete XAUTOCLAIM scans. Zero
+	// defaults to ClaimIdle.
+	ClaimInterval time.Duration
+}
+
+// ConsumerGroup processes a stream with <https://redis.io/topics/streams-intro>
+// consumer groups until Close. The blocking XREADGROUP of the read loop executes
+// on a dedicated connection, so c remains available for other commands.
+type ConsumerGroup struct {
+	ConsumerGroupConfig // read-only attributes
+
+	client *Client
+
+	// shutdown request
+	halt chan struct{}
+	// shutdown completion
+	closed chan struct{}
+}
+
+// NewConsumerGroup launches the read loop on c.
+func NewConsumerGroup(c *Client, config ConsumerGroupConfig) *ConsumerGroup {
+	g := &ConsumerGroup{
+		ConsumerGroupConfig: config,
+		client:              c,
+		halt:                make(chan struct{}),
+		closed:              make(chan struct{}),
+	}
+	// apply configuration defaults
+	if g.StartID == "" {
+		g.StartID = "0"
+	}
+	if g.Count <= 0 {
+		g.Count = 10
+	}
+	if g.Block <= 0 {
+		g.Block = time.Second
+	}
+	if g.ClaimInterval <= 0 {
+		g.ClaimInterval = g.ClaimIdle
+	}
+
+	go g.readLoop()
+
+	return g
+}
+
+// Close terminates the read loop. The Handler is not called after return.
+// Calling Close more than once just blocks until the first call completed.
+// The Client remains open.
+func (g *ConsumerGroup) Close() error {
+	select {
+	case <-g.halt:
+		break // closed already
+	default:
+		close(g.halt)
+	}
+	<-g.closed
+	return nil
+}
+
+func (g *ConsumerGroup) readLoop() {
+	defer close(g.closed)
+
+	var retryDelay time.Duration
+	var groupCreated bool
+	claimStart := "0-0"
+	var claimNext time.Time
+	for {
+		select {
+		case <-g.halt:
+			return
+		default:
+			break
+		}
+
+		var entries []StreamEntry
+		var err error
+		switch {
+		case !groupCreated:
+			err = g.client.XGROUPCREATE(g.Key, g.Group, g.StartID, true)
+			if err == nil || errors.Is(err, ErrBusyGroup) {
+				err = nil
+				groupCreated = true
+			}
+
+		case g.ClaimIdle > 0 && !time.Now().Before(claimNext):
+			claimStart, entries, _, err = g.client.XAUTOCLAIM(g.Key, g.Group, g.Consumer, g.ClaimIdle, claimStart, g.Count)
+			if err != nil {
+				// XREADGROUP goes first on the next attempts
+				claimStart = "0-0"
+				claimNext = time.Now().Add(g.ClaimInterval)
+			} else if claimStart == "0-0" {
+				// scan complete
+				claimNext = time.Now().Add(g.ClaimInterval)
+			}
+
+		default:
+			var streams []Stream
+			streams, err = g.client.XREADGROUPBlock(g.Group, g.Consumer, g.Count, g.Block, []string{g.Key}, []string{">"})
+			for _, s := range streams {
+				entries = append(entries, s.Entries...)
+			}
+		}
+		if err == nil {
+			err = g.process(entries)
+		}
+
+		if err == nil {
+			retryDelay = 0
+			continue
+		}
+		if errors.Is(err, ErrNoGroup) {
+			// key or group deleted
+			groupCreated = false
+		}
+		if err == ErrClosed {
+			return
+		}
+		if g.ErrorFunc != nil {
+			g.ErrorFunc(err)
+		}
+		retry := time.NewTimer(retryDelay)
+		retryDelay = 2*retryDelay + time.Millisecond
+		if retryDelay > DialDelayMax {
+			retryDelay = DialDelayMax
+		}
+		select {
+		case <-g.halt:
+			retry.Stop()
+			return
+		case <-retry.C:
+			break
+		}
+	}
+}
+
+// Process passes entries to the Handler, and it acknowledges the successes.
+func (g *ConsumerGroup) process(entries []StreamEntry) error {
+	var acks []string
+	for _, e := range entries {
+		if g.Handler(e) == nil {
+			acks = append(acks, e.ID)
+		}
+	}
+	if len(acks) == 0 {
+		return nil
+	}
+	_, err := g.client.XACK(g.Key, g.Group, acks...)
+	return err
+}
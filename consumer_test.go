@@ -0,0 +1,148 @@
+package redis
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConsumerGroup(t *testing.T) {
+	t.Parallel()
+	key := randomKey("stream")
+
+	// testClient may have another database selected
+	c := NewClient(testClient.Addr, time.Second, 0)
+	defer c.Close()
+	if _, err := c.XADD(key, "1-1", "n", "1"); err != nil {
+		t.Fatal("XADD error:", err)
+	}
+
+	var mutex sync.Mutex
+	var got []string
+	entries := make(chan StreamEntry, 10)
+	failing := NewConsumerGroup(NewClient(testClient.Addr, time.Second, 0), ConsumerGroupConfig{
+		Key:      key,
+		Group:    "g",
+		Consumer: "failing",
+		Handler: func(e StreamEntry) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			got = append(got, "failing "+e.ID)
+			return errors.New("test error")
+		},
+		ErrorFunc: func(err error) { t.Error("failing consumer error:", err) },
+		Block:     50 * time.Millisecond,
+	})
+	// await first delivery
+	for i := 0; ; i++ {
+		mutex.Lock()
+		n := len(got)
+		mutex.Unlock()
+		if n != 0 {
+			break
+		}
+		if i > 100 {
+			t.Fatal("no delivery to failing consumer")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	failing.Close()
+	failing.client.Close()
+
+	g := NewConsumerGroup(NewClient(testClient.Addr, time.Second, 0), ConsumerGroupConfig{
+		Key:      key,
+		Group:    "g",
+		Consumer: "working",
+		Handler: func(e StreamEntry) error {
+			entries <- e
+			return nil
+		},
+		ErrorFunc: func(err error) { t.Error("working consumer error:", err) },
+		Block:     50 * time.Millisecond,
+		ClaimIdle: 10 * time.Millisecond,
+	})
+	defer g.client.Close()
+	defer g.Close()
+
+	if _, err := c.XADD(key, "2-1", "n", "2"); err != nil {
+		t.Fatal("XADD error:", err)
+	}
+
+	want := map[string]StreamEntry{
+		"1-1": {ID: "1-1", Fields: []string{"n", "1"}},
+		"2-1": {ID: "2-1", Fields: []string{"n", "2"}},
+	}
+	for len(want) != 0 {
+		select {
+		case e := <-entries:
+			if !reflect.DeepEqual(e, want[e.ID]) {
+				t.Errorf("got entry %+v, want %+v", e, want[e.ID])
+			}
+			delete(want, e.ID)
+		case <-time.After(time.Second):
+			t.Fatalf("entries %+v not delivered", want)
+		}
+	}
+
+	// await acknowledgement
+	for i := 0; ; i++ {
+		streams, err := c.XREADGROUP("g", "working", 0, []string{key}, []string{"0"})
+		if err != nil {
+			t.Fatal("XREADGROUP history error:", err)
+		}
+		if len(streams) == 1 && len(streams[0].Entries) == 0 {
+			break
+		}
+		if i > 100 {
+			t.Fatalf("pending entries %+v not acknowledged", streams)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(got) != 1 || got[0] != "failing 1-1" {
+		t.Errorf("failing consumer got %q, want one delivery of entry 1-1", got)
+	}
+}
+
+func TestConsumerGroupClaimError(t *testing.T) {
+	t.Parallel()
+	// XAUTOCLAIM gets an unknown command error
+	server, counts := newDropServerOn(t, 0, "")
+	defer server.Close()
+	c := NewClient(server.Addr().String(), time.Second, 0)
+	defer c.Close()
+
+	errs := make(chan error, 99)
+	g := NewConsumerGroup(c, ConsumerGroupConfig{
+		Key:           "k",
+		Group:         "g",
+		Consumer:      "c",
+		Handler:       func(StreamEntry) error { return nil },
+		ErrorFunc:     func(err error) { errs <- err },
+		ClaimIdle:     time.Millisecond,
+		ClaimInterval: time.Hour,
+	})
+	defer g.Close()
+
+	for i := 0; counts("XREADGROUP") == 0; i++ {
+		if i > 100 {
+			t.Fatalf("no XREADGROUP after %d XAUTOCLAIM", counts("XAUTOCLAIM"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := counts("XAUTOCLAIM"); n != 1 {
+		t.Errorf("got %d XAUTOCLAIM, want 1 within ClaimInterval", n)
+	}
+	select {
+	case err := <-errs:
+		if _, ok := err.(ServerError); !ok {
+			t.Errorf("got error %v, want a ServerError from XAUTOCLAIM", err)
+		}
+	default:
+		t.Error("no XAUTOCLAIM error reported")
+	}
+}
@@ -31,6 +31,44 @@ func ExampleClient_SETStringWithOptions() {
 	}
 }
 
+func ExampleNewClientWithOptions() {
+	// connection setup
+	var Redis = redis.NewClientWithOptions("rds1.example.com", time.Second/2, 0, redis.ClientOptions{
+		// detect dead peers, e.g., behind NAT
+		KeepAlive: 30 * time.Second,
+		// no delay on small commands
+		NoDelay: true,
+	})
+	defer Redis.Close()
+
+	// execute command
+	if err := Redis.SETString("k", "v"); err != nil {
+		log.Print("command error: ", err)
+	}
+}
+
+func ExampleClient_Do() {
+	// connection setup
+	var Redis = redis.NewClient("rds1.example.com", time.Second/2, 0)
+	defer Redis.Close()
+
+	// execute module command without dedicated method
+	reply, err := Redis.Do("JSON.GET", "doc", "$.name")
+	if err != nil {
+		log.Print("command error: ", err)
+		return
+	}
+
+	switch reply.Type() {
+	case redis.NilReply:
+		log.Print("no such document")
+	case redis.BlobReply:
+		log.Print("name in JSON: ", reply.Str())
+	default:
+		log.Print("unexpected reply: ", reply)
+	}
+}
+
 func ExampleListener() {
 	// connection setup
 	var RedisListener = redis.NewListener(redis.ListenerConfig{
@@ -55,3 +93,34 @@ func ExampleListener() {
 	RedisListener.SUBSCRIBE("demo_channel")
 	time.Sleep(time.Millisecond)
 }
+
+func ExampleListener_channel() {
+	// Go channel delivery with copies of the payload
+	messages := make(chan redis.Message, 100)
+	var RedisListener = redis.NewListener(redis.ListenerConfig{
+		Func: func(channel string, message []byte, err error) {
+			if err != nil {
+				log.Print("subscription error: ", err)
+			}
+		},
+		MessageFunc: func(m redis.Message) {
+			m.Payload = append([]byte(nil), m.Payload...)
+			select {
+			case messages <- m:
+				break
+			default:
+				log.Printf("message on %q dropped; receiver too slow", m.Channel)
+			}
+		},
+		Addr: "rds1.example.com:6379",
+	})
+	defer RedisListener.Close()
+
+	RedisListener.PSUBSCRIBE("demo_*")
+	select {
+	case m := <-messages:
+		log.Printf("received %q on %q via %q", m.Payload, m.Channel, m.Pattern)
+	case <-time.After(time.Second):
+		log.Print("no message within a second")
+	}
+}
@@ -0,0 +1,149 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Geo Limits
+const (
+	// GeoLonMax is the upper boundary for longitudes, in degrees.
+	GeoLonMax = 180
+	// GeoLatMax is the upper boundary for latitudes, in degrees. The poles
+	// are not indexable in the Web Mercator projection.
+	GeoLatMax = 85.05112878
+)
+
+// GeoMember is a named position.
+type GeoMember struct {
+	Lon, Lat float64 // degrees
+	Name     string
+}
+
+// GeoResult is a GEOSEARCH match. Fields other than Name are only present when
+// requested with the respective option.
+type GeoResult struct {
+	Name string
+
+	Dist     float64 // WithDist, in the unit of the query
+	Hash     int64   // WithHash, as a 52-bit integer
+	Lon, Lat float64 // WithCoord, in degrees
+}
+
+// GeoSearch has the GEOSEARCH options. The center is either FromMember, or the
+// FromLon and FromLat position when FromMember is empty. The shape is either a
+// circle with Radius, or a box with Width and Height when Radius is zero.
+type GeoSearch struct {
+	FromMember       string
+	FromLon, FromLat float64 // degrees
+
+	Radius        float64
+	Width, Height float64
+
+	// Unit for Radius, Width, Height and result distances is one of "m"
+	// (meters), "km", "ft" and "mi". The empty string defaults to meters.
+	Unit string
+
+	// Count limits the number of results when positive. Any returns as soon
+	// as Count matches are found, which are then not the closest per se.
+	Count int64
+	Any   bool
+
+	// Asc sorts the results from the nearest to the farthest, and Desc does
+	// the opposite. The order is unspecified without either.
+	Asc, Desc bool
+
+	WithCoord, WithDist, WithHash bool
+}
+
+var errGeoShape = errors.New("redis: GEOSEARCH needs either a Radius or a Width and Height")
+
+func validGeo(lon, lat float64) error {
+	if !(lon >= -GeoLonMax && lon <= GeoLonMax && lat >= -GeoLatMax && lat <= GeoLatMax) {
+		return fmt.Errorf("redis: position (%g, %g) out of range", lon, lat)
+	}
+	return nil
+}
+
+// GEOADD executes <https://redis.io/commands/geoadd>. Positions are validated
+// before submission. The return is the number of members added, i.e., excluding
+// updates.
+func (c *Client) GEOADD(key string, members ...GeoMember) (int64, error) {
+	args := make([]string, 0, 3*len(members))
+	for _, m := range members {
+		if err := validGeo(m.Lon, m.Lat); err != nil {
+			return 0, err
+		}
+		args = append(args, formatFloat(m.Lon), formatFloat(m.Lat), m.Name)
+	}
+
+	r := newRequestSize(2+len(args), "\r\n$6\r\nGEOADD\r\n$")
+	r.addStringStringList(key, args)
+	return c.commandInteger(r)
+}
+
+// GEODIST executes <https://redis.io/commands/geodist>. The unit is one of "m"
+// (meters), "km", "ft" and "mi". The empty string defaults to meters. Boolean ok
+// is false if either member does not exist.
+func (c *Client) GEODIST(key, member1, member2, unit string) (dist float64, ok bool, err error) {
+	if unit == "" {
+		unit = "m"
+	}
+	r := newRequest("*5\r\n$7\r\nGEODIST\r\n$")
+	r.addStringStringStringString(key, member1, member2, unit)
+	return c.commandFloat(r)
+}
+
+// GEOSEARCH executes <https://redis.io/commands/geosearch>.
+// The return is empty if key does not exist.
+func (c *Client) GEOSEARCH(key string, q *GeoSearch) ([]GeoResult, error) {
+	args := make([]string, 0, 16)
+	if q.FromMember != "" {
+		args = append(args, "FROMMEMBER", q.FromMember)
+	} else {
+		if err := validGeo(q.FromLon, q.FromLat); err != nil {
+			return nil, err
+		}
+		args = append(args, "FROMLONLAT", formatFloat(q.FromLon), formatFloat(q.FromLat))
+	}
+
+	unit := q.Unit
+	if unit == "" {
+		unit = "m"
+	}
+	switch {
+	case q.Radius > 0:
+		args = append(args, "BYRADIUS", formatFloat(q.Radius), unit)
+	case q.Width > 0 && q.Height > 0:
+		args = append(args, "BYBOX", formatFloat(q.Width), formatFloat(q.Height), unit)
+	default:
+		return nil, errGeoShape
+	}
+
+	switch {
+	case q.Asc:
+		args = append(args, "ASC")
+	case q.Desc:
+		args = append(args, "DESC")
+	}
+	if q.Count > 0 {
+		args = append(args, "COUNT", strconv.FormatInt(q.Count, 10))
+		if q.Any {
+			args = append(args, "ANY")
+		}
+	}
+	if q.WithCoord {
+		args = append(args, "WITHCOORD")
+	}
+	if q.WithDist {
+		args = append(args, "WITHDIST")
+	}
+	if q.WithHash {
+		args = append(args, "WITHHASH")
+	}
+
+	r := newRequestSize(2+len(args), "\r\n$9\r\nGEOSEARCH\r\n$")
+	r.addStringStringList(key, args)
+	return c.commandGeoResults(r, q)
+}
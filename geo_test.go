@@ -0,0 +1,81 @@
+package redis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGeo(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test-geo")
+
+	n, err := testClient.GEOADD(key,
+		GeoMember{Lon: 13.361389, Lat: 38.115556, Name: "Palermo"},
+		GeoMember{Lon: 15.087269, Lat: 37.502669, Name: "Catania"},
+	)
+	if err != nil {
+		t.Fatalf("GEOADD %q error: %s", key, err)
+	}
+	if n != 2 {
+		t.Errorf("GEOADD %q got %d, want 2", key, n)
+	}
+
+	if dist, ok, err := testClient.GEODIST(key, "Palermo", "Catania", "km"); err != nil {
+		t.Errorf("GEODIST %q error: %s", key, err)
+	} else if !ok || math.Abs(dist-166.2742) > 0.001 {
+		t.Errorf("GEODIST %q got %f, %t, want 166.2742 km", key, dist, ok)
+	}
+	if _, ok, err := testClient.GEODIST(key, "Palermo", "Rome", ""); err != nil {
+		t.Errorf("GEODIST %q with absent member error: %s", key, err)
+	} else if ok {
+		t.Errorf("GEODIST %q with absent member got ok", key)
+	}
+
+	results, err := testClient.GEOSEARCH(key, &GeoSearch{
+		FromLon: 15, FromLat: 37,
+		Radius: 200, Unit: "km",
+		Asc:       true,
+		WithCoord: true, WithDist: true,
+	})
+	if err != nil {
+		t.Fatalf("GEOSEARCH %q error: %s", key, err)
+	}
+	if len(results) != 2 || results[0].Name != "Catania" || results[1].Name != "Palermo" {
+		t.Fatalf("GEOSEARCH %q got %+v, want Catania and Palermo", key, results)
+	}
+	if math.Abs(results[0].Dist-56.4413) > 0.001 {
+		t.Errorf("GEOSEARCH %q got distance %f for Catania, want 56.4413", key, results[0].Dist)
+	}
+	if math.Abs(results[0].Lon-15.087269) > 0.0001 || math.Abs(results[0].Lat-37.502669) > 0.0001 {
+		t.Errorf("GEOSEARCH %q got position (%f, %f) for Catania", key, results[0].Lon, results[0].Lat)
+	}
+
+	results, err = testClient.GEOSEARCH(key, &GeoSearch{
+		FromMember: "Palermo",
+		Width:      100, Height: 100, Unit: "km",
+	})
+	if err != nil {
+		t.Fatalf("GEOSEARCH %q FROMMEMBER error: %s", key, err)
+	}
+	if len(results) != 1 || results[0].Name != "Palermo" {
+		t.Errorf("GEOSEARCH %q FROMMEMBER got %+v, want Palermo only", key, results)
+	}
+}
+
+func TestGeoValidation(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test-geo")
+
+	if _, err := testClient.GEOADD(key, GeoMember{Lon: 0, Lat: 86, Name: "pole"}); err == nil {
+		t.Error("GEOADD with latitude 86 got no error")
+	}
+	if _, err := testClient.GEOADD(key, GeoMember{Lon: -181, Lat: 0, Name: "west"}); err == nil {
+		t.Error("GEOADD with longitude -181 got no error")
+	}
+	if _, err := testClient.GEOSEARCH(key, &GeoSearch{FromLon: math.NaN(), Radius: 1}); err == nil {
+		t.Error("GEOSEARCH from NaN longitude got no error")
+	}
+	if _, err := testClient.GEOSEARCH(key, &GeoSearch{FromMember: "x"}); err != errGeoShape {
+		t.Errorf("GEOSEARCH without shape got error %v, want %v", err, errGeoShape)
+	}
+}
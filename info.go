@@ -0,0 +1,104 @@
+package redis
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ServerInfo is the parsed INFO report.
+type ServerInfo struct {
+	// Sections maps each section name, e.g., "Server" or "Memory", to its
+	// fields. Fields before any section header go in the empty name.
+	Sections map[string]map[string]string
+}
+
+// INFO executes <https://redis.io/commands/info>. Zero sections gets the
+// default set. See ServerInfo for the common fields.
+func (c *Client) INFO(sections ...string) (*ServerInfo, error) {
+	r := newRequestSize(1+len(sections), "\r\n$4\r\nINFO")
+	r.addStringList(sections)
+	report, _, err := c.commandBlobString(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseInfo(report), nil
+}
+
+// ParseInfo reads the "# Section" headers and the "field:value" lines in
+// between. Line ends may be CRLF or LF. Blank lines are ignored.
+func parseInfo(report string) *ServerInfo {
+	info := &ServerInfo{Sections: make(map[string]map[string]string)}
+
+	section := ""
+	for len(report) != 0 {
+		var line string
+		if i := strings.IndexByte(report, '\n'); i >= 0 {
+			line, report = report[:i], report[i+1:]
+		} else {
+			line, report = report, ""
+		}
+		line = strings.TrimSuffix(line, "\r")
+
+		switch {
+		case line == "":
+			continue
+		case line[0] == '#':
+			section = strings.TrimSpace(line[1:])
+			if info.Sections[section] == nil {
+				info.Sections[section] = make(map[string]string)
+			}
+			continue
+		}
+
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			continue // not a field
+		}
+		fields := info.Sections[section]
+		if fields == nil {
+			fields = make(map[string]string)
+			info.Sections[section] = fields
+		}
+		fields[line[:i]] = line[i+1:]
+	}
+	return info
+}
+
+// Get returns the value of a field in a section, with false for absence.
+func (info *ServerInfo) Get(section, field string) (value string, ok bool) {
+	value, ok = info.Sections[section][field]
+	return
+}
+
+// Int returns the value of a field in a section, with zero for absence and
+// for values which are not an integer.
+func (info *ServerInfo) Int(section, field string) int64 {
+	v, _ := strconv.ParseInt(info.Sections[section][field], 10, 64)
+	return v
+}
+
+// RedisVersion returns redis_version from the Server section.
+func (info *ServerInfo) RedisVersion() string {
+	return info.Sections["Server"]["redis_version"]
+}
+
+// UptimeInSeconds returns uptime_in_seconds from the Server section.
+func (info *ServerInfo) UptimeInSeconds() int64 {
+	return info.Int("Server", "uptime_in_seconds")
+}
+
+// ConnectedClients returns connected_clients from the Clients section.
+func (info *ServerInfo) ConnectedClients() int64 {
+	return info.Int("Clients", "connected_clients")
+}
+
+// UsedMemory returns used_memory, in bytes, from the Memory section.
+func (info *ServerInfo) UsedMemory() int64 {
+	return info.Int("Memory", "used_memory")
+}
+
+// Role returns role from the Replication section, which is either "master"
+// or "slave".
+func (info *ServerInfo) Role() string {
+	return info.Sections["Replication"]["role"]
+}
@@ -0,0 +1,49 @@
+package redis
+
+import "testing"
+
+func TestParseInfo(t *testing.T) {
+	const report = "# Server\r\nredis_version:6.2.6\r\nuptime_in_seconds:42\r\n\r\n" +
+		"# Clients\r\nconnected_clients:3\r\n\r\n" +
+		"# Memory\r\nused_memory:871232\r\nused_memory_human:850.81K\r\n\r\n" +
+		"# Replication\nrole:master\n\n" +
+		"# Keyspace\r\ndb0:keys=1,expires=0,avg_ttl=0\r\n"
+
+	info := parseInfo(report)
+	if got := info.RedisVersion(); got != "6.2.6" {
+		t.Errorf("got Redis version %q, want 6.2.6", got)
+	}
+	if got := info.UptimeInSeconds(); got != 42 {
+		t.Errorf("got uptime %d, want 42", got)
+	}
+	if got := info.ConnectedClients(); got != 3 {
+		t.Errorf("got %d connected clients, want 3", got)
+	}
+	if got := info.UsedMemory(); got != 871232 {
+		t.Errorf("got used memory %d, want 871232", got)
+	}
+	if got := info.Role(); got != "master" {
+		t.Errorf("got role %q, want master", got)
+	}
+	if got, ok := info.Get("Keyspace", "db0"); !ok || got != "keys=1,expires=0,avg_ttl=0" {
+		t.Errorf("got keyspace db0 %q, %t", got, ok)
+	}
+	if got := info.Int("Memory", "used_memory_human"); got != 0 {
+		t.Errorf("got %d for a non-integer field, want 0", got)
+	}
+}
+
+func TestINFO(t *testing.T) {
+	t.Parallel()
+
+	info, err := testClient.INFO("server", "clients")
+	if err != nil {
+		t.Fatal("INFO error:", err)
+	}
+	if info.RedisVersion() == "" {
+		t.Errorf("INFO got no Redis version in %q", info.Sections)
+	}
+	if info.ConnectedClients() < 1 {
+		t.Errorf("INFO got %d connected clients, want 1 or more", info.ConnectedClients())
+	}
+}
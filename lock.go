@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// Lock is a mutual exclusion on a single Redis node, as acquired with
+// AcquireLock. The key holds a random token to identify the owner. Multiple
+// goroutines may use a Lock simultaneously.
+type Lock struct {
+	// Key is the Redis key. This field is read-only.
+	Key string
+
+	// Token is the value which identifies the owner. This field is
+	// read-only.
+	Token string
+
+	c *Client
+}
+
+// Only the owner, as identified by the token, may delete or extend a lock.
+var (
+	lockReleaseScript = NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`)
+	lockRefreshScript = NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) else return 0 end`)
+)
+
+// AcquireLock executes <https://redis.io/commands/set> with NX and PX on key,
+// with a random token as the value. The return is nil when key exists, i.e.,
+// when the lock is held by another owner. The ttl is truncated to whole
+// milliseconds. The lock expires after ttl, unless Release or Refresh.
+func (c *Client) AcquireLock(key string, ttl time.Duration) (*Lock, error) {
+	var random [16]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(random[:])
+
+	ok, err := c.SETStringWithOptions(key, token, SETOptions{Flags: NX | PX, Expire: ttl})
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &Lock{Key: key, Token: token, c: c}, nil
+}
+
+// Release deletes the key when it still holds the token. The return is false
+// when the lock expired already, including when another owner acquired the
+// lock since.
+func (l *Lock) Release() (bool, error) {
+	v, err := lockReleaseScript.EVAL(l.c, []string{l.Key}, []string{l.Token})
+	if err != nil {
+		return false, err
+	}
+	return v == int64(1), nil
+}
+
+// Refresh sets the expiry of the key to ttl when it still holds the token.
+// The ttl is truncated to whole milliseconds. The return is false when the
+// lock expired already, including when another owner acquired the lock since.
+func (l *Lock) Refresh(ttl time.Duration) (bool, error) {
+	v, err := lockRefreshScript.EVAL(l.c, []string{l.Key}, []string{l.Token, strconv.FormatInt(int64(ttl/time.Millisecond), 10)})
+	if err != nil {
+		return false, err
+	}
+	return v == int64(1), nil
+}
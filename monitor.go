@@ -0,0 +1,298 @@
+package redis
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MonitorConfig defines a Monitor setup.
+type MonitorConfig struct {
+	// Func is the callback for each command trace. Invocation is in order
+	// of reception. Slow or blocking receivers should spawn of in a
+	// separate routine.
+	Func func(MonitorEntry)
+
+	// ErrFunc is the optional callback for lines which fail to parse,
+	// and for the connection error which terminates the stream, if any.
+	// Malformed lines do not interrupt the stream.
+	ErrFunc func(error)
+
+	// Upper boundary for the number of bytes in a line. Larger lines
+	// are skipped with an io.ErrShortBuffer to ErrFunc. Zero defaults
+	// to 64 KiB. Values larger than SizeMax have no effect.
+	BufferSize int
+
+	// The host defaults to localhost, and the port defaults to 6379.
+	// Thus, the empty string defaults to "localhost:6379". Use an
+	// absolute file path (e.g. "/var/run/redis.sock") for Unix
+	// domain sockets.
+	Addr string
+
+	// Upper boundary for network connection establishment. See the
+	// net.Dialer Timeout for details. Zero defaults to one second.
+	DialTimeout time.Duration
+
+	// Optional AUTH [command] value applied to the connection.
+	Password []byte
+
+	// Limits the execution for AUTH and MONITOR. Zero defaults to one
+	// second.
+	CommandTimeout time.Duration
+}
+
+// MonitorEntry is a command trace from <https://redis.io/commands/monitor>.
+type MonitorEntry struct {
+	// Timestamp is the moment of execution, with microsecond precision.
+	Timestamp time.Time
+
+	// DB is the database selected by the client.
+	DB int
+
+	// ClientAddr is the network address of the client. Commands from
+	// scripts have "lua" instead, and Unix domain socket connections
+	// have a "unix:" prefix.
+	ClientAddr string
+
+	// Args has the command name followed by its arguments.
+	Args []string
+}
+
+// Monitor streams command traces from a Redis node, on a dedicated network
+// connection, until Close. A Monitor does not reconnect. A broken connection
+// terminates the stream, with the cause passed to ErrFunc.
+type Monitor struct {
+	MonitorConfig // read-only attributes
+
+	conn net.Conn
+
+	closeOnce sync.Once
+	// read loop completion
+	done chan struct{}
+}
+
+// NewMonitor connects, and it starts the stream with the MONITOR command.
+func NewMonitor(config MonitorConfig) (*Monitor, error) {
+	m := &Monitor{
+		MonitorConfig: config,
+		done:          make(chan struct{}),
+	}
+	// apply configuration defaults
+	if m.BufferSize == 0 {
+		m.BufferSize = 1 << 16
+	}
+	if m.CommandTimeout == 0 {
+		m.CommandTimeout = time.Second
+	}
+	if m.DialTimeout == 0 {
+		m.DialTimeout = time.Second
+	}
+
+	conn, reader, err := connect(connConfig{
+		BufferSize:     m.BufferSize,
+		Addr:           normalizeAddr(m.Addr),
+		DialTimeout:    m.DialTimeout,
+		CommandTimeout: m.CommandTimeout,
+		Password:       m.Password,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("redis: monitor offline due %w", err)
+	}
+
+	conn.SetDeadline(time.Now().Add(m.CommandTimeout))
+	_, err = conn.Write([]byte("*1\r\n$7\r\nMONITOR\r\n"))
+	if err == nil {
+		err = decodeOK(reader)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("redis: MONITOR with %w", err)
+	}
+	conn.SetDeadline(time.Time{})
+
+	m.conn = conn
+	go m.readLoop(reader)
+	return m, nil
+}
+
+// Close terminates the stream. The network connection is closed, and the
+// return awaits the read routine to exit, i.e., no more callbacks happen after
+// Close. Calling Close more than once just blocks until the first call
+// completed.
+func (m *Monitor) Close() error {
+	var err error
+	m.closeOnce.Do(func() {
+		err = m.conn.Close()
+	})
+	<-m.done
+	return err
+}
+
+func (m *Monitor) readLoop(reader *bufio.Reader) {
+	defer close(m.done)
+
+	for {
+		line, err := reader.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// skip remainder of line
+			for err == bufio.ErrBufferFull {
+				_, err = reader.ReadSlice('\n')
+			}
+			if err == nil {
+				m.error(fmt.Errorf("redis: monitor line exceeds %d bytes: %w", reader.Size(), io.ErrShortBuffer))
+				continue
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !isClosed(err) {
+				m.error(fmt.Errorf("redis: monitor stream got %w", err))
+			}
+			return
+		}
+
+		entry, err := parseMonitorLine(line)
+		if err != nil {
+			m.error(err)
+			continue
+		}
+		m.Func(entry)
+	}
+}
+
+func (m *Monitor) error(err error) {
+	if m.ErrFunc != nil {
+		m.ErrFunc(err)
+	}
+}
+
+// ParseMonitorLine decodes a simple string like
+// `+1339518083.107412 [0 127.0.0.1:60866] "keys" "*"`.
+func parseMonitorLine(line []byte) (MonitorEntry, error) {
+	var entry MonitorEntry
+	if len(line) < 3 || line[len(line)-2] != '\r' || line[len(line)-1] != '\n' {
+		return entry, fmt.Errorf("%w; monitor line %.40q without CRLF", errProtocol, line)
+	}
+	switch line[0] {
+	case '+':
+		break
+	case '-':
+		return entry, ServerError(line[1 : len(line)-2])
+	default:
+		return entry, fmt.Errorf("%w; expected simple string, got %.40q", errProtocol, line)
+	}
+	s := string(line[1 : len(line)-2])
+
+	// timestamp in seconds with microseconds
+	i := 0
+	for i < len(s) && s[i] != ' ' {
+		i++
+	}
+	sec, usec := s[:i], ""
+	for j := 0; j < len(sec); j++ {
+		if sec[j] == '.' {
+			sec, usec = sec[:j], sec[j+1:]
+			break
+		}
+	}
+	secs, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return entry, fmt.Errorf("%w; monitor timestamp in %.40q", errProtocol, line)
+	}
+	var usecs int64
+	if usec != "" {
+		usecs, err = strconv.ParseInt(usec, 10, 64)
+		if err != nil {
+			return entry, fmt.Errorf("%w; monitor timestamp in %.40q", errProtocol, line)
+		}
+	}
+	entry.Timestamp = time.Unix(secs, usecs*1000)
+
+	// client as "[db addr]"
+	if i+1 >= len(s) || s[i+1] != '[' {
+		return entry, fmt.Errorf("%w; monitor client in %.40q", errProtocol, line)
+	}
+	s = s[i+2:]
+	end, space := -1, -1
+	for j := 0; j < len(s); j++ {
+		if s[j] == ' ' && space < 0 {
+			space = j
+		}
+		if s[j] == ']' {
+			end = j
+			break
+		}
+	}
+	if end < 0 || space < 0 || space > end {
+		return entry, fmt.Errorf("%w; monitor client in %.40q", errProtocol, line)
+	}
+	entry.DB, err = strconv.Atoi(s[:space])
+	if err != nil {
+		return entry, fmt.Errorf("%w; monitor database in %.40q", errProtocol, line)
+	}
+	entry.ClientAddr = s[space+1 : end]
+	s = s[end+1:]
+
+	// arguments as quoted strings, separated by a space
+	for len(s) != 0 {
+		if len(s) < 3 || s[0] != ' ' || s[1] != '"' {
+			return entry, fmt.Errorf("%w; monitor arguments in %.40q", errProtocol, line)
+		}
+		arg, n, ok := unquoteMonitorArg(s[2:])
+		if !ok {
+			return entry, fmt.Errorf("%w; monitor arguments in %.40q", errProtocol, line)
+		}
+		entry.Args = append(entry.Args, arg)
+		s = s[2+n:]
+	}
+	return entry, nil
+}
+
+// UnquoteMonitorArg decodes the escape sequences from the server, up until the
+// closing double quote. The return has the number of bytes consumed, including
+// the closing double quote.
+func unquoteMonitorArg(s string) (arg string, n int, ok bool) {
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"':
+			return string(buf), i + 1, true
+		case '\\':
+			i++
+			if i >= len(s) {
+				return "", 0, false
+			}
+			switch s[i] {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'a':
+				c = '\a'
+			case 'b':
+				c = '\b'
+			case 'x':
+				if i+2 >= len(s) {
+					return "", 0, false
+				}
+				v, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+				if err != nil {
+					return "", 0, false
+				}
+				c = byte(v)
+				i += 2
+			default:
+				c = s[i] // includes \" and \\
+			}
+		}
+		buf = append(buf, c)
+	}
+	return "", 0, false
+}
@@ -0,0 +1,114 @@
+package redis
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseMonitorLine(t *testing.T) {
+	golden := []struct {
+		line string
+		want MonitorEntry
+	}{
+		{"+1339518083.107412 [0 127.0.0.1:60866] \"keys\" \"*\"\r\n", MonitorEntry{
+			Timestamp:  time.Unix(1339518083, 107412000),
+			ClientAddr: "127.0.0.1:60866",
+			Args:       []string{"keys", "*"},
+		}},
+		{"+1339518087.877697 [3 lua] \"set\" \"a\\\"b\" \"x\\\\y\"\r\n", MonitorEntry{
+			Timestamp:  time.Unix(1339518087, 877697000),
+			DB:         3,
+			ClientAddr: "lua",
+			Args:       []string{"set", `a"b`, `x\y`},
+		}},
+		{"+1.000001 [15 unix:/tmp/redis.sock] \"ECHO\" \"\\r\\n\\t\\x00\\xff\" \"\"\r\n", MonitorEntry{
+			Timestamp:  time.Unix(1, 1000),
+			DB:         15,
+			ClientAddr: "unix:/tmp/redis.sock",
+			Args:       []string{"ECHO", "\r\n\t\x00\xff", ""},
+		}},
+	}
+	for _, gold := range golden {
+		got, err := parseMonitorLine([]byte(gold.line))
+		if err != nil {
+			t.Errorf("%q got error: %s", gold.line, err)
+			continue
+		}
+		if !got.Timestamp.Equal(gold.want.Timestamp) {
+			t.Errorf("%q got timestamp %s, want %s", gold.line, got.Timestamp, gold.want.Timestamp)
+		}
+		got.Timestamp = gold.want.Timestamp
+		if !reflect.DeepEqual(got, gold.want) {
+			t.Errorf("%q got %+v, want %+v", gold.line, got, gold.want)
+		}
+	}
+
+	for _, line := range []string{
+		"+1339518083.107412 [0 127.0.0.1:60866] \"keys\r\n",
+		"+1339518083.107412 [0 127.0.0.1:60866 \"keys\"\r\n",
+		"+x.107412 [0 127.0.0.1:60866] \"keys\"\r\n",
+		"+1339518083.107412 [a 127.0.0.1:60866] \"keys\"\r\n",
+		"+1339518083.107412 [0 127.0.0.1:60866] keys\r\n",
+		"+1339518083.107412 [0 127.0.0.1:60866] \"\\x4\"\r\n",
+		":1\r\n",
+	} {
+		if _, err := parseMonitorLine([]byte(line)); !errors.Is(err, errProtocol) {
+			t.Errorf("%q got error %v, want %v", line, err, errProtocol)
+		}
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	t.Parallel()
+	key := randomKey("monitor")
+
+	entries := make(chan MonitorEntry, 100)
+	m, err := NewMonitor(MonitorConfig{
+		Func:     func(e MonitorEntry) { entries <- e },
+		ErrFunc:  func(err error) { t.Error("monitor error:", err) },
+		Addr:     testClient.Addr,
+		Password: password,
+	})
+	if err != nil {
+		t.Fatal("NewMonitor error:", err)
+	}
+
+	start := time.Now().Add(-time.Second)
+	if err := testClient.SETString(key, "with \"quotes\"\n"); err != nil {
+		t.Fatal("SET error:", err)
+	}
+
+	timeout := time.After(time.Second)
+	for {
+		var e MonitorEntry
+		select {
+		case e = <-entries:
+		case <-timeout:
+			t.Fatal("no monitor entry for the SET")
+		}
+		if len(e.Args) != 3 || e.Args[1] != key {
+			continue // other test traffic
+		}
+
+		if want := []string{"SET", key, "with \"quotes\"\n"}; !reflect.DeepEqual(e.Args, want) {
+			t.Errorf("got arguments %q, want %q", e.Args, want)
+		}
+		if e.Timestamp.Before(start) || e.Timestamp.After(time.Now().Add(time.Second)) {
+			t.Errorf("got timestamp %s, want approximately now", e.Timestamp)
+		}
+		if e.ClientAddr == "" {
+			t.Error("got empty client address")
+		}
+		break
+	}
+
+	if err := m.Close(); err != nil {
+		t.Error("close error:", err)
+	}
+	// await with Close return
+	if err := m.Close(); err != nil {
+		t.Error("second close error:", err)
+	}
+}
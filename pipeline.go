@@ -0,0 +1,91 @@
+package redis
+
+// Pipeline is a batch of commands. Commands queue locally, until Exec submits
+// them all in one write. Each command executes on its own, i.e., without the
+// atomicity of a Tx. Use a Pipeline to send many commands from one goroutine;
+// the Client pipelines concurrent submissions from multiple goroutines already.
+// A Pipeline is not safe for concurrent use.
+type Pipeline struct {
+	// Client is the executor of the batch.
+	Client *Client
+
+	cmds    [][]interface{}
+	results []*Result
+	// first argument error, if any
+	err error
+}
+
+// Pipeline returns a new batch on c.
+func (c *Client) Pipeline() *Pipeline {
+	return &Pipeline{Client: c}
+}
+
+// Queue adds a command for execution. The arguments go like Do. The Result is
+// available once Exec returned.
+func (p *Pipeline) Queue(args ...interface{}) *Result {
+	r := &Result{err: errNotExecuted}
+	if err := checkDoArgs(args); err != nil && p.err == nil {
+		p.err = err
+	}
+	p.cmds = append(p.cmds, args)
+	p.results = append(p.results, r)
+	return r
+}
+
+// Len returns the number of commands queued.
+func (p *Pipeline) Len() int { return len(p.cmds) }
+
+// Exec submits the commands queued, after which the Pipeline is empty again.
+// Error replies go to the Result of the respective command only, as a
+// ServerError. Any other error applies to all Results without a response.
+// The command timeout of the Client applies to the batch as a whole.
+func (p *Pipeline) Exec() error {
+	cmds, results, err := p.cmds, p.results, p.err
+	p.cmds, p.results, p.err = nil, nil, nil
+	if len(cmds) == 0 {
+		return err
+	}
+	if err == nil {
+		err = p.exec(cmds, results)
+	}
+	if err != nil {
+		for _, r := range results {
+			if r.err == errNotExecuted {
+				r.err = err
+			}
+		}
+	}
+	return err
+}
+
+func (p *Pipeline) exec(cmds [][]interface{}, results []*Result) error {
+	req := newRequest("")
+	if err := req.addCommandList(cmds); err != nil {
+		req.free()
+		return err
+	}
+
+	req.more = len(cmds) - 1
+	reader, err := p.Client.submit(req)
+	if err != nil {
+		return err
+	}
+	for i, r := range results {
+		if i != 0 {
+			if err := p.Client.dispatchPush(reader); err != nil {
+				p.Client.pass(reader, err)
+				return err
+			}
+		}
+		v, err := decodeReply(reader)
+		if err != nil {
+			if _, ok := err.(ServerError); !ok {
+				p.Client.pass(reader, err)
+				return err
+			}
+		}
+		r.reply, r.err = v, err
+	}
+	p.Client.pass(reader, nil)
+	return nil
+}
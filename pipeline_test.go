@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestPipeline(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test")
+	listKey := randomKey("test-list")
+
+	p := testClient.Pipeline()
+	if err := p.Exec(); err != nil {
+		t.Error("Exec without commands error:", err)
+	}
+
+	const n = 10000
+	incrs := make([]*Result, n)
+	for i := range incrs {
+		incrs[i] = p.Queue("INCR", key)
+	}
+	p.Queue("RPUSH", listKey, "x")
+	wrongType := p.Queue("GET", listKey)
+	get := p.Queue("GET", key)
+	if p.Len() != n+3 {
+		t.Errorf("got length %d, want %d", p.Len(), n+3)
+	}
+	if err := p.Exec(); err != nil {
+		t.Fatal("Exec error:", err)
+	}
+	if p.Len() != 0 {
+		t.Error("Pipeline not empty after Exec")
+	}
+
+	for i, r := range incrs {
+		if v, err := r.Int(); err != nil || v != int64(i+1) {
+			t.Fatalf("INCR %d got %d, %v; want %d", i, v, err, i+1)
+		}
+	}
+	if err := wrongType.Err(); !errors.Is(err, ErrWrongType) {
+		t.Errorf("GET on list got error %v, want a WRONGTYPE", err)
+	}
+	if v, err := get.Str(); err != nil || v != strconv.Itoa(n) {
+		t.Errorf("GET got %q, %v; want %d", v, err, n)
+	}
+
+	// client side rejection
+	r := p.Queue("MONITOR")
+	if err := p.Exec(); err != ErrSubscribeMode {
+		t.Errorf("Exec with MONITOR got error %v, want ErrSubscribeMode", err)
+	}
+	if err := r.Err(); err != ErrSubscribeMode {
+		t.Errorf("MONITOR result got error %v, want ErrSubscribeMode", err)
+	}
+}
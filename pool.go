@@ -0,0 +1,94 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// Pool is a fixed set of Clients to the same node, each with its own
+// connection. Commands on a Client share the connection in order, which means
+// that a large response or a blocking command holds up any command after it.
+// A Pool spreads such load over multiple connections. Multiple goroutines may
+// use a Pool simultaneously.
+type Pool struct {
+	clients []*Client
+
+	// round-robin offset for Client selection
+	next uint32
+}
+
+// NewPool launches size Clients to a node, each as if NewClientWithOptions.
+// Connection settings such as AUTH and SELECT go with the ClientOptions, as
+// the AUTH, SELECT and CLIENTSETNAME methods apply to a single Client only.
+func NewPool(addr string, size int, commandTimeout, dialTimeout time.Duration, o ClientOptions) (*Pool, error) {
+	if size < 1 {
+		return nil, errors.New("redis: pool size less than one")
+	}
+	p := &Pool{clients: make([]*Client, size)}
+	for i := range p.clients {
+		p.clients[i] = NewClientWithOptions(addr, commandTimeout, dialTimeout, o)
+	}
+	return p, nil
+}
+
+// Client returns the Client with the least commands in progress, i.e., the
+// QueueLen plus any response being read. Ties rotate. Offline Clients are
+// passed over, unless none of the Clients is online. Use the return for a
+// single command, or for a short sequence, as the load changes constantly.
+// Blocking commands and large responses, such as BLPOP and HGETALL, should
+// get a fresh Client for each invocation.
+func (p *Pool) Client() *Client {
+	offset := int(atomic.AddUint32(&p.next, 1))
+	var best *Client
+	bestLoad := -1
+	for i := range p.clients {
+		c := p.clients[(offset+i)%len(p.clients)]
+		if c.State().State != Online {
+			continue
+		}
+		load := c.QueueLen() + int(atomic.LoadInt32(&c.busy))
+		if bestLoad < 0 || load < bestLoad {
+			best, bestLoad = c, load
+			if load == 0 {
+				break // can't get any better
+			}
+		}
+	}
+	if best == nil {
+		// commands get the connection error
+		return p.clients[offset%len(p.clients)]
+	}
+	return best
+}
+
+// Close terminates all Clients in the Pool. The return is the first error, if
+// any. See Client Close for details.
+func (p *Pool) Close() error {
+	var err error
+	for _, c := range p.clients {
+		if e := c.Close(); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// CloseWait terminates all Clients in the Pool gracefully. The return is the
+// first error, if any. See Client CloseWait for details.
+func (p *Pool) CloseWait(ctx context.Context) error {
+	errs := make(chan error, len(p.clients))
+	for _, c := range p.clients {
+		go func(c *Client) {
+			errs <- c.CloseWait(ctx)
+		}(c)
+	}
+	var err error
+	for range p.clients {
+		if e := <-errs; err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	t.Parallel()
+	p, err := NewPool(testClient.Addr, 2, time.Second, 0, ClientOptions{Password: password})
+	if err != nil {
+		t.Fatal("NewPool error:", err)
+	}
+	defer p.Close()
+
+	// await connects
+	for i := 0; ; i++ {
+		if p.clients[0].State().State == Online && p.clients[1].State().State == Online {
+			break
+		}
+		if i > 100 {
+			t.Fatal("pool not online")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	key := randomKey("test-list")
+	blocked := p.Client()
+	popDone := make(chan error, 1)
+	go func() {
+		_, _, err := blocked.BLMPOP(500*time.Millisecond, LEFT, 1, key)
+		popDone <- err
+	}()
+	for i := 0; atomic.LoadInt32(&blocked.busy) == 0; i++ {
+		if i > 100 {
+			t.Fatal("BLMPOP not in progress")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for i := 0; i < 10; i++ {
+		c := p.Client()
+		if c == blocked {
+			t.Fatal("got the Client with BLMPOP in progress")
+		}
+		if _, err := c.EXISTS(key); err != nil {
+			t.Fatal("EXISTS error:", err)
+		}
+	}
+
+	if err := <-popDone; err != nil {
+		t.Error("BLMPOP error:", err)
+	}
+}
+
+func TestPoolSize(t *testing.T) {
+	if _, err := NewPool(testClient.Addr, 0, time.Second, 0, ClientOptions{}); err == nil {
+		t.Error("NewPool with size 0 got no error")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -25,6 +26,22 @@ func (c *Client) PUBLISHString(channel, message string) (clientCount int64, err
 	return c.commandInteger(r)
 }
 
+// SPUBLISH executes <https://redis.io/commands/spublish>, which requires Redis
+// 7.0. In a cluster, the node must serve the Slot of channel.
+func (c *Client) SPUBLISH(channel string, message []byte) (clientCount int64, err error) {
+	r := newRequest("*3\r\n$8\r\nSPUBLISH\r\n$")
+	r.addStringBytes(channel, message)
+	return c.commandInteger(r)
+}
+
+// SPUBLISHString executes <https://redis.io/commands/spublish>, which requires
+// Redis 7.0. In a cluster, the node must serve the Slot of channel.
+func (c *Client) SPUBLISHString(channel, message string) (clientCount int64, err error) {
+	r := newRequest("*3\r\n$8\r\nSPUBLISH\r\n$")
+	r.addStringString(channel, message)
+	return c.commandInteger(r)
+}
+
 // ListenerConfig defines a Listener setup.
 type ListenerConfig struct {
 	// Func is the callback interface for both push messages and error
@@ -58,6 +75,49 @@ type ListenerConfig struct {
 	// closed upon expiry, which causes the automated reconnect attempts.
 	// Zero defaults to one second.
 	CommandTimeout time.Duration
+
+	// Optional callback for messages, including the pattern match of
+	// PSUBSCRIBE. When set, Func receives errors only. The same rules
+	// apply as for Func, i.e., the Payload must not be retained.
+	MessageFunc func(Message)
+
+	// Optional callback for subscription confirmation from the server.
+	// Invocation is in order of reception, interleaved with messages.
+	ConfirmFunc func(Confirm)
+
+	// Optional callback for each reconnect, after connection loss, with
+	// the moment of loss. Messages published since may be missed, up to
+	// the confirmation of each resubscribe. Invocation is after the
+	// resubscribes are sent, and before any message on the connection.
+	ReconnectFunc func(lost time.Time)
+}
+
+// Message is a publication as received by a Listener.
+type Message struct {
+	// Pattern has the PSUBSCRIBE match, which is the empty string for
+	// messages from SUBSCRIBE.
+	Pattern string
+
+	// Channel is the name of the PUBLISH.
+	Channel string
+
+	// Payload is the content of the PUBLISH.
+	Payload []byte
+}
+
+// Confirm is a (un)subscription acknowledgement from the server.
+type Confirm struct {
+	// Kind is either "subscribe", "psubscribe", "ssubscribe",
+	// "unsubscribe", "punsubscribe" or "sunsubscribe".
+	Kind string
+
+	// Name is either the channel or the pattern.
+	Name string
+
+	// Count is the number of subscriptions on the connection, channels
+	// and patterns combined, after the (un)subscription. Sharded channels
+	// count separately.
+	Count int64
 }
 
 // Listener manages a connection to a Redis node until Close. Broken connection
@@ -76,18 +136,42 @@ type Listener struct {
 	subs map[string]time.Time
 	// pending unsubscriptions with their submission moment
 	unsubs map[string]time.Time
+	// requested pattern subscription state with their submission moment
+	psubs map[string]time.Time
+	// pending pattern unsubscriptions with their submission moment
+	punsubs map[string]time.Time
+	// requested shard channel subscription state with their submission moment
+	ssubs map[string]time.Time
+	// pending shard channel unsubscriptions with their submission moment
+	sunsubs map[string]time.Time
 	// shutdown request flag with the submission moment
 	halt time.Time
 	// shutdown completion
 	closed chan struct{}
+
+	// optional connection setup, before any subscription
+	onConnect func(net.Conn, *bufio.Reader) error
+	// optional notification of connection loss
+	onLoss func()
 }
 
 // NewListener launches a managed connection.
 func NewListener(config ListenerConfig) *Listener {
+	l := newListener(config)
+	go l.connectLoop()
+	return l
+}
+
+// newListener returns a Listener without launch.
+func newListener(config ListenerConfig) *Listener {
 	l := &Listener{
 		ListenerConfig: config,
 		subs:           make(map[string]time.Time),
 		unsubs:         make(map[string]time.Time),
+		psubs:          make(map[string]time.Time),
+		punsubs:        make(map[string]time.Time),
+		ssubs:          make(map[string]time.Time),
+		sunsubs:        make(map[string]time.Time),
 		closed:         make(chan struct{}),
 	}
 	// apply configuration defaults
@@ -100,10 +184,6 @@ func NewListener(config ListenerConfig) *Listener {
 	if l.DialTimeout == 0 {
 		l.DialTimeout = time.Second
 	}
-
-	// launch connection management
-	go l.connectLoop()
-
 	return l
 }
 
@@ -142,6 +222,7 @@ func (l *Listener) connectLoop() {
 	}()
 
 	var retryDelay time.Duration
+	var lost time.Time // zero until connection loss
 	for {
 		// l.conn is zero; check l.halt for shutdown requests
 		l.Lock()
@@ -158,6 +239,12 @@ func (l *Listener) connectLoop() {
 			CommandTimeout: l.CommandTimeout,
 			Password:       l.Password,
 		})
+		if err == nil && l.onConnect != nil {
+			err = l.onConnect(conn, reader)
+			if err != nil {
+				conn.Close()
+			}
+		}
 		if err != nil {
 			retry := time.NewTimer(retryDelay)
 
@@ -174,18 +261,51 @@ func (l *Listener) connectLoop() {
 		// connect success
 		retryDelay = 0
 
-		if subscribed, ok := l.releaseConn(conn); ok {
+		if subscribed, psubscribed, ssubscribed, ok := l.releaseConn(conn); ok {
 			if len(subscribed) > 0 {
 				// resubscribe
 				r := newRequestSize(1+len(subscribed), "\r\n$9\r\nSUBSCRIBE")
 				r.addStringList(subscribed)
 				l.submit(conn, r)
 			}
+			if len(psubscribed) > 0 {
+				// resubscribe patterns
+				r := newRequestSize(1+len(psubscribed), "\r\n$10\r\nPSUBSCRIBE")
+				r.addStringList(psubscribed)
+				l.submit(conn, r)
+			}
+			if len(ssubscribed) > 0 {
+				// resubscribe shard channels, one command per
+				// slot, as the server rejects a mix with CROSSSLOT
+				bySlot := make(map[uint16][]string)
+				var slots []uint16
+				for _, name := range ssubscribed {
+					slot := Slot(name)
+					if _, ok := bySlot[slot]; !ok {
+						slots = append(slots, slot)
+					}
+					bySlot[slot] = append(bySlot[slot], name)
+				}
+				for _, slot := range slots {
+					names := bySlot[slot]
+					r := newRequestSize(1+len(names), "\r\n$10\r\nSSUBSCRIBE")
+					r.addStringList(names)
+					l.submit(conn, r)
+				}
+			}
+
+			if !lost.IsZero() && l.ReconnectFunc != nil {
+				l.ReconnectFunc(lost)
+			}
 
 			cancel := make(chan struct{})
 			go l.monitorExpiry(conn, cancel)
 			l.readLoop(reader)
 			close(cancel)
+			lost = time.Now()
+			if l.onLoss != nil {
+				l.onLoss()
+			}
 
 			// retract after releaseConn
 			l.Lock()
@@ -196,12 +316,12 @@ func (l *Listener) connectLoop() {
 	}
 }
 
-func (l *Listener) releaseConn(conn net.Conn) (subscribed []string, ok bool) {
+func (l *Listener) releaseConn(conn net.Conn) (subscribed, psubscribed, ssubscribed []string, ok bool) {
 	l.Lock()
 	defer l.Unlock()
 
 	if !l.halt.IsZero() {
-		return nil, false
+		return nil, nil, nil, false
 	}
 
 	l.conn = conn
@@ -211,6 +331,14 @@ func (l *Listener) releaseConn(conn net.Conn) (subscribed []string, ok bool) {
 		delete(l.unsubs, name)
 		delete(l.subs, name)
 	}
+	for pattern := range l.punsubs {
+		delete(l.punsubs, pattern)
+		delete(l.psubs, pattern)
+	}
+	for name := range l.sunsubs {
+		delete(l.sunsubs, name)
+		delete(l.ssubs, name)
+	}
 
 	// collect subscription state
 	now := time.Now()
@@ -218,15 +346,33 @@ func (l *Listener) releaseConn(conn net.Conn) (subscribed []string, ok bool) {
 		l.subs[name] = now // reset timestamp
 		subscribed = append(subscribed, name)
 	}
+	for pattern := range l.psubs {
+		l.psubs[pattern] = now // reset timestamp
+		psubscribed = append(psubscribed, pattern)
+	}
+	for name := range l.ssubs {
+		l.ssubs[name] = now // reset timestamp
+		ssubscribed = append(ssubscribed, name)
+	}
 
-	return subscribed, true
+	return subscribed, psubscribed, ssubscribed, true
 }
 
 var errPushArrayEmpty = errors.New("redis: got push array with 0 elements")
 
+// ErrShardUnsubscribe is a sunsubscribe from the server, rather than on
+// request, such as when the slot of the shard channel moved to another node.
+// Func receives the channel with the error, for an SSUBSCRIBE on the Listener
+// of the new node.
+var ErrShardUnsubscribe = errors.New("redis: shard channel unsubscribed by server")
+
 func (l *Listener) readLoop(reader *bufio.Reader) error {
 	// confirmed state as message channel mapping
 	subscriptions := make(map[string]string)
+	// confirmed state as message pattern mapping
+	psubscriptions := make(map[string]string)
+	// confirmed state as shard message channel mapping
+	ssubscriptions := make(map[string]string)
 
 	for {
 		// receive push array
@@ -250,7 +396,13 @@ func (l *Listener) readLoop(reader *bufio.Reader) error {
 		if err != nil {
 			return fmt.Errorf("redis: push kind length got %w", err)
 		}
-		// skip actual label; length is enough
+		// skip actual label; length is enough,
+		// except for the 's' of shard channels
+		label, err := reader.Peek(1)
+		if err != nil {
+			return fmt.Errorf("redis: push kind string got %w", err)
+		}
+		shard := label[0] == 's'
 		if _, err := reader.Discard(kindLen + 2); err != nil {
 			return fmt.Errorf("redis: push kind string got %w", err)
 		}
@@ -258,6 +410,21 @@ func (l *Listener) readLoop(reader *bufio.Reader) error {
 		default:
 			return fmt.Errorf("redis: push array with %d elements and %d B kind label", elementCount, kindLen)
 
+		case kindLen == len("smessage") && elementCount == 3 && shard:
+			channel, err := decodeBlobToken(reader, ssubscriptions)
+			switch err {
+			case nil:
+				break
+			case errTokenDict:
+				return fmt.Errorf("redis: message for shard channel %q while not subscribed", channel)
+			default:
+				return fmt.Errorf("redis: message channel got %w", err)
+			}
+
+			if err := l.readPayload(reader, "", channel); err != nil {
+				return err
+			}
+
 		case kindLen == len("message") && elementCount == 3:
 			channel, err := decodeBlobToken(reader, subscriptions)
 			switch err {
@@ -269,32 +436,33 @@ func (l *Listener) readLoop(reader *bufio.Reader) error {
 				return fmt.Errorf("redis: message channel got %w", err)
 			}
 
-			payloadLen, err := readBlobLen(reader)
-			if err != nil {
-				return fmt.Errorf("redis: message payload length got %w", err)
+			if err := l.readPayload(reader, "", channel); err != nil {
+				return err
 			}
-			payloadSlice, err := reader.Peek(int(payloadLen))
+
+		case kindLen == len("pmessage") && elementCount == 4:
+			pattern, err := decodeBlobToken(reader, psubscriptions)
 			switch err {
 			case nil:
-				l.Func(channel, payloadSlice, nil)
-			case bufio.ErrBufferFull:
-				l.Func(channel, nil, io.ErrShortBuffer)
+				break
+			case errTokenDict:
+				return fmt.Errorf("redis: message for pattern %q while not subscribed", pattern)
 			default:
-				return fmt.Errorf("redis: message payload got %w", err)
+				return fmt.Errorf("redis: message pattern got %w", err)
 			}
-			if _, err := reader.Discard(int(payloadLen) + 2); err != nil {
-				return fmt.Errorf("redis: message payload got %w", err)
-			}
-
-		case kindLen == len("subscribe") && elementCount == 3:
 			channel, err := decodeBlobString(reader)
 			if err != nil {
-				return fmt.Errorf("redis: subscribe channel got %w", err)
+				return fmt.Errorf("redis: message channel got %w", err)
+			}
+
+			if err := l.readPayload(reader, pattern, channel); err != nil {
+				return err
 			}
 
-			// subscription count is useless with concurrency
-			if _, err := decodeInteger(reader); err != nil {
-				return fmt.Errorf("redis: subscription count got %w", err)
+		case kindLen == len("subscribe") && elementCount == 3:
+			channel, count, err := decodeConfirm(reader, nil)
+			if err != nil {
+				return fmt.Errorf("redis: subscribe %w", err)
 			}
 
 			l.Lock()
@@ -302,21 +470,38 @@ func (l *Listener) readLoop(reader *bufio.Reader) error {
 			l.subs[channel] = time.Time{}
 			l.Unlock()
 			subscriptions[channel] = channel
+			l.confirm("subscribe", channel, count)
 
-		case kindLen == len("unsubscribe") && elementCount == 3:
-			// skip actual kind label
-			if _, err := reader.Discard(13); err != nil {
-				return fmt.Errorf("redis: unsubscribe kind got %w", err)
+		case kindLen == len("ssubscribe") && elementCount == 3 && shard:
+			channel, count, err := decodeConfirm(reader, nil)
+			if err != nil {
+				return fmt.Errorf("redis: ssubscribe %w", err)
 			}
 
-			channel, err := decodeBlobToken(reader, subscriptions)
-			if err != nil && err != errTokenDict {
-				return fmt.Errorf("redis: unsubscribe channel got %w", err)
+			l.Lock()
+			// zero submission timestamp stops expiry check
+			l.ssubs[channel] = time.Time{}
+			l.Unlock()
+			ssubscriptions[channel] = channel
+			l.confirm("ssubscribe", channel, count)
+
+		case kindLen == len("psubscribe") && elementCount == 3:
+			pattern, count, err := decodeConfirm(reader, nil)
+			if err != nil {
+				return fmt.Errorf("redis: psubscribe %w", err)
 			}
 
-			// subscription count is useless with concurrency
-			if _, err := decodeInteger(reader); err != nil {
-				return fmt.Errorf("redis: subscription count got %w", err)
+			l.Lock()
+			// zero submission timestamp stops expiry check
+			l.psubs[pattern] = time.Time{}
+			l.Unlock()
+			psubscriptions[pattern] = pattern
+			l.confirm("psubscribe", pattern, count)
+
+		case kindLen == len("unsubscribe") && elementCount == 3:
+			channel, count, err := decodeConfirm(reader, subscriptions)
+			if err != nil {
+				return fmt.Errorf("redis: unsubscribe %w", err)
 			}
 
 			l.Lock()
@@ -324,14 +509,125 @@ func (l *Listener) readLoop(reader *bufio.Reader) error {
 			delete(l.unsubs, channel)
 			l.Unlock()
 			delete(subscriptions, channel)
+			l.confirm("unsubscribe", channel, count)
+
+		case kindLen == len("sunsubscribe") && elementCount == 3 && shard:
+			channel, count, err := decodeConfirm(reader, ssubscriptions)
+			if err != nil {
+				return fmt.Errorf("redis: sunsubscribe %w", err)
+			}
+
+			l.Lock()
+			_, requested := l.sunsubs[channel]
+			delete(l.ssubs, channel)
+			delete(l.sunsubs, channel)
+			l.Unlock()
+			delete(ssubscriptions, channel)
+			if !requested {
+				l.Func(channel, nil, ErrShardUnsubscribe)
+			}
+			l.confirm("sunsubscribe", channel, count)
+
+		case kindLen == len("punsubscribe") && elementCount == 3:
+			pattern, count, err := decodeConfirm(reader, psubscriptions)
+			if err != nil {
+				return fmt.Errorf("redis: punsubscribe %w", err)
+			}
+
+			l.Lock()
+			delete(l.psubs, pattern)
+			delete(l.punsubs, pattern)
+			l.Unlock()
+			delete(psubscriptions, pattern)
+			l.confirm("punsubscribe", pattern, count)
+		}
+	}
+}
+
+// readPayload passes the message payload to the callback. Array payloads, as
+// with the invalidation messages of client-side caching, pass each element as a
+// message. A null array passes as a single message with a nil payload.
+func (l *Listener) readPayload(reader *bufio.Reader, pattern, channel string) error {
+	if b, err := reader.Peek(1); err == nil && b[0] == '*' {
+		n, err := readArrayLen(reader)
+		if err == ErrNil {
+			l.deliver(pattern, channel, nil)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("redis: message payload array got %w", err)
+		}
+		for ; n > 0; n-- {
+			if err := l.readPayload(reader, pattern, channel); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	payloadLen, err := readBlobLen(reader)
+	if err != nil {
+		return fmt.Errorf("redis: message payload length got %w", err)
+	}
+	payloadSlice, err := reader.Peek(int(payloadLen))
+	switch err {
+	case nil:
+		l.deliver(pattern, channel, payloadSlice)
+	case bufio.ErrBufferFull:
+		l.Func(channel, nil, io.ErrShortBuffer)
+	default:
+		return fmt.Errorf("redis: message payload got %w", err)
+	}
+	if _, err := reader.Discard(int(payloadLen) + 2); err != nil {
+		return fmt.Errorf("redis: message payload got %w", err)
+	}
+	return nil
+}
+
+func (l *Listener) deliver(pattern, channel string, payload []byte) {
+	if l.MessageFunc != nil {
+		l.MessageFunc(Message{Pattern: pattern, Channel: channel, Payload: payload})
+	} else {
+		l.Func(channel, payload, nil)
+	}
+}
+
+// decodeConfirm reads the name and the subscription count of a confirmation.
+// Names are looked up in dict when not nil.
+func decodeConfirm(reader *bufio.Reader, dict map[string]string) (name string, count int64, err error) {
+	if dict != nil {
+		name, err = decodeBlobToken(reader, dict)
+		if err == errTokenDict {
+			err = nil
 		}
+	} else {
+		name, err = decodeBlobString(reader)
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("name got %w", err)
+	}
+
+	count, err = decodeInteger(reader)
+	if err != nil {
+		return "", 0, fmt.Errorf("subscription count got %w", err)
+	}
+	return name, count, nil
+}
+
+func (l *Listener) confirm(kind, name string, count int64) {
+	if l.ConfirmFunc != nil {
+		l.ConfirmFunc(Confirm{Kind: kind, Name: name, Count: count})
 	}
 }
 
 var (
-	errQUITTimeout        = errors.New("redis: QUIT expired by timeout")
-	errSUBSCRIBETimeout   = errors.New("redis: SUBSCRIBE expired by timeout")
-	errUNSUBSCRIBETimeout = errors.New("redis: UNSUBSCRIBE expired by timeout")
+	errQUITTimeout         = errors.New("redis: QUIT expired by timeout")
+	errSUBSCRIBETimeout    = errors.New("redis: SUBSCRIBE expired by timeout")
+	errUNSUBSCRIBETimeout  = errors.New("redis: UNSUBSCRIBE expired by timeout")
+	errPSUBSCRIBETimeout   = errors.New("redis: PSUBSCRIBE expired by timeout")
+	errPUNSUBSCRIBETimeout = errors.New("redis: PUNSUBSCRIBE expired by timeout")
+	errSSUBSCRIBETimeout   = errors.New("redis: SSUBSCRIBE expired by timeout")
+	errSUNSUBSCRIBETimeout = errors.New("redis: SUNSUBSCRIBE expired by timeout")
 )
 
 func (l *Listener) monitorExpiry(conn net.Conn, cancel <-chan struct{}) {
@@ -368,6 +664,30 @@ func (l *Listener) monitorExpiry(conn net.Conn, cancel <-chan struct{}) {
 					timeout = true
 				}
 			}
+			for _, timestamp := range l.psubs {
+				if !timestamp.IsZero() && timestamp.Before(expire) {
+					l.Func("", nil, errPSUBSCRIBETimeout)
+					timeout = true
+				}
+			}
+			for _, timestamp := range l.punsubs {
+				if !timestamp.IsZero() && timestamp.Before(expire) {
+					l.Func("", nil, errPUNSUBSCRIBETimeout)
+					timeout = true
+				}
+			}
+			for _, timestamp := range l.ssubs {
+				if !timestamp.IsZero() && timestamp.Before(expire) {
+					l.Func("", nil, errSSUBSCRIBETimeout)
+					timeout = true
+				}
+			}
+			for _, timestamp := range l.sunsubs {
+				if !timestamp.IsZero() && timestamp.Before(expire) {
+					l.Func("", nil, errSUNSUBSCRIBETimeout)
+					timeout = true
+				}
+			}
 			l.Unlock()
 
 			if timeout {
@@ -397,17 +717,42 @@ func (l *Listener) submit(conn net.Conn, req *request) {
 // connection will reset with automated attempts to reach the requested state.
 // Invocation with zero arguments has no effect.
 func (l *Listener) SUBSCRIBE(channels ...string) {
+	l.subscribe(l.subs, "\r\n$9\r\nSUBSCRIBE", channels)
+}
+
+// PSUBSCRIBE executes <https://redis.io/commands/psubscribe> in a persistent
+// way, like SUBSCRIBE. Messages from pattern subscriptions have the match in
+// the Message Pattern when ListenerConfig MessageFunc is set. Otherwise, Func
+// receives them like any other message. Invocation with zero arguments has no
+// effect.
+func (l *Listener) PSUBSCRIBE(patterns ...string) {
+	l.subscribe(l.psubs, "\r\n$10\r\nPSUBSCRIBE", patterns)
+}
+
+// SSUBSCRIBE executes <https://redis.io/commands/ssubscribe> in a persistent
+// way, like SUBSCRIBE. Shard channels require Redis 7.0. A Listener connects to
+// one node only, without routing per cluster shard. In a cluster, use a
+// Listener per node, and subscribe each channel on the node which serves its
+// Slot. A slot migration causes an unsubscribe by the server, as reported to
+// Func with ErrShardUnsubscribe. The Listener does not restore such
+// subscription, as the channel belongs to another node by then. Invocation
+// with zero arguments has no effect.
+func (l *Listener) SSUBSCRIBE(channels ...string) {
+	l.subscribe(l.ssubs, "\r\n$10\r\nSSUBSCRIBE", channels)
+}
+
+func (l *Listener) subscribe(subs map[string]time.Time, prefix string, names []string) {
 	var todo []string
 
 	l.Lock()
 	now := time.Now()
-	for _, name := range channels {
-		if _, ok := l.subs[name]; !ok {
+	for _, name := range names {
+		if _, ok := subs[name]; !ok {
 			if len(name) > SizeMax {
 				go l.Func(name, nil, fmt.Errorf("%w; %d byte channel name %.40q…", errProtocol, len(name), name))
 				continue
 			}
-			l.subs[name] = now
+			subs[name] = now
 			todo = append(todo, name)
 		}
 	}
@@ -415,12 +760,10 @@ func (l *Listener) SUBSCRIBE(channels ...string) {
 	l.Unlock()
 
 	if conn != nil && len(todo) != 0 {
-		r := newRequestSize(len(todo)+1, "\r\n$9\r\nSUBSCRIBE")
+		r := newRequestSize(len(todo)+1, prefix)
 		r.addStringList(todo)
 		l.submit(conn, r)
 	}
-
-	return
 }
 
 // UNSUBSCRIBE executes <https://redis.io/commands/unsubscribe> in a persistent
@@ -430,21 +773,48 @@ func (l *Listener) SUBSCRIBE(channels ...string) {
 // immediate effect. Invocation with zero arguments is not covered by the error
 // recovery due to limitations in the protocol.
 func (l *Listener) UNSUBSCRIBE(channels ...string) {
+	l.unsubscribe(l.unsubs, "\r\n$11\r\nUNSUBSCRIBE", channels)
+}
+
+// PUNSUBSCRIBE executes <https://redis.io/commands/punsubscribe> in a
+// persistent way, like UNSUBSCRIBE.
+func (l *Listener) PUNSUBSCRIBE(patterns ...string) {
+	l.unsubscribe(l.punsubs, "\r\n$12\r\nPUNSUBSCRIBE", patterns)
+}
+
+// SUNSUBSCRIBE executes <https://redis.io/commands/sunsubscribe> in a
+// persistent way, like UNSUBSCRIBE. Invocation with zero arguments applies to
+// all shard channels from SSUBSCRIBE.
+func (l *Listener) SUNSUBSCRIBE(channels ...string) {
+	if len(channels) == 0 {
+		l.Lock()
+		for name := range l.ssubs {
+			channels = append(channels, name)
+		}
+		l.Unlock()
+		if len(channels) == 0 {
+			return
+		}
+	}
+	l.unsubscribe(l.sunsubs, "\r\n$12\r\nSUNSUBSCRIBE", channels)
+}
+
+func (l *Listener) unsubscribe(unsubs map[string]time.Time, prefix string, names []string) {
 	var todo []string
 
 	l.Lock()
 	now := time.Now()
-	for _, name := range channels {
-		if _, ok := l.unsubs[name]; !ok {
-			l.unsubs[name] = now
+	for _, name := range names {
+		if _, ok := unsubs[name]; !ok {
+			unsubs[name] = now
 			todo = append(todo, name)
 		}
 	}
 	conn := l.conn
 	l.Unlock()
 
-	if conn != nil && (len(todo) != 0 || len(channels) == 0) {
-		r := newRequestSize(len(todo)+1, "\r\n$11\r\nUNSUBSCRIBE")
+	if conn != nil && (len(todo) != 0 || len(names) == 0) {
+		r := newRequestSize(len(todo)+1, prefix)
 		r.addStringList(todo)
 		l.submit(conn, r)
 	}
@@ -455,3 +825,78 @@ func isClosed(err error) bool {
 	e := new(*net.OpError)
 	return errors.As(err, e) && strings.Contains((*e).Err.Error(), "use of closed network connection")
 }
+
+// Channel patterns of <https://redis.io/topics/notifications>, for PSUBSCRIBE on
+// a Listener, in any database. Keyspace channels have the key in the name, and
+// the event as the message. Keyevent channels have the event in the name, and
+// the key as the message.
+const (
+	KeyspacePattern = "__keyspace@*__:*"
+	KeyeventPattern = "__keyevent@*__:*"
+)
+
+// KeyEvent is a keyspace notification, as parsed with ParseKeyEvent.
+type KeyEvent struct {
+	// DB is the database of the key.
+	DB int64
+
+	// Key is the subject of the event.
+	Key string
+
+	// Event is the kind of operation, e.g., "set", "del" or "expired".
+	Event string
+}
+
+// ParseKeyEvent reads a message from either a keyspace or a keyevent channel.
+// The return is false for any other channel.
+func ParseKeyEvent(channel string, message []byte) (KeyEvent, bool) {
+	var keyspace bool
+	switch {
+	case strings.HasPrefix(channel, "__keyspace@"):
+		keyspace = true
+		channel = channel[len("__keyspace@"):]
+	case strings.HasPrefix(channel, "__keyevent@"):
+		channel = channel[len("__keyevent@"):]
+	default:
+		return KeyEvent{}, false
+	}
+
+	i := strings.Index(channel, "__:")
+	if i <= 0 {
+		return KeyEvent{}, false
+	}
+	db, err := strconv.ParseInt(channel[:i], 10, 64)
+	if err != nil || db < 0 {
+		return KeyEvent{}, false
+	}
+	if keyspace {
+		return KeyEvent{DB: db, Key: channel[i+3:], Event: string(message)}, true
+	}
+	return KeyEvent{DB: db, Key: string(message), Event: channel[i+3:]}, true
+}
+
+// Flags included in the "A" alias of notify-keyspace-events.
+const keyEventAliasA = "g$lshzxetd"
+
+// EnableKeyEvents makes sure that notify-keyspace-events has each of flags,
+// with CONFIG GET, and with CONFIG SET when any of the flags is missing. Flags
+// in place remain untouched. See <https://redis.io/topics/notifications> for
+// the flags, e.g., "Kx" for expiry events on keyspace channels.
+func (c *Client) EnableKeyEvents(flags string) error {
+	config, err := c.CONFIGGET("notify-keyspace-events")
+	if err != nil {
+		return err
+	}
+	current := config["notify-keyspace-events"]
+	value := current
+	for _, flag := range flags {
+		if strings.ContainsRune(value, flag) || (strings.ContainsRune(value, 'A') && strings.ContainsRune(keyEventAliasA, flag)) {
+			continue
+		}
+		value += string(flag)
+	}
+	if value == current {
+		return nil
+	}
+	return c.CONFIGSET("notify-keyspace-events", value)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -128,6 +129,161 @@ func TestSubscribe(t *testing.T) {
 	}
 }
 
+func TestPSubscribe(t *testing.T) {
+	t.Parallel()
+
+	messages := make(chan Message, 9)
+	confirms := make(chan Confirm, 9)
+	l := NewListener(ListenerConfig{
+		Func: func(channel string, message []byte, err error) {
+			if err != nil && err != ErrClosed {
+				t.Error("Listener error:", err)
+			}
+		},
+		MessageFunc: func(m Message) {
+			m.Payload = append([]byte(nil), m.Payload...)
+			messages <- m
+		},
+		ConfirmFunc: func(c Confirm) {
+			confirms <- c
+		},
+		Addr:           testClient.Addr,
+		Password:       password,
+		CommandTimeout: time.Second,
+	})
+	defer l.Close()
+
+	await := func(kind, name string) {
+		t.Helper()
+		select {
+		case c := <-confirms:
+			if c.Kind != kind || c.Name != name {
+				t.Fatalf("got confirm %s %q, want %s %q", c.Kind, c.Name, kind, name)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s %q confirm timeout", kind, name)
+		}
+	}
+
+	prefix := randomKey("channel")
+	pattern := prefix + ".*"
+	channel := prefix + ".news"
+	l.PSUBSCRIBE(pattern)
+	await("psubscribe", pattern)
+	l.SUBSCRIBE(channel)
+	await("subscribe", channel)
+
+	if n, err := testClient.PUBLISHString(channel, "hello"); err != nil {
+		t.Fatal("publish error:", err)
+	} else if n != 2 {
+		t.Errorf("publish got %d clients, want 2", n)
+	}
+	got := map[string]string{}
+	for i := 0; i < 2; i++ {
+		select {
+		case m := <-messages:
+			if m.Channel != channel || string(m.Payload) != "hello" {
+				t.Errorf("got message %q on %q, want %q on %q", m.Payload, m.Channel, "hello", channel)
+			}
+			got[m.Pattern] = m.Channel
+		case <-time.After(time.Second):
+			t.Fatal("message timeout")
+		}
+	}
+	if _, ok := got[pattern]; !ok {
+		t.Errorf("no message with pattern %q", pattern)
+	}
+	if _, ok := got[""]; !ok {
+		t.Error("no message without pattern")
+	}
+
+	l.PUNSUBSCRIBE(pattern)
+	await("punsubscribe", pattern)
+	l.UNSUBSCRIBE(channel)
+	await("unsubscribe", channel)
+	if n, err := testClient.PUBLISHString(channel, "bye"); err != nil {
+		t.Error("publish error:", err)
+	} else if n != 0 {
+		t.Errorf("publish after unsubscribe got %d clients, want 0", n)
+	}
+}
+
+func TestSSubscribe(t *testing.T) {
+	t.Parallel()
+
+	messages := make(chan Message, 9)
+	confirms := make(chan Confirm, 9)
+	l := NewListener(ListenerConfig{
+		Func: func(channel string, message []byte, err error) {
+			if err != nil && err != ErrClosed {
+				t.Error("Listener error:", err)
+			}
+		},
+		MessageFunc: func(m Message) {
+			m.Payload = append([]byte(nil), m.Payload...)
+			messages <- m
+		},
+		ConfirmFunc: func(c Confirm) {
+			confirms <- c
+		},
+		Addr:           testClient.Addr,
+		Password:       password,
+		CommandTimeout: time.Second,
+	})
+	defer l.Close()
+
+	await := func(kind, name string) {
+		t.Helper()
+		select {
+		case c := <-confirms:
+			if c.Kind != kind || c.Name != name {
+				t.Fatalf("got confirm %s %q, want %s %q", c.Kind, c.Name, kind, name)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s %q confirm timeout", kind, name)
+		}
+	}
+	awaitMessage := func(channel, payload string) {
+		t.Helper()
+		select {
+		case m := <-messages:
+			if m.Channel != channel || string(m.Payload) != payload {
+				t.Errorf("got message %q on %q, want %q on %q", m.Payload, m.Channel, payload, channel)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("message timeout")
+		}
+	}
+
+	// same name on both kinds of channel
+	channel := randomKey("channel")
+	l.SSUBSCRIBE(channel)
+	await("ssubscribe", channel)
+	l.SUBSCRIBE(channel)
+	await("subscribe", channel)
+
+	if n, err := testClient.SPUBLISHString(channel, "sharded"); err != nil {
+		t.Fatal("SPUBLISH error:", err)
+	} else if n != 1 {
+		t.Errorf("SPUBLISH got %d clients, want 1", n)
+	}
+	awaitMessage(channel, "sharded")
+	if n, err := testClient.PUBLISHString(channel, "global"); err != nil {
+		t.Fatal("PUBLISH error:", err)
+	} else if n != 1 {
+		t.Errorf("PUBLISH got %d clients, want 1", n)
+	}
+	awaitMessage(channel, "global")
+
+	l.SUNSUBSCRIBE()
+	await("sunsubscribe", channel)
+	if n, err := testClient.SPUBLISH(channel, []byte("bye")); err != nil {
+		t.Error("SPUBLISH error:", err)
+	} else if n != 0 {
+		t.Errorf("SPUBLISH after unsubscribe got %d clients, want 0", n)
+	}
+}
+
 func TestUnsubscribe(t *testing.T) {
 	t.Parallel()
 
@@ -171,6 +327,145 @@ func TestUnsubscribeRace(t *testing.T) {
 	}
 }
 
+func TestResubscribe(t *testing.T) {
+	t.Parallel()
+
+	confirms := make(chan Confirm, 9)
+	reconnects := make(chan time.Time, 9)
+	l := NewListener(ListenerConfig{
+		Func: func(channel string, message []byte, err error) {},
+		ConfirmFunc: func(c Confirm) {
+			confirms <- c
+		},
+		ReconnectFunc: func(lost time.Time) {
+			reconnects <- lost
+		},
+		Addr:           testClient.Addr,
+		Password:       password,
+		CommandTimeout: time.Second,
+	})
+	defer l.Close()
+
+	channel := randomKey("channel")
+	pattern := randomKey("pattern") + "*"
+	l.SUBSCRIBE(channel)
+	l.PSUBSCRIBE(pattern)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-confirms:
+			break
+		case <-time.After(time.Second):
+			t.Fatal("subscribe confirm timeout")
+		}
+	}
+
+	// break the connection
+	before := time.Now()
+	l.Lock()
+	l.conn.Close()
+	l.Unlock()
+
+	select {
+	case lost := <-reconnects:
+		if lost.Before(before) {
+			t.Errorf("got loss at %s, before the connection broke at %s", lost, before)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("reconnect timeout")
+	}
+	got := make(map[string]string)
+	for i := 0; i < 2; i++ {
+		select {
+		case c := <-confirms:
+			got[c.Kind] = c.Name
+		case <-time.After(time.Second):
+			t.Fatal("resubscribe confirm timeout")
+		}
+	}
+	if got["subscribe"] != channel || got["psubscribe"] != pattern {
+		t.Errorf("got resubscribes %q, want %q and %q", got, channel, pattern)
+	}
+	if n, err := testClient.PUBLISHString(channel, "back"); err != nil {
+		t.Error("publish error:", err)
+	} else if n != 1 {
+		t.Errorf("publish after reconnect got %d clients, want 1", n)
+	}
+}
+
+func TestResubscribeShards(t *testing.T) {
+	t.Parallel()
+
+	confirms := make(chan Confirm, 9)
+	reconnects := make(chan time.Time, 9)
+	l := NewListener(ListenerConfig{
+		Func: func(channel string, message []byte, err error) {
+			if err != nil && err != ErrClosed {
+				t.Error("Listener error:", err)
+			}
+		},
+		ConfirmFunc: func(c Confirm) {
+			confirms <- c
+		},
+		ReconnectFunc: func(lost time.Time) {
+			reconnects <- lost
+		},
+		Addr:           testClient.Addr,
+		Password:       password,
+		CommandTimeout: time.Second,
+	})
+	defer l.Close()
+
+	// one subscription per slot
+	channels := []string{randomKey("{a}channel"), randomKey("{b}channel")}
+	if Slot(channels[0]) == Slot(channels[1]) {
+		t.Fatal("test channels share a slot")
+	}
+	awaitConfirms := func() {
+		t.Helper()
+		got := make(map[string]bool)
+		for range channels {
+			select {
+			case c := <-confirms:
+				if c.Kind != "ssubscribe" {
+					t.Fatalf("got confirm %s %q, want ssubscribe", c.Kind, c.Name)
+				}
+				got[c.Name] = true
+			case <-time.After(time.Second):
+				t.Fatal("ssubscribe confirm timeout")
+			}
+		}
+		for _, channel := range channels {
+			if !got[channel] {
+				t.Errorf("no ssubscribe confirm for %q", channel)
+			}
+		}
+	}
+	for _, channel := range channels {
+		l.SSUBSCRIBE(channel)
+	}
+	awaitConfirms()
+
+	// break the connection
+	l.Lock()
+	l.conn.Close()
+	l.Unlock()
+	select {
+	case <-reconnects:
+		break
+	case <-time.After(time.Second):
+		t.Fatal("reconnect timeout")
+	}
+	awaitConfirms()
+
+	for _, channel := range channels {
+		if n, err := testClient.SPUBLISHString(channel, "back"); err != nil {
+			t.Error("SPUBLISH error:", err)
+		} else if n != 1 {
+			t.Errorf("SPUBLISH on %q after reconnect got %d clients, want 1", channel, n)
+		}
+	}
+}
+
 func TestSubscriptionConcurrency(t *testing.T) {
 	t.Parallel()
 
@@ -336,3 +631,54 @@ func BenchmarkPubSub(b *testing.B) {
 		})
 	}
 }
+
+func TestParseKeyEvent(t *testing.T) {
+	golden := []struct {
+		channel, message string
+		want             KeyEvent
+	}{
+		{"__keyspace@0__:mykey", "del", KeyEvent{DB: 0, Key: "mykey", Event: "del"}},
+		{"__keyevent@3__:expired", "a:b", KeyEvent{DB: 3, Key: "a:b", Event: "expired"}},
+		{"__keyspace@12__:x__:y", "set", KeyEvent{DB: 12, Key: "x__:y", Event: "set"}},
+		{"__keyspace@0__:", "set", KeyEvent{DB: 0, Key: "", Event: "set"}},
+	}
+	for _, gold := range golden {
+		got, ok := ParseKeyEvent(gold.channel, []byte(gold.message))
+		if !ok {
+			t.Errorf("%q got false", gold.channel)
+		} else if got != gold.want {
+			t.Errorf("%q got %+v, want %+v", gold.channel, got, gold.want)
+		}
+	}
+
+	for _, channel := range []string{"news", "__keyspace@", "__keyspace@__:k", "__keyevent@x__:del", "__keyevent@-1__:del", "__keyspace@0:k"} {
+		if got, ok := ParseKeyEvent(channel, []byte("v")); ok {
+			t.Errorf("%q got %+v", channel, got)
+		}
+	}
+}
+
+func TestEnableKeyEvents(t *testing.T) {
+	config, err := testClient.CONFIGGET("notify-keyspace-events")
+	if err != nil {
+		t.Fatal("CONFIG GET error:", err)
+	}
+	defer testClient.CONFIGSET("notify-keyspace-events", config["notify-keyspace-events"])
+
+	if err := testClient.CONFIGSET("notify-keyspace-events", "Kg"); err != nil {
+		t.Fatal("CONFIG SET error:", err)
+	}
+	if err := testClient.EnableKeyEvents("Ex"); err != nil {
+		t.Fatal("EnableKeyEvents error:", err)
+	}
+	config, err = testClient.CONFIGGET("notify-keyspace-events")
+	if err != nil {
+		t.Fatal("CONFIG GET error:", err)
+	}
+	got := config["notify-keyspace-events"]
+	for _, flag := range "KEgx" {
+		if !strings.ContainsRune(got, flag) {
+			t.Errorf("notify-keyspace-events %q misses %q", got, flag)
+		}
+	}
+}
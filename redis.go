@@ -6,11 +6,14 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"net"
+	"net/url"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 // Server Limits
@@ -38,9 +41,11 @@ var ErrClosed = errors.New("redis: connection establishment closed")
 // errProtocol signals invalid RESP reception.
 var errProtocol = errors.New("redis: protocol violation")
 
-// errNull represents a null reply. This case shoud be contained internally.
-// The API represents null with nil and ok booleans conform Go convention.
-var errNull = errors.New("redis: null")
+// ErrNil represents a null reply, i.e., the absence of a value. The API
+// represents null with nil and ok booleans conform Go convention, whenever the
+// return type permits. Commands with an integer or a simple-string reply have
+// no such means, and they return ErrNil instead.
+var ErrNil = errors.New("redis: null")
 
 // errOK represents a simple string reply.
 var errOK = errors.New("redis: OK")
@@ -64,6 +69,189 @@ func (e ServerError) Prefix() string {
 	return s
 }
 
+// Server Error Kinds
+var (
+	// ErrWrongType is an operation against a key holding the wrong kind of
+	// value.
+	ErrWrongType = errors.New("redis: WRONGTYPE")
+	// ErrNoScript is an EVALSHA without a matching script on the server.
+	ErrNoScript = errors.New("redis: NOSCRIPT")
+	// ErrBusy is a script or function in execution, which blocks the server.
+	ErrBusy = errors.New("redis: BUSY")
+	// ErrLoading is a server which loads its dataset in memory still.
+	ErrLoading = errors.New("redis: LOADING")
+	// ErrReadOnly is a write against a read-only replica.
+	ErrReadOnly = errors.New("redis: READONLY")
+	// ErrOOM is a command rejected for exceeding the memory limit.
+	ErrOOM = errors.New("redis: OOM")
+	// ErrClusterDown is a cluster which can not serve requests.
+	ErrClusterDown = errors.New("redis: CLUSTERDOWN")
+	// ErrBusyGroup is a stream consumer group which exists already.
+	ErrBusyGroup = errors.New("redis: BUSYGROUP")
+	// ErrNoGroup is a stream consumer group, or its key, which does not
+	// exist.
+	ErrNoGroup = errors.New("redis: NOGROUP")
+	// ErrExecAbort is a transaction discarded because of errors during
+	// command queueing.
+	ErrExecAbort = errors.New("redis: EXECABORT")
+)
+
+var serverErrorKinds = map[error]string{
+	ErrWrongType:   "WRONGTYPE",
+	ErrNoScript:    "NOSCRIPT",
+	ErrBusy:        "BUSY",
+	ErrLoading:     "LOADING",
+	ErrReadOnly:    "READONLY",
+	ErrOOM:         "OOM",
+	ErrClusterDown: "CLUSTERDOWN",
+	ErrBusyGroup:   "BUSYGROUP",
+	ErrNoGroup:     "NOGROUP",
+	ErrExecAbort:   "EXECABORT",
+}
+
+// Is honors the errors package conventions. A ServerError matches any of the
+// Server Error Kinds by Prefix.
+func (e ServerError) Is(target error) bool {
+	prefix, ok := serverErrorKinds[target]
+	return ok && e.Prefix() == prefix
+}
+
+// As honors the errors package conventions. A MOVED or an ASK ServerError
+// converts to a *MovedError.
+func (e ServerError) As(target interface{}) bool {
+	p, ok := target.(**MovedError)
+	if !ok {
+		return false
+	}
+	moved, ok := parseMoved(e)
+	if ok {
+		*p = moved
+	}
+	return ok
+}
+
+// MovedError is a cluster redirection, as parsed from a MOVED or an ASK
+// ServerError.
+type MovedError struct {
+	// Err is the original response.
+	Err ServerError
+	// Ask is set for a one-time redirection with ASK, as opposed to the
+	// permanent reassignment of MOVED.
+	Ask bool
+	// Slot is the hash slot of the key in question.
+	Slot int64
+	// Addr is the network address of the node serving Slot.
+	Addr string
+}
+
+// Error honors the error interface.
+func (e *MovedError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap honors the errors package conventions.
+func (e *MovedError) Unwrap() error {
+	return e.Err
+}
+
+// ParseMoved returns the redirection of a MOVED or ASK error, if any.
+func parseMoved(e ServerError) (*MovedError, bool) {
+	fields := strings.Fields(string(e))
+	if len(fields) != 3 || (fields[0] != "MOVED" && fields[0] != "ASK") {
+		return nil, false
+	}
+	slot, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || fields[2] == "" {
+		return nil, false
+	}
+	return &MovedError{Err: e, Ask: fields[0] == "ASK", Slot: slot, Addr: fields[2]}, true
+}
+
+// IsMoved returns the redirection when err is a MOVED ServerError.
+func IsMoved(err error) (*MovedError, bool) {
+	var moved *MovedError
+	if !errors.As(err, &moved) || moved.Ask {
+		return nil, false
+	}
+	return moved, true
+}
+
+// IsAsk returns the redirection when err is an ASK ServerError. Use DoAsking
+// on a Client for the address to follow the redirection.
+func IsAsk(err error) (*MovedError, bool) {
+	var moved *MovedError
+	if !errors.As(err, &moved) || !moved.Ask {
+		return nil, false
+	}
+	return moved, true
+}
+
+// SlotCount is the number of hash slots in a Redis Cluster.
+const SlotCount = 16384
+
+// Slot returns the hash slot of a key in a Redis Cluster. Only the hash tag, if
+// any, applies, i.e., the content between the first '{' and the following '}',
+// when not empty. Keys with the same hash tag share a slot, which is required
+// for multi-key commands.
+func Slot(key string) uint16 {
+	if i := strings.IndexByte(key, '{'); i >= 0 {
+		if n := strings.IndexByte(key[i+1:], '}'); n > 0 {
+			key = key[i+1 : i+1+n]
+		}
+	}
+
+	// CRC-16/XMODEM
+	var crc uint16
+	for i := 0; i < len(key); i++ {
+		crc = crc<<8 ^ crc16Table[byte(crc>>8)^key[i]]
+	}
+	return crc % SlotCount
+}
+
+var crc16Table = func() (table [256]uint16) {
+	for i := range table {
+		crc := uint16(i) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return
+}()
+
+// IsWrongType returns whether err is a WRONGTYPE ServerError, i.e., an
+// operation against a key holding the wrong kind of value.
+func IsWrongType(err error) bool {
+	return errors.Is(err, ErrWrongType)
+}
+
+// IsNoScript returns whether err is a NOSCRIPT ServerError, i.e., EVALSHA
+// without a matching script cached on the server.
+func IsNoScript(err error) bool {
+	return errors.Is(err, ErrNoScript)
+}
+
+// IsLoading returns whether err is a LOADING ServerError, i.e., the server is
+// loading its dataset in memory.
+func IsLoading(err error) bool {
+	return errors.Is(err, ErrLoading)
+}
+
+// IsReadOnly returns whether err is a READONLY ServerError, i.e., a write
+// against a read-only replica.
+func IsReadOnly(err error) bool {
+	return errors.Is(err, ErrReadOnly)
+}
+
+// IsClusterDown returns whether err is a CLUSTERDOWN ServerError.
+func IsClusterDown(err error) bool {
+	return errors.Is(err, ErrClusterDown)
+}
+
 func isUnixAddr(s string) bool {
 	return len(s) != 0 && s[0] == '/'
 }
@@ -86,6 +274,102 @@ func normalizeAddr(s string) string {
 	return net.JoinHostPort(host, port)
 }
 
+// URLConfig has the settings from a connection URL.
+type urlConfig struct {
+	Addr           string
+	User           string
+	Password       []byte
+	DB             int64
+	TLS            bool
+	DialTimeout    time.Duration
+	CommandTimeout time.Duration
+}
+
+// ParseURL reads a redis://, rediss:// or unix:// URL. Errors don't include the
+// URL, as it may contain a password.
+func parseURL(s string) (urlConfig, error) {
+	var c urlConfig
+	u, err := url.Parse(s)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return c, fmt.Errorf("redis: malformed URL; %w", err)
+	}
+	if u.Opaque != "" || u.Fragment != "" {
+		return c, errors.New("redis: malformed URL")
+	}
+
+	if u.User != nil {
+		password, ok := u.User.Password()
+		if !ok {
+			return c, errors.New("redis: URL has user name without password")
+		}
+		c.User = u.User.Username()
+		c.Password = []byte(password)
+	}
+
+	switch u.Scheme {
+	case "redis", "rediss":
+		c.TLS = u.Scheme == "rediss"
+		c.Addr = normalizeAddr(u.Host)
+		if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+			c.DB, err = parseURLDB(db)
+			if err != nil {
+				return c, err
+			}
+		}
+
+	case "unix":
+		if u.Host != "" || !isUnixAddr(u.Path) {
+			return c, errors.New("redis: URL with unix scheme needs an absolute path, and no host")
+		}
+		c.Addr = normalizeAddr(u.Path)
+
+	default:
+		return c, fmt.Errorf("redis: URL scheme %q not supported", u.Scheme)
+	}
+
+	for name, values := range u.Query() {
+		if len(values) != 1 {
+			return c, fmt.Errorf("redis: URL query parameter %q repeated", name)
+		}
+		switch name {
+		case "db":
+			if u.Scheme != "unix" {
+				return c, errors.New("redis: URL query parameter \"db\" not supported with " + u.Scheme + " scheme")
+			}
+			c.DB, err = parseURLDB(values[0])
+		case "dial_timeout":
+			c.DialTimeout, err = parseURLTimeout(name, values[0])
+		case "command_timeout":
+			c.CommandTimeout, err = parseURLTimeout(name, values[0])
+		default:
+			return c, fmt.Errorf("redis: URL query parameter %q not supported", name)
+		}
+		if err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+func parseURLDB(s string) (int64, error) {
+	db, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || db < 0 {
+		return 0, fmt.Errorf("redis: URL has malformed database number %q", s)
+	}
+	return db, nil
+}
+
+func parseURLTimeout(name, s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("redis: URL query parameter %q has malformed duration %q", name, s)
+	}
+	return d, nil
+}
+
 // ParseInt assumes a valid decimal string—no validation.
 // The empty string returns zero.
 func ParseInt(bytes []byte) int64 {
@@ -113,6 +397,28 @@ func ParseInt(bytes []byte) int64 {
 	return value
 }
 
+// ParseFloat reads a floating-point number in the formats of Redis, which
+// include exponent notation, "inf" and "-inf". Unlike ParseInt, the input is
+// validated. NaN, hexadecimal notation and values out of range are errors.
+func ParseFloat(bytes []byte) (float64, error) {
+	for _, b := range bytes {
+		if b == 'x' || b == 'X' || b == '_' {
+			return 0, fmt.Errorf("redis: malformed floating-point %q", bytes)
+		}
+	}
+	f, err := strconv.ParseFloat(string(bytes), 64)
+	if err != nil || f != f {
+		return 0, fmt.Errorf("redis: malformed floating-point %q", bytes)
+	}
+	return f, nil
+}
+
+// FormatFloat returns the shortest representation which parses back to f,
+// such that increments don't drift from what the caller passed.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
 func decodeOK(r *bufio.Reader) error {
 	line, err := readLF(r)
 	switch {
@@ -122,12 +428,27 @@ func decodeOK(r *bufio.Reader) error {
 		return nil
 	case len(line) == 5 && line[0] == '$' && line[1] == '-' && line[2] == '1',
 		len(line) == 3 && line[0] == '_':
-		return errNull
+		return ErrNil
 	default:
 		return readError(r, line, "OK")
 	}
 }
 
+func decodeSimpleString(r *bufio.Reader) (string, error) {
+	line, err := readLF(r)
+	switch {
+	case err != nil:
+		return "", err
+	case len(line) > 2 && line[0] == '+':
+		return string(line[1 : len(line)-2]), nil
+	case len(line) == 5 && line[0] == '$' && line[1] == '-' && line[2] == '1',
+		len(line) == 3 && line[0] == '_':
+		return "", ErrNil
+	default:
+		return "", readError(r, line, "simple string")
+	}
+}
+
 func decodeInteger(r *bufio.Reader) (int64, error) {
 	line, err := readLF(r)
 	switch {
@@ -135,6 +456,15 @@ func decodeInteger(r *bufio.Reader) (int64, error) {
 		return 0, err
 	case len(line) > 3 && line[0] == ':':
 		return ParseInt(line[1 : len(line)-2]), nil
+	case len(line) == 4 && line[0] == '#':
+		// RESP3 boolean
+		if line[1] == 't' {
+			return 1, nil
+		}
+		return 0, nil
+	case len(line) == 5 && line[0] == '$' && line[1] == '-' && line[2] == '1',
+		len(line) == 3 && line[0] == '_':
+		return 0, ErrNil
 	default:
 		return 0, readError(r, line, "integer")
 	}
@@ -156,6 +486,28 @@ func decodeBlobString(r *bufio.Reader) (string, error) {
 	return readStringSize(r, l)
 }
 
+// DecodeBlobInto copies the payload into dst. When dst is too small, then the
+// payload is skipped, and the return is the payload size with io.ErrShortBuffer.
+func decodeBlobInto(r *bufio.Reader, dst []byte) (int, error) {
+	l, err := readBlobLen(r)
+	if err != nil {
+		return 0, err
+	}
+	if l > len(dst) {
+		if _, err := r.Discard(l + 2); err != nil {
+			return 0, err
+		}
+		return l, io.ErrShortBuffer
+	}
+
+	if _, err := io.ReadFull(r, dst[:l]); err != nil {
+		return 0, err
+	}
+	// skip CRLF
+	_, err = r.Discard(2)
+	return l, err
+}
+
 var errTokenDict = errors.New("unknown token")
 
 func decodeBlobToken(r *bufio.Reader, dict map[string]string) (string, error) {
@@ -186,39 +538,1063 @@ func decodeBytesArray(r *bufio.Reader) ([][]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	array := make([][]byte, 0, l)
+	array := make([][]byte, 0, l)
+
+	for len(array) < cap(array) {
+		bytes, err := decodeBlobBytes(r)
+		switch err {
+		case nil:
+			array = append(array, bytes)
+		case ErrNil:
+			array = append(array, nil)
+		default:
+			return nil, err
+		}
+	}
+	return array, nil
+}
+
+// DecodeBytesArrayFunc passes each element of an array to fn, without holding
+// the array in memory. Elements which fit the read buffer pass as a slice of
+// the buffer, i.e., without allocation. Null elements pass as nil. The array is
+// read completely, regardless of any error from fn, which returns as fnErr.
+func decodeBytesArrayFunc(r *bufio.Reader, fn func([]byte) error) (fnErr, err error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return nil, err
+	}
+	return decodeBytesArrayFuncSize(r, l, fn)
+}
+
+// DecodeBytesArrayFuncSize is like decodeBytesArrayFunc, with the array length
+// read already.
+func decodeBytesArrayFuncSize(r *bufio.Reader, l int64, fn func([]byte) error) (fnErr, err error) {
+	for ; l > 0; l-- {
+		size, err := readBlobLen(r)
+		if err == ErrNil {
+			if fnErr == nil {
+				fnErr = fn(nil)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if fnErr != nil {
+			if _, err := r.Discard(size + 2); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		bytes, err := r.Peek(size)
+		switch err {
+		case nil:
+			fnErr = fn(bytes)
+			_, err = r.Discard(size + 2)
+		case bufio.ErrBufferFull:
+			bytes, err = readBytesSize(r, size)
+			if err == nil {
+				fnErr = fn(bytes)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return fnErr, nil
+}
+
+// DecodeStringBytesPairsFunc is like decodeBytesArrayFunc, with each pair of
+// elements, as with RESP2 arrays or RESP3 maps.
+func decodeStringBytesPairsFunc(r *bufio.Reader, fn func(string, []byte) error) (fnErr, err error) {
+	l, err := readMapLen(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var field string
+	var odd bool
+	return decodeBytesArrayFuncSize(r, l*2, func(bytes []byte) error {
+		odd = !odd
+		if odd {
+			field = string(bytes)
+			return nil
+		}
+		return fn(field, bytes)
+	})
+}
+
+func decodeStringArray(r *bufio.Reader) ([]string, error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return nil, err
+	}
+	array := make([]string, 0, l)
+
+	for len(array) < cap(array) {
+		s, err := decodeBlobString(r)
+		switch err {
+		case nil:
+			array = append(array, s)
+		case ErrNil:
+			array = append(array, "")
+		default:
+			return nil, err
+		}
+	}
+	return array, nil
+}
+
+// DecodeIntegerArray reads an array of integers. Null elements are a protocol
+// error.
+func decodeIntegerArray(r *bufio.Reader) ([]int64, error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return nil, err
+	}
+	array := make([]int64, 0, l)
+
+	for len(array) < cap(array) {
+		integer, err := decodeInteger(r)
+		switch err {
+		case nil:
+			array = append(array, integer)
+		case ErrNil:
+			return nil, fmt.Errorf("%w; null in integer array", errProtocol)
+		default:
+			return nil, err
+		}
+	}
+	return array, nil
+}
+
+// DecodeStringArrayOK is like decodeStringArray, with the presence of each
+// element in ok. Null elements are an empty string with false.
+func decodeStringArrayOK(r *bufio.Reader) ([]string, []bool, error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	array := make([]string, 0, l)
+	ok := make([]bool, 0, l)
+
+	for len(array) < cap(array) {
+		s, err := decodeBlobString(r)
+		switch err {
+		case nil:
+			array = append(array, s)
+			ok = append(ok, true)
+		case ErrNil:
+			array = append(array, "")
+			ok = append(ok, false)
+		default:
+			return nil, nil, err
+		}
+	}
+	return array, ok, nil
+}
+
+// DecodeBytesMap reads an array of field–value pairs, or a RESP3 map.
+func decodeBytesMap(r *bufio.Reader) (map[string][]byte, error) {
+	n, err := readMapLen(r)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string][]byte, n)
+
+	for ; n > 0; n-- {
+		field, err := decodeBlobString(r)
+		if err != nil {
+			return nil, err
+		}
+		value, err := decodeBlobBytes(r)
+		if err != nil && err != ErrNil {
+			return nil, err
+		}
+		m[field] = value
+	}
+	return m, nil
+}
+
+// DecodeStringMap reads an array of field–value pairs, or a RESP3 map.
+func decodeStringMap(r *bufio.Reader) (map[string]string, error) {
+	n, err := readMapLen(r)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string, n)
+
+	for ; n > 0; n-- {
+		field, err := decodeBlobString(r)
+		if err != nil {
+			return nil, err
+		}
+		value, err := decodeBlobString(r)
+		if err != nil && err != ErrNil {
+			return nil, err
+		}
+		m[field] = value
+	}
+	return m, nil
+}
+
+// DecodeStringBytesPairs reads an array of field–value pairs, in order.
+func decodeStringBytesPairs(r *bufio.Reader) ([]string, [][]byte, error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	// RESP3 has an array per pair
+	var nested bool
+	if l != 0 {
+		b, err := r.Peek(1)
+		if err != nil {
+			return nil, nil, err
+		}
+		nested = b[0] == '*'
+	}
+	n := l
+	if !nested {
+		if l&1 != 0 {
+			return nil, nil, fmt.Errorf("%w; pairs with %d elements", errProtocol, l)
+		}
+		n = l / 2
+	}
+	fields := make([]string, 0, n)
+	values := make([][]byte, 0, n)
+
+	for len(fields) < cap(fields) {
+		if nested {
+			l, err := readArrayLen(r)
+			if err != nil {
+				return nil, nil, err
+			}
+			if l != 2 {
+				return nil, nil, fmt.Errorf("%w; pair with %d elements", errProtocol, l)
+			}
+		}
+		field, err := decodeBlobString(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		value, err := decodeBlobBytes(r)
+		if err != nil && err != ErrNil {
+			return nil, nil, err
+		}
+		fields = append(fields, field)
+		values = append(values, value)
+	}
+	return fields, values, nil
+}
+
+// DecodeFloat reads either a blob string, or a RESP3 double.
+func decodeFloat(r *bufio.Reader) (float64, error) {
+	if b, err := r.Peek(1); err == nil && b[0] == ',' {
+		line, err := readLF(r)
+		if err != nil {
+			return 0, err
+		}
+		if len(line) < 4 {
+			return 0, readError(r, line, "double")
+		}
+		f, err := ParseFloat(line[1 : len(line)-2])
+		if err != nil {
+			return 0, fmt.Errorf("%w; double %q", errProtocol, line[1:len(line)-2])
+		}
+		return f, nil
+	}
+
+	bytes, err := decodeBlobBytes(r)
+	if err != nil {
+		return 0, err
+	}
+	f, err := ParseFloat(bytes)
+	if err != nil {
+		return 0, fmt.Errorf("%w; floating-point %q", errProtocol, bytes)
+	}
+	return f, nil
+}
+
+// DecodeZMembers reads an array of member–score pairs. RESP3 has an array per
+// pair, while RESP2 has the pairs in sequence.
+func decodeZMembers(r *bufio.Reader) ([]ZMember, error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return nil, err
+	}
+	var nested bool
+	if l != 0 {
+		b, err := r.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		nested = b[0] == '*'
+	}
+	n := l
+	if !nested {
+		if l&1 != 0 {
+			return nil, fmt.Errorf("%w; member–score pairs with %d elements", errProtocol, l)
+		}
+		n = l / 2
+	}
+	members := make([]ZMember, 0, n)
+
+	for len(members) < cap(members) {
+		if nested {
+			l, err := readArrayLen(r)
+			if err != nil {
+				return nil, err
+			}
+			if l != 2 {
+				return nil, fmt.Errorf("%w; member–score pair with %d elements", errProtocol, l)
+			}
+		}
+		member, err := decodeBlobBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		score, err := decodeFloat(r)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, ZMember{Member: member, Score: score})
+	}
+	return members, nil
+}
+
+// DecodeZPop reads a key, a member, and a score.
+func decodeZPop(r *bufio.Reader) (string, ZMember, error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return "", ZMember{}, err
+	}
+	if l != 3 {
+		return "", ZMember{}, fmt.Errorf("%w; pop with %d elements", errProtocol, l)
+	}
+	key, err := decodeBlobString(r)
+	if err != nil {
+		return "", ZMember{}, err
+	}
+	member, err := decodeBlobBytes(r)
+	if err != nil {
+		return "", ZMember{}, err
+	}
+	score, err := decodeFloat(r)
+	if err != nil {
+		return "", ZMember{}, err
+	}
+	return key, ZMember{Member: member, Score: score}, nil
+}
+
+// DecodeZMPop reads a key with an array of member–score arrays.
+func decodeZMPop(r *bufio.Reader) (string, []ZMember, error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return "", nil, err
+	}
+	if l != 2 {
+		return "", nil, fmt.Errorf("%w; pop with %d elements", errProtocol, l)
+	}
+	key, err := decodeBlobString(r)
+	if err != nil {
+		return "", nil, err
+	}
+	l, err = readArrayLen(r)
+	if err != nil {
+		return "", nil, err
+	}
+	members := make([]ZMember, 0, l)
+
+	for len(members) < cap(members) {
+		l, err := readArrayLen(r)
+		if err != nil {
+			return "", nil, err
+		}
+		if l != 2 {
+			return "", nil, fmt.Errorf("%w; member–score pair with %d elements", errProtocol, l)
+		}
+		member, err := decodeBlobBytes(r)
+		if err != nil {
+			return "", nil, err
+		}
+		score, err := decodeFloat(r)
+		if err != nil {
+			return "", nil, err
+		}
+		members = append(members, ZMember{Member: member, Score: score})
+	}
+	return key, members, nil
+}
+
+// DecodeLMPop reads a key with an array of values.
+func decodeLMPop(r *bufio.Reader) (string, [][]byte, error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return "", nil, err
+	}
+	if l != 2 {
+		return "", nil, fmt.Errorf("%w; pop with %d elements", errProtocol, l)
+	}
+	key, err := decodeBlobString(r)
+	if err != nil {
+		return "", nil, err
+	}
+	values, err := decodeBytesArray(r)
+	if err != nil {
+		return "", nil, err
+	}
+	return key, values, nil
+}
+
+// DecodeGeoResults reads the GEOSEARCH reply. Each match is either a plain
+// blob with the name, or an array with the name followed by the distance, the
+// hash, and the coordinates (in that order) as far as requested.
+func decodeGeoResults(r *bufio.Reader, q *GeoSearch) ([]GeoResult, error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]GeoResult, 0, l)
+
+	nested := q.WithCoord || q.WithDist || q.WithHash
+	for len(results) < cap(results) {
+		if !nested {
+			name, err := decodeBlobString(r)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, GeoResult{Name: name})
+			continue
+		}
+
+		if _, err := readArrayLen(r); err != nil {
+			return nil, err
+		}
+		var result GeoResult
+		result.Name, err = decodeBlobString(r)
+		if err != nil {
+			return nil, err
+		}
+		if q.WithDist {
+			result.Dist, err = decodeFloat(r)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if q.WithHash {
+			result.Hash, err = decodeInteger(r)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if q.WithCoord {
+			l, err := readArrayLen(r)
+			if err != nil {
+				return nil, err
+			}
+			if l != 2 {
+				return nil, fmt.Errorf("%w; coordinates with %d elements", errProtocol, l)
+			}
+			result.Lon, err = decodeFloat(r)
+			if err != nil {
+				return nil, err
+			}
+			result.Lat, err = decodeFloat(r)
+			if err != nil {
+				return nil, err
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func decodeStreamEntries(r *bufio.Reader) ([]StreamEntry, error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]StreamEntry, 0, l)
+
+	for len(entries) < cap(entries) {
+		entry, err := decodeStreamEntry(r)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func decodeStreamEntry(r *bufio.Reader) (StreamEntry, error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return StreamEntry{}, err
+	}
+	if l != 2 {
+		return StreamEntry{}, fmt.Errorf("%w; stream entry with %d elements", errProtocol, l)
+	}
+	id, err := decodeBlobString(r)
+	if err != nil {
+		return StreamEntry{}, err
+	}
+	fields, err := decodeStringArray(r)
+	if err != nil && err != ErrNil {
+		return StreamEntry{}, err
+	}
+	return StreamEntry{ID: id, Fields: fields}, nil
+}
+
+func decodeStreams(r *bufio.Reader) ([]Stream, error) {
+	// RESP3 has a map from key to entries
+	b, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	isMap := b[0] == '%'
+
+	l, err := readArrayLen(r)
+	if err != nil {
+		return nil, err
+	}
+	if isMap {
+		l /= 2
+	}
+	streams := make([]Stream, 0, l)
+
+	for len(streams) < cap(streams) {
+		if !isMap {
+			l, err := readArrayLen(r)
+			if err != nil {
+				return nil, err
+			}
+			if l != 2 {
+				return nil, fmt.Errorf("%w; stream with %d elements", errProtocol, l)
+			}
+		}
+		key, err := decodeBlobString(r)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := decodeStreamEntries(r)
+		if err != nil {
+			return nil, err
+		}
+		streams = append(streams, Stream{Key: key, Entries: entries})
+	}
+	return streams, nil
+}
+
+func decodeXPendingSummary(r *bufio.Reader) (XPendingSummary, error) {
+	var summary XPendingSummary
+	l, err := readArrayLen(r)
+	if err != nil {
+		return summary, err
+	}
+	if l != 4 {
+		return summary, fmt.Errorf("%w; pending summary with %d elements", errProtocol, l)
+	}
+	summary.Count, err = decodeInteger(r)
+	if err != nil {
+		return summary, err
+	}
+	// boundaries and consumers are null without entries
+	summary.Lowest, err = decodeBlobString(r)
+	if err != nil && err != ErrNil {
+		return summary, err
+	}
+	summary.Highest, err = decodeBlobString(r)
+	if err != nil && err != ErrNil {
+		return summary, err
+	}
+	l, err = readArrayLen(r)
+	if err == ErrNil {
+		return summary, nil
+	}
+	if err != nil {
+		return summary, err
+	}
+	summary.Consumers = make([]XPendingConsumer, 0, l)
+
+	for len(summary.Consumers) < cap(summary.Consumers) {
+		l, err := readArrayLen(r)
+		if err != nil {
+			return summary, err
+		}
+		if l != 2 {
+			return summary, fmt.Errorf("%w; pending consumer with %d elements", errProtocol, l)
+		}
+		name, err := decodeBlobString(r)
+		if err != nil {
+			return summary, err
+		}
+		count, err := decodeBlobString(r)
+		if err != nil {
+			return summary, err
+		}
+		n, err := strconv.ParseInt(count, 10, 64)
+		if err != nil {
+			return summary, fmt.Errorf("%w; pending count %q", errProtocol, count)
+		}
+		summary.Consumers = append(summary.Consumers, XPendingConsumer{Name: name, Count: n})
+	}
+	return summary, nil
+}
+
+func decodeXPendingEntries(r *bufio.Reader) ([]XPendingEntry, error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]XPendingEntry, 0, l)
+
+	for len(entries) < cap(entries) {
+		l, err := readArrayLen(r)
+		if err != nil {
+			return nil, err
+		}
+		if l != 4 {
+			return nil, fmt.Errorf("%w; pending entry with %d elements", errProtocol, l)
+		}
+		var e XPendingEntry
+		e.ID, err = decodeBlobString(r)
+		if err != nil {
+			return nil, err
+		}
+		e.Consumer, err = decodeBlobString(r)
+		if err != nil {
+			return nil, err
+		}
+		idle, err := decodeInteger(r)
+		if err != nil {
+			return nil, err
+		}
+		e.Idle = time.Duration(idle) * time.Millisecond
+		e.Deliveries, err = decodeInteger(r)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// DecodeFieldMap reads a map, or an array of field–value pairs, and it passes
+// each field name to decodeValue, which must read the value in full.
+func decodeFieldMap(r *bufio.Reader, decodeValue func(field string) error) error {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return err
+	}
+	if l&1 != 0 {
+		return fmt.Errorf("%w; field map with %d elements", errProtocol, l)
+	}
+	for ; l > 0; l -= 2 {
+		field, err := decodeBlobString(r)
+		if err != nil {
+			return err
+		}
+		if err := decodeValue(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeFieldInteger reads an integer value, with -1 for null.
+func decodeFieldInteger(r *bufio.Reader) (int64, error) {
+	v, err := decodeInteger(r)
+	if err == ErrNil {
+		return -1, nil
+	}
+	return v, err
+}
+
+// SkipAny reads a value of any type, including errors.
+func skipAny(r *bufio.Reader) error {
+	_, err := decodeAny(r)
+	if _, ok := err.(ServerError); ok {
+		return nil
+	}
+	return err
+}
+
+func decodeXInfoStream(r *bufio.Reader) (XInfoStream, error) {
+	var info XInfoStream
+	err := decodeFieldMap(r, func(field string) error {
+		var err error
+		switch field {
+		case "length":
+			info.Length, err = decodeInteger(r)
+		case "last-generated-id":
+			info.LastGeneratedID, err = decodeBlobString(r)
+		case "groups":
+			info.Groups, err = decodeInteger(r)
+		case "first-entry", "last-entry":
+			var entry StreamEntry
+			entry, err = decodeStreamEntry(r)
+			switch {
+			case err == ErrNil:
+				err = nil // empty stream
+			case err == nil && field == "first-entry":
+				info.FirstEntry = &entry
+			case err == nil:
+				info.LastEntry = &entry
+			}
+		default:
+			err = skipAny(r)
+		}
+		return err
+	})
+	return info, err
+}
+
+func decodeXInfoGroups(r *bufio.Reader) ([]XInfoGroup, error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]XInfoGroup, l)
+
+	for i := range groups {
+		g := &groups[i]
+		g.EntriesRead, g.Lag = -1, -1
+		err := decodeFieldMap(r, func(field string) error {
+			var err error
+			switch field {
+			case "name":
+				g.Name, err = decodeBlobString(r)
+			case "consumers":
+				g.Consumers, err = decodeInteger(r)
+			case "pending":
+				g.Pending, err = decodeInteger(r)
+			case "last-delivered-id":
+				g.LastDeliveredID, err = decodeBlobString(r)
+			case "entries-read":
+				g.EntriesRead, err = decodeFieldInteger(r)
+			case "lag":
+				g.Lag, err = decodeFieldInteger(r)
+			default:
+				err = skipAny(r)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return groups, nil
+}
+
+func decodeXInfoConsumers(r *bufio.Reader) ([]XInfoConsumer, error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return nil, err
+	}
+	consumers := make([]XInfoConsumer, l)
+
+	for i := range consumers {
+		c := &consumers[i]
+		c.Inactive = -1
+		err := decodeFieldMap(r, func(field string) error {
+			var err error
+			var ms int64
+			switch field {
+			case "name":
+				c.Name, err = decodeBlobString(r)
+			case "pending":
+				c.Pending, err = decodeInteger(r)
+			case "idle":
+				ms, err = decodeInteger(r)
+				c.Idle = time.Duration(ms) * time.Millisecond
+			case "inactive":
+				ms, err = decodeFieldInteger(r)
+				if ms >= 0 {
+					c.Inactive = time.Duration(ms) * time.Millisecond
+				}
+			default:
+				err = skipAny(r)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return consumers, nil
+}
+
+func decodeFunctionLibraries(r *bufio.Reader) ([]FunctionLibrary, error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return nil, err
+	}
+	libs := make([]FunctionLibrary, l)
+
+	for i := range libs {
+		lib := &libs[i]
+		err := decodeFieldMap(r, func(field string) error {
+			var err error
+			switch field {
+			case "library_name":
+				lib.Name, err = decodeBlobString(r)
+			case "engine":
+				lib.Engine, err = decodeBlobString(r)
+			case "library_code":
+				lib.Code, err = decodeBlobString(r)
+			case "functions":
+				lib.Functions, err = decodeFunctionInfos(r)
+			default:
+				err = skipAny(r)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return libs, nil
+}
+
+func decodeFunctionInfos(r *bufio.Reader) ([]FunctionInfo, error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]FunctionInfo, l)
+
+	for i := range infos {
+		info := &infos[i]
+		err := decodeFieldMap(r, func(field string) error {
+			var err error
+			switch field {
+			case "name":
+				info.Name, err = decodeBlobString(r)
+			case "description":
+				info.Description, err = decodeBlobString(r)
+				if err == ErrNil {
+					err = nil
+				}
+			case "flags":
+				info.Flags, err = decodeFlags(r)
+			default:
+				err = skipAny(r)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return infos, nil
+}
+
+// DecodeFlags reads an array (or set) of simple strings or blobs.
+func decodeFlags(r *bufio.Reader) ([]string, error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return nil, err
+	}
+	flags := make([]string, 0, l)
+	for len(flags) < cap(flags) {
+		v, err := decodeAny(r)
+		if err != nil {
+			return nil, err
+		}
+		switch v := v.(type) {
+		case string:
+			flags = append(flags, v)
+		case []byte:
+			flags = append(flags, string(v))
+		default:
+			return nil, fmt.Errorf("%w; flag of type %T", errProtocol, v)
+		}
+	}
+	return flags, nil
+}
+
+func decodeXAutoClaim(r *bufio.Reader) (next string, entries []StreamEntry, deleted []string, err error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	// deleted entries are reported since Redis 7.0
+	if l != 2 && l != 3 {
+		return "", nil, nil, fmt.Errorf("%w; auto claim with %d elements", errProtocol, l)
+	}
+	next, err = decodeBlobString(r)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	entries, err = decodeStreamEntries(r)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if l == 3 {
+		deleted, err = decodeStringArray(r)
+		if err != nil {
+			return "", nil, nil, err
+		}
+	}
+	return next, entries, deleted, nil
+}
+
+// DecodeCommandSpecs reads the COMMAND INFO reply. Each command has its name,
+// the arity, the flags, and the key positions. Any elements after those, such
+// as the ACL categories and the key specifications of Redis 7, are discarded.
+// Null entries, for unknown commands, remain as a zero CommandSpec.
+func decodeCommandSpecs(r *bufio.Reader) ([]CommandSpec, error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return nil, err
+	}
+	specs := make([]CommandSpec, l)
 
-	for len(array) < cap(array) {
-		bytes, err := decodeBlobBytes(r)
-		switch err {
-		case nil:
-			array = append(array, bytes)
-		case errNull:
-			array = append(array, nil)
-		default:
+	for i := range specs {
+		l, err := readArrayLen(r)
+		if err == ErrNil {
+			continue // unknown command
+		}
+		if err != nil {
 			return nil, err
 		}
-	}
-	return array, nil
-}
+		if l < 6 {
+			return nil, fmt.Errorf("%w; command info with %d elements", errProtocol, l)
+		}
 
-func decodeStringArray(r *bufio.Reader) ([]string, error) {
-	l, err := readArrayLen(r)
+		spec := &specs[i]
+		spec.Name, err = decodeBlobString(r)
+		if err != nil {
+			return nil, err
+		}
+		spec.Arity, err = decodeInteger(r)
+		if err != nil {
+			return nil, err
+		}
+		n, err := readArrayLen(r)
+		if err != nil {
+			return nil, err
+		}
+		spec.Flags = make([]string, 0, n)
+		for len(spec.Flags) < cap(spec.Flags) {
+			flag, err := decodeSimpleString(r)
+			if err != nil {
+				return nil, err
+			}
+			spec.Flags = append(spec.Flags, flag)
+		}
+		for _, p := range []*int64{&spec.FirstKey, &spec.LastKey, &spec.KeyStep} {
+			*p, err = decodeInteger(r)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for ; l > 6; l-- {
+			if _, err := decodeAny(r); err != nil {
+				if _, ok := err.(ServerError); !ok {
+					return nil, err
+				}
+			}
+		}
+	}
+	return specs, nil
+}
+
+// DecodeAny reads a reply of any type. The return is either nil for null, an
+// int64 for integers, a string for simple strings, a []byte for blobs, or an
+// []interface{} for arrays. Array elements may also be a ServerError, as error
+// replies within arrays don't fail the command as a whole. RESP3 adds float64
+// for doubles, and bool for booleans. RESP3 big numbers return as a string in
+// decimal notation, verbatim strings as a []byte without the format, sets as
+// an []interface{}, and maps as an []interface{} with field–value pairs in
+// sequence.
+func decodeAny(r *bufio.Reader) (interface{}, error) {
+	line, err := readLF(r)
 	if err != nil {
 		return nil, err
 	}
-	array := make([]string, 0, l)
 
+	if len(line) > 2 {
+		switch line[0] {
+		case '+', '(':
+			return string(line[1 : len(line)-2]), nil
+		case ':':
+			if len(line) > 3 {
+				return ParseInt(line[1 : len(line)-2]), nil
+			}
+		case '_':
+			return nil, nil
+		case ',':
+			if len(line) > 3 {
+				f, err := strconv.ParseFloat(string(line[1:len(line)-2]), 64)
+				if err != nil {
+					return nil, fmt.Errorf("%w; double %.40q", errProtocol, line)
+				}
+				return f, nil
+			}
+		case '#':
+			if len(line) == 4 {
+				return line[1] == 't', nil
+			}
+		case '=':
+			if len(line) > 3 {
+				l := ParseInt(line[1 : len(line)-2])
+				if l >= 4 && l <= SizeMax {
+					if _, err := r.Discard(4); err != nil {
+						return nil, err
+					}
+					return readBytesSize(r, int(l-4))
+				}
+			}
+		case '~', '%':
+			if len(line) > 3 {
+				l := ParseInt(line[1 : len(line)-2])
+				if line[0] == '%' {
+					l *= 2
+				}
+				if l >= 0 && l <= ElementMax {
+					return decodeAnyArray(r, l)
+				}
+			}
+		case '$':
+			if len(line) > 3 {
+				l := ParseInt(line[1 : len(line)-2])
+				switch {
+				case l >= 0 && l <= SizeMax:
+					return readBytesSize(r, int(l))
+				case l == -1:
+					return nil, nil
+				}
+			}
+		case '*':
+			if len(line) > 3 {
+				l := ParseInt(line[1 : len(line)-2])
+				switch {
+				case l >= 0 && l <= ElementMax:
+					return decodeAnyArray(r, l)
+				case l == -1:
+					return nil, nil
+				}
+			}
+		}
+	}
+	return nil, readError(r, line, "reply")
+}
+
+func decodeAnyArray(r *bufio.Reader, size int64) ([]interface{}, error) {
+	array := make([]interface{}, 0, size)
 	for len(array) < cap(array) {
-		s, err := decodeBlobString(r)
-		switch err {
-		case nil:
-			array = append(array, s)
-		case errNull:
-			array = append(array, "")
-		default:
-			return nil, err
+		v, err := decodeAny(r)
+		if err != nil {
+			e, ok := err.(ServerError)
+			if !ok {
+				return nil, err
+			}
+			v = e
 		}
+		array = append(array, v)
 	}
 	return array, nil
 }
@@ -240,16 +1616,30 @@ func readBlobLen(r *bufio.Reader) (int, error) {
 		return 0, err
 	}
 
-	if len(line) > 3 && line[0] == '$' {
+	if len(line) > 3 {
 		l := ParseInt(line[1 : len(line)-2])
-		switch {
-		case l >= 0 && l <= SizeMax:
-			return int(l), nil
-		case l == -1:
-			return 0, errNull
+		switch line[0] {
+		case '$':
+			switch {
+			case l >= 0 && l <= SizeMax:
+				return int(l), nil
+			case l == -1:
+				return 0, ErrNil
+			}
+		case '=':
+			// RESP3 verbatim string starts with the format, like "txt:"
+			if l >= 4 && l <= SizeMax {
+				if _, err := r.Discard(4); err != nil {
+					return 0, err
+				}
+				return int(l - 4), nil
+			}
 		}
 	}
-	return 0, readError(r, line, "blob")
+	if len(line) == 3 && line[0] == '_' {
+		return 0, ErrNil
+	}
+	return 0, readError(r, line, "blob string")
 }
 
 func readArrayLen(r *bufio.Reader) (int64, error) {
@@ -258,18 +1648,85 @@ func readArrayLen(r *bufio.Reader) (int64, error) {
 		return 0, err
 	}
 
-	if len(line) > 3 && line[0] == '*' {
+	if len(line) > 3 {
 		l := ParseInt(line[1 : len(line)-2])
-		switch {
-		case l >= 0 && l <= ElementMax:
-			return l, nil
-		case l == -1:
-			return 0, errNull
+		switch line[0] {
+		case '*', '~': // RESP3 set too
+			switch {
+			case l >= 0 && l <= ElementMax:
+				return l, nil
+			case l == -1:
+				return 0, ErrNil
+			}
+		case '%':
+			// RESP3 map in sequence of field–value pairs, like RESP2
+			if l >= 0 && l <= ElementMax/2 {
+				return l * 2, nil
+			}
 		}
 	}
+	if len(line) == 3 && line[0] == '_' {
+		return 0, ErrNil
+	}
 	return 0, readError(r, line, "array")
 }
 
+// ReadMapLen returns the number of field–value pairs from either a RESP2 array,
+// which must have an even number of elements, or a RESP3 map, as in use after
+// HELLO 3.
+func readMapLen(r *bufio.Reader) (int64, error) {
+	line, err := readLF(r)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(line) > 3 {
+		l := ParseInt(line[1 : len(line)-2])
+		switch line[0] {
+		case '*':
+			switch {
+			case l >= 0 && l <= ElementMax && l&1 == 0:
+				return l / 2, nil
+			case l == -1:
+				return 0, ErrNil
+			case l >= 0 && l <= ElementMax:
+				return 0, fmt.Errorf("%w; map with %d elements", errProtocol, l)
+			}
+		case '%':
+			if l >= 0 && l <= ElementMax/2 {
+				return l, nil
+			}
+		}
+	}
+	if len(line) == 3 && line[0] == '_' {
+		return 0, ErrNil
+	}
+	return 0, readError(r, line, "map")
+}
+
+// ReplyTypeNames has the RESP3 names per type byte.
+var replyTypeNames = [256]string{
+	'+': "simple string",
+	'-': "simple error",
+	':': "integer",
+	'$': "blob string",
+	'*': "array",
+	'_': "null",
+	',': "double",
+	'#': "boolean",
+	'!': "blob error",
+	'=': "verbatim string",
+	'(': "big number",
+	'%': "map",
+	'~': "set",
+	'|': "attribute",
+	'>': "push",
+}
+
+// ReadError resolves a reply line which did not match the type expected, as
+// described by want. Error replies return as a ServerError, and OK replies
+// return as errOK. Anything else is a protocol violation with the received
+// type in the description.
 func readError(r *bufio.Reader, line []byte, want string) error {
 	switch {
 	case len(line) > 3 && line[0] == '-':
@@ -288,7 +1745,17 @@ func readError(r *bufio.Reader, line []byte, want string) error {
 		return errOK
 	}
 
-	return fmt.Errorf("%w; %s expected–received %.40q", errProtocol, want, line)
+	if len(line) == 0 {
+		return fmt.Errorf("%w; expected %s, got empty line", errProtocol, want)
+	}
+	got := replyTypeNames[line[0]]
+	switch got {
+	case "":
+		return fmt.Errorf("%w; expected %s, got unknown type %.40q", errProtocol, want, line)
+	case want:
+		return fmt.Errorf("%w; malformed %s %.40q", errProtocol, want, line)
+	}
+	return fmt.Errorf("%w; expected %s, got %s %.40q", errProtocol, want, got, line)
 }
 
 func readBytesSize(r *bufio.Reader, size int) ([]byte, error) {
@@ -347,19 +1814,72 @@ func readStringSize(r *bufio.Reader, size int) (string, error) {
 // errMapSlices rejects execution due malformed invocation.
 var errMapSlices = errors.New("redis: number of keys doesn't match number of values")
 
+var errNoKeys = errors.New("redis: command needs at least one key")
+
+// errSizeMax rejects execution due an argument beyond the server limit.
+var errSizeMax = errors.New("redis: argument exceeds 512 MiB")
+
+// errElementMax rejects execution due an argument count beyond the server limit.
+var errElementMax = errors.New("redis: number of arguments exceeds 2³² − 1")
+
+// errClientName rejects CLIENT SETNAME before execution.
+var errClientName = errors.New("redis: client name with space, newline or special character")
+
 type request struct {
 	buf     []byte
 	receive chan *bufio.Reader
+
+	// The write lock owner sets the connection when it sent the request on
+	// behalf of another routine [write coalescing], or the error when that
+	// failed.
+	conn net.Conn
+	err  error
+
+	// Read-only requests may execute again, as many as ClientOptions
+	// ReadRetries times, with attempt as the count so far.
+	readOnly bool
+	attempt  int
+
+	// A pinned request fails with ErrConnLost, without submission, when
+	// the connection is not pin [WATCH state].
+	pin net.Conn
+
+	// number of responses after the first, for requests with multiple
+	// commands
+	more int
+
+	// Construction sets invalid when the request exceeds server limits.
+	// Such requests never reach the network.
+	invalid error
 }
 
+// RequestBufMax is the upper boundary for buffer capacity retained on free,
+// such that a single large value does not pin memory in the pool.
+const requestBufMax = 64 << 10
+
+// Free recycles the request. The receive channel must not be referenced by
+// the read queue anymore, i.e., either it was never queued, or the handover
+// (or queue abandonment) was received already.
 func (r *request) free() {
+	if cap(r.buf) > requestBufMax {
+		r.buf = nil
+	} else {
+		r.buf = r.buf[:0]
+	}
+	r.conn = nil
+	r.err = nil
+	r.readOnly = false
+	r.attempt = 0
+	r.pin = nil
+	r.more = 0
+	r.invalid = nil
 	requestPool.Put(r)
 }
 
 var requestPool = sync.Pool{
 	New: func() interface{} {
 		return &request{
-			buf:     make([]byte, 256),
+			buf:     make([]byte, 0, 256),
 			receive: make(chan *bufio.Reader),
 		}
 	},
@@ -373,6 +1893,9 @@ func newRequest(prefix string) *request {
 
 func newRequestSize(n int, prefix string) *request {
 	r := requestPool.Get().(*request)
+	if uint64(n) > ElementMax {
+		r.invalid = errElementMax
+	}
 	r.buf = append(r.buf[:0], '*')
 	r.buf = strconv.AppendUint(r.buf, uint64(n), 10)
 	r.buf = append(r.buf, prefix...)
@@ -390,6 +1913,11 @@ func (r *request) addString(a string) {
 }
 
 func (r *request) addBytesList(a [][]byte) {
+	n := len(a) * argOverhead
+	for _, b := range a {
+		n += len(b)
+	}
+	r.grow(n)
 	for _, b := range a {
 		r.buf = append(r.buf, '\r', '\n', '$')
 		r.bytes(b)
@@ -398,6 +1926,11 @@ func (r *request) addBytesList(a [][]byte) {
 }
 
 func (r *request) addStringList(a []string) {
+	n := len(a) * argOverhead
+	for _, s := range a {
+		n += len(s)
+	}
+	r.grow(n)
 	for _, s := range a {
 		r.buf = append(r.buf, '\r', '\n', '$')
 		r.string(s)
@@ -432,7 +1965,36 @@ func (r *request) addBytesBytesStringInt(a1, a2 []byte, a3 string, a4 int64) {
 	r.buf = append(r.buf, '\r', '\n')
 }
 
-func (r *request) addBytesBytesStringStringInt(a1, a2 []byte, a3, a4 string, a5 int64) {
+func (r *request) addBytesBytesStringIntString(a1, a2 []byte, a3 string, a4 int64, a5 string) {
+	r.bytes(a1)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.bytes(a2)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.string(a3)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.decimal(a4)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.string(a5)
+	r.buf = append(r.buf, '\r', '\n')
+}
+
+func (r *request) addBytesString(a1 []byte, a2 string) {
+	r.bytes(a1)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.string(a2)
+	r.buf = append(r.buf, '\r', '\n')
+}
+
+func (r *request) addBytesStringInt(a1 []byte, a2 string, a3 int64) {
+	r.bytes(a1)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.string(a2)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.decimal(a3)
+	r.buf = append(r.buf, '\r', '\n')
+}
+
+func (r *request) addBytesBytesStringString(a1, a2 []byte, a3, a4 string) {
 	r.bytes(a1)
 	r.buf = append(r.buf, '\r', '\n', '$')
 	r.bytes(a2)
@@ -440,8 +2002,17 @@ func (r *request) addBytesBytesStringStringInt(a1, a2 []byte, a3, a4 string, a5
 	r.string(a3)
 	r.buf = append(r.buf, '\r', '\n', '$')
 	r.string(a4)
+	r.buf = append(r.buf, '\r', '\n')
+}
+
+func (r *request) addBytesStringBytesBytes(a1 []byte, a2 string, a3, a4 []byte) {
+	r.bytes(a1)
 	r.buf = append(r.buf, '\r', '\n', '$')
-	r.decimal(a5)
+	r.string(a2)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.bytes(a3)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.bytes(a4)
 	r.buf = append(r.buf, '\r', '\n')
 }
 
@@ -458,6 +2029,11 @@ func (r *request) addBytesBytesMapLists(a1, a2 [][]byte) error {
 	if len(a1) != len(a2) {
 		return errMapSlices
 	}
+	n := len(a1) * 2 * argOverhead
+	for i := range a1 {
+		n += len(a1[i]) + len(a2[i])
+	}
+	r.grow(n)
 	for i, key := range a1 {
 		r.buf = append(r.buf, '\r', '\n', '$')
 		r.bytes(key)
@@ -482,43 +2058,15 @@ func (r *request) addStringBytes(a1 string, a2 []byte) {
 	r.buf = append(r.buf, '\r', '\n')
 }
 
-func (r *request) addStringBytesString(a1 string, a2 []byte, a3 string) {
-	r.string(a1)
-	r.buf = append(r.buf, '\r', '\n', '$')
-	r.bytes(a2)
-	r.buf = append(r.buf, '\r', '\n', '$')
-	r.string(a3)
-	r.buf = append(r.buf, '\r', '\n')
-}
-
-func (r *request) addStringBytesStringInt(a1 string, a2 []byte, a3 string, a4 int64) {
-	r.string(a1)
-	r.buf = append(r.buf, '\r', '\n', '$')
-	r.bytes(a2)
-	r.buf = append(r.buf, '\r', '\n', '$')
-	r.string(a3)
-	r.buf = append(r.buf, '\r', '\n', '$')
-	r.decimal(a4)
-	r.buf = append(r.buf, '\r', '\n')
-}
-
-func (r *request) addStringBytesStringStringInt(a1 string, a2 []byte, a3, a4 string, a5 int64) {
-	r.string(a1)
-	r.buf = append(r.buf, '\r', '\n', '$')
-	r.bytes(a2)
-	r.buf = append(r.buf, '\r', '\n', '$')
-	r.string(a3)
-	r.buf = append(r.buf, '\r', '\n', '$')
-	r.string(a4)
-	r.buf = append(r.buf, '\r', '\n', '$')
-	r.decimal(a5)
-	r.buf = append(r.buf, '\r', '\n')
-}
-
 func (r *request) addStringBytesMapLists(a1 []string, a2 [][]byte) error {
 	if len(a1) != len(a2) {
 		return errMapSlices
 	}
+	n := len(a1) * 2 * argOverhead
+	for i := range a1 {
+		n += len(a1[i]) + len(a2[i])
+	}
+	r.grow(n)
 	for i, key := range a1 {
 		r.buf = append(r.buf, '\r', '\n', '$')
 		r.string(key)
@@ -543,6 +2091,15 @@ func (r *request) addStringString(a1, a2 string) {
 	r.buf = append(r.buf, '\r', '\n')
 }
 
+func (r *request) addStringStringInt(a1, a2 string, a3 int64) {
+	r.string(a1)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.string(a2)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.decimal(a3)
+	r.buf = append(r.buf, '\r', '\n')
+}
+
 func (r *request) addStringStringString(a1, a2, a3 string) {
 	r.string(a1)
 	r.buf = append(r.buf, '\r', '\n', '$')
@@ -563,6 +2120,30 @@ func (r *request) addStringStringStringInt(a1, a2, a3 string, a4 int64) {
 	r.buf = append(r.buf, '\r', '\n')
 }
 
+func (r *request) addStringStringStringString(a1, a2, a3, a4 string) {
+	r.string(a1)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.string(a2)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.string(a3)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.string(a4)
+	r.buf = append(r.buf, '\r', '\n')
+}
+
+func (r *request) addStringStringStringIntString(a1, a2, a3 string, a4 int64, a5 string) {
+	r.string(a1)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.string(a2)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.string(a3)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.decimal(a4)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.string(a5)
+	r.buf = append(r.buf, '\r', '\n')
+}
+
 func (r *request) addStringStringStringStringInt(a1, a2, a3, a4 string, a5 int64) {
 	r.string(a1)
 	r.buf = append(r.buf, '\r', '\n', '$')
@@ -576,10 +2157,26 @@ func (r *request) addStringStringStringStringInt(a1, a2, a3, a4 string, a5 int64
 	r.buf = append(r.buf, '\r', '\n')
 }
 
+func (r *request) addStringStringBytesBytes(a1, a2 string, a3, a4 []byte) {
+	r.string(a1)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.string(a2)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.bytes(a3)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.bytes(a4)
+	r.buf = append(r.buf, '\r', '\n')
+}
+
 func (r *request) addStringStringMapLists(a1, a2 []string) error {
 	if len(a1) != len(a2) {
 		return errMapSlices
 	}
+	n := len(a1) * 2 * argOverhead
+	for i := range a1 {
+		n += len(a1[i]) + len(a2[i])
+	}
+	r.grow(n)
 	for i, key := range a1 {
 		r.buf = append(r.buf, '\r', '\n', '$')
 		r.string(key)
@@ -590,6 +2187,46 @@ func (r *request) addStringStringMapLists(a1, a2 []string) error {
 	return nil
 }
 
+func (r *request) addStringBytesMap(m map[string][]byte) {
+	n := len(m) * 2 * argOverhead
+	for key, value := range m {
+		n += len(key) + len(value)
+	}
+	r.grow(n)
+	for key, value := range m {
+		r.buf = append(r.buf, '\r', '\n', '$')
+		r.string(key)
+		r.buf = append(r.buf, '\r', '\n', '$')
+		r.bytes(value)
+	}
+	r.buf = append(r.buf, '\r', '\n')
+}
+
+func (r *request) addStringStringMap(m map[string]string) {
+	n := len(m) * 2 * argOverhead
+	for key, value := range m {
+		n += len(key) + len(value)
+	}
+	r.grow(n)
+	for key, value := range m {
+		r.buf = append(r.buf, '\r', '\n', '$')
+		r.string(key)
+		r.buf = append(r.buf, '\r', '\n', '$')
+		r.string(value)
+	}
+	r.buf = append(r.buf, '\r', '\n')
+}
+
+func (r *request) addStringStringBytesMap(a1 string, a2 map[string][]byte) {
+	r.string(a1)
+	r.addStringBytesMap(a2)
+}
+
+func (r *request) addStringStringStringMap(a1 string, a2 map[string]string) {
+	r.string(a1)
+	r.addStringStringMap(a2)
+}
+
 func (r *request) addStringStringList(a1 string, a2 []string) {
 	r.string(a1)
 	for _, s := range a2 {
@@ -680,6 +2317,19 @@ func (r *request) addStringBytesStringList(a1 string, a2 []byte, a3 []string) {
 	r.buf = append(r.buf, '\r', '\n')
 }
 
+func (r *request) addStringIntBytesStringList(a1 string, a2 int64, a3 []byte, a4 []string) {
+	r.string(a1)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.decimal(a2)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.bytes(a3)
+	for _, s := range a4 {
+		r.buf = append(r.buf, '\r', '\n', '$')
+		r.string(s)
+	}
+	r.buf = append(r.buf, '\r', '\n')
+}
+
 func (r *request) addStringIntBytes(a1 string, a2 int64, a3 []byte) {
 	r.string(a1)
 	r.buf = append(r.buf, '\r', '\n', '$')
@@ -727,6 +2377,21 @@ func (r *request) addStringStringStringList(a1, a2 string, a3 []string) {
 	r.buf = append(r.buf, '\r', '\n')
 }
 
+func (r *request) addStringIntStringListStringList(a1 string, a2 int64, a3, a4 []string) {
+	r.string(a1)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.decimal(a2)
+	for _, s := range a3 {
+		r.buf = append(r.buf, '\r', '\n', '$')
+		r.string(s)
+	}
+	for _, s := range a4 {
+		r.buf = append(r.buf, '\r', '\n', '$')
+		r.string(s)
+	}
+	r.buf = append(r.buf, '\r', '\n')
+}
+
 func (r *request) addStringBytesList(a1 string, a2 [][]byte) {
 	r.string(a1)
 	for _, key := range a2 {
@@ -796,20 +2461,98 @@ func (r *request) addStringIntStringMapLists(a1 string, a2 []int64, a3 []string)
 	return nil
 }
 
+// AddStringPayloadSize appends the length announcement of a blob, which is
+// to be sent separately.
+func (r *request) addStringPayloadSize(a1 string, size int64) {
+	r.string(a1)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.buf = strconv.AppendInt(r.buf, size, 10)
+	r.buf = append(r.buf, '\r', '\n')
+}
+
+func (r *request) addDecimalDecimal(a1, a2 int64) {
+	r.decimal(a1)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.decimal(a2)
+	r.buf = append(r.buf, '\r', '\n')
+}
+
 func (r *request) addDecimal(v int64) {
 	r.decimal(v)
 	r.buf = append(r.buf, '\r', '\n')
 }
 
+// AddSETArgs appends the optional arguments of SET, as counted by
+// newSETRequest.
+func (r *request) addSETArgs(existArg, expireArg string, expire int64, get bool) {
+	if existArg != "" {
+		r.buf = append(r.buf, '$')
+		r.addString(existArg)
+	}
+	if expireArg != "" {
+		r.buf = append(r.buf, '$')
+		r.addString(expireArg)
+		if expireArg != "KEEPTTL" {
+			r.buf = append(r.buf, '$')
+			r.addDecimal(expire)
+		}
+	}
+	if get {
+		r.buf = append(r.buf, "$3\r\nGET\r\n"...)
+	}
+}
+
+// AddLPOSArgs appends the optional arguments of LPOS, as counted by
+// newLPOSRequest.
+func (r *request) addLPOSArgs(rank, count int64) {
+	if rank != 0 {
+		r.addOptionInt("RANK", rank)
+	}
+	if count >= 0 {
+		r.addOptionInt("COUNT", count)
+	}
+}
+
+// AddOptionInt appends a named integer argument.
+func (r *request) addOptionInt(name string, v int64) {
+	r.buf = append(r.buf, '$')
+	r.string(name)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	r.decimal(v)
+	r.buf = append(r.buf, '\r', '\n')
+}
+
+// ArgOverhead is the upper boundary for the encoding size of an argument,
+// excluding the argument itself: "\r\n$" + length (up to 9 digits) + "\r\n".
+const argOverhead = 16
+
+// Grow ensures space for another n bytes, such that large argument lists cause
+// a single allocation at most.
+func (r *request) grow(n int) {
+	if cap(r.buf)-len(r.buf) < n {
+		buf := make([]byte, len(r.buf), len(r.buf)+n)
+		copy(buf, r.buf)
+		r.buf = buf
+	}
+}
+
 func (r *request) bytes(v []byte) {
 	r.buf = strconv.AppendUint(r.buf, uint64(len(v)), 10)
 	r.buf = append(r.buf, '\r', '\n')
+	if len(v) > SizeMax {
+		r.invalid = errSizeMax
+		return // don't copy what can't be sent
+	}
 	r.buf = append(r.buf, v...)
 }
 
 func (r *request) string(v string) {
 	r.buf = strconv.AppendUint(r.buf, uint64(len(v)), 10)
 	r.buf = append(r.buf, '\r', '\n')
+	if len(v) > SizeMax {
+		r.invalid = errSizeMax
+		return // don't copy what can't be sent
+	}
 	r.buf = append(r.buf, v...)
 }
 
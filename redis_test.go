@@ -1,9 +1,15 @@
 package redis
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
 	"math"
+	"reflect"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseInt(t *testing.T) {
@@ -18,6 +24,340 @@ func TestParseInt(t *testing.T) {
 	}
 }
 
+func TestParseFloat(t *testing.T) {
+	golden := []struct {
+		s    string
+		want float64
+	}{
+		{"0", 0},
+		{"1.5", 1.5},
+		{"-2", -2},
+		{"0.30000000000000004", 0.30000000000000004},
+		{"1e+300", 1e300},
+		{"-1.7976931348623157e308", -math.MaxFloat64},
+		{"4.9406564584124654e-324", math.SmallestNonzeroFloat64},
+		{"inf", math.Inf(1)},
+		{"+inf", math.Inf(1)},
+		{"-inf", math.Inf(-1)},
+	}
+	for _, gold := range golden {
+		got, err := ParseFloat([]byte(gold.s))
+		if err != nil {
+			t.Errorf("%q got error: %s", gold.s, err)
+		} else if got != gold.want {
+			t.Errorf("%q got %g, want %g", gold.s, got, gold.want)
+		}
+	}
+
+	if got, err := ParseFloat([]byte("-0")); err != nil {
+		t.Error("negative zero got error:", err)
+	} else if got != 0 || !math.Signbit(got) {
+		t.Errorf("negative zero got %g", got)
+	}
+
+	for _, s := range []string{"", " 1", "1 ", "1.5x", "--1", "nan", "0x1p3", "1_000", "1e400"} {
+		if got, err := ParseFloat([]byte(s)); err == nil {
+			t.Errorf("%q got %g, want error", s, got)
+		}
+	}
+
+	for _, f := range []float64{0.1, -0.1, 1e300, -math.MaxFloat64, math.SmallestNonzeroFloat64, math.Copysign(0, -1), math.Inf(-1)} {
+		got, err := ParseFloat([]byte(formatFloat(f)))
+		if err != nil {
+			t.Errorf("%g formatted as %q got error: %s", f, formatFloat(f), err)
+		} else if got != f || math.Signbit(got) != math.Signbit(f) {
+			t.Errorf("%g formatted as %q got %g", f, formatFloat(f), got)
+		}
+	}
+}
+
+func TestDecodeNil(t *testing.T) {
+	for _, reply := range []string{"$-1\r\n", "_\r\n"} {
+		if err := decodeOK(bufio.NewReader(strings.NewReader(reply))); !errors.Is(err, ErrNil) {
+			t.Errorf("OK decode of %q got error %v, want ErrNil", reply, err)
+		}
+		if _, err := decodeInteger(bufio.NewReader(strings.NewReader(reply))); !errors.Is(err, ErrNil) {
+			t.Errorf("integer decode of %q got error %v, want ErrNil", reply, err)
+		}
+		if _, err := decodeSimpleString(bufio.NewReader(strings.NewReader(reply))); !errors.Is(err, ErrNil) {
+			t.Errorf("simple string decode of %q got error %v, want ErrNil", reply, err)
+		}
+	}
+}
+
+func TestDecodeRESP3(t *testing.T) {
+	reader := func(reply string) *bufio.Reader {
+		return bufio.NewReader(strings.NewReader(reply))
+	}
+
+	if s, err := decodeBlobString(reader("=8\r\ntxt:abcd\r\n")); err != nil {
+		t.Error("verbatim string error:", err)
+	} else if s != "abcd" {
+		t.Errorf(`verbatim string got %q, want "abcd"`, s)
+	}
+	if _, err := decodeBlobBytes(reader("_\r\n")); err != ErrNil {
+		t.Errorf("null blob got error %v, want %v", err, ErrNil)
+	}
+	if _, err := decodeBytesArray(reader("_\r\n")); err != ErrNil {
+		t.Errorf("null array got error %v, want %v", err, ErrNil)
+	}
+	if n, err := decodeInteger(reader("#t\r\n")); err != nil || n != 1 {
+		t.Errorf("boolean true got %d, %v, want 1", n, err)
+	}
+	if n, err := decodeInteger(reader("#f\r\n")); err != nil || n != 0 {
+		t.Errorf("boolean false got %d, %v, want 0", n, err)
+	}
+	if f, err := decodeFloat(reader(",1.5\r\n")); err != nil || f != 1.5 {
+		t.Errorf("double 1.5 got %g, %v", f, err)
+	}
+	if f, err := decodeFloat(reader(",-inf\r\n")); err != nil || !math.IsInf(f, -1) {
+		t.Errorf("double -inf got %g, %v", f, err)
+	}
+	if f, err := decodeFloat(reader("$5\r\n0x1p0\r\n")); !errors.Is(err, errProtocol) {
+		t.Errorf("hexadecimal floating-point got %g, %v, want protocol error", f, err)
+	}
+	if a, err := decodeStringArray(reader("~2\r\n$1\r\na\r\n$1\r\nb\r\n")); err != nil {
+		t.Error("set error:", err)
+	} else if !reflect.DeepEqual(a, []string{"a", "b"}) {
+		t.Errorf(`set got %q, want ["a" "b"]`, a)
+	}
+
+	const pairs = "*2\r\n*2\r\n$1\r\na\r\n,1\r\n*2\r\n$1\r\nb\r\n,2.5\r\n"
+	if members, err := decodeZMembers(reader(pairs)); err != nil {
+		t.Error("nested member–score pairs error:", err)
+	} else if want := []ZMember{{[]byte("a"), 1}, {[]byte("b"), 2.5}}; !reflect.DeepEqual(members, want) {
+		t.Errorf("nested member–score pairs got %+v, want %+v", members, want)
+	}
+
+	const streams = "%1\r\n$1\r\ns\r\n*1\r\n*2\r\n$3\r\n1-1\r\n*2\r\n$1\r\nf\r\n$1\r\nv\r\n"
+	if got, err := decodeStreams(reader(streams)); err != nil {
+		t.Error("stream map error:", err)
+	} else if len(got) != 1 || got[0].Key != "s" || len(got[0].Entries) != 1 || got[0].Entries[0].ID != "1-1" {
+		t.Errorf("stream map got %+v", got)
+	}
+
+	const mixed = "%2\r\n+a\r\n,0.5\r\n+b\r\n~1\r\n#t\r\n"
+	if got, err := decodeAny(reader(mixed)); err != nil {
+		t.Error("any map error:", err)
+	} else if want := []interface{}{"a", 0.5, "b", []interface{}{true}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("any map got %#v, want %#v", got, want)
+	}
+	if got, err := decodeReply(reader(mixed)); err != nil {
+		t.Error("reply map error:", err)
+	} else if a := got.Array(); len(a) != 4 || a[1].Str() != "0.5" || a[3].Array()[0].Int() != 1 {
+		t.Errorf("reply map got %s", got)
+	}
+}
+
+func TestDecodeTypeMismatch(t *testing.T) {
+	golden := []struct {
+		reply string
+		f     func(*bufio.Reader) error
+		want  string
+	}{
+		{"$2\r\n42\r\n", func(r *bufio.Reader) error { _, err := decodeInteger(r); return err }, "expected integer, got blob string"},
+		{":42\r\n", func(r *bufio.Reader) error { _, err := decodeBlobBytes(r); return err }, "expected blob string, got integer"},
+		{"*1\r\n:1\r\n", func(r *bufio.Reader) error { return decodeOK(r) }, "expected OK, got array"},
+		{"+QUEUED\r\n", func(r *bufio.Reader) error { return decodeOK(r) }, "expected OK, got simple string"},
+		{":1\r\n", func(r *bufio.Reader) error { _, err := readArrayLen(r); return err }, "expected array, got integer"},
+		{"$-5\r\n", func(r *bufio.Reader) error { _, err := decodeBlobString(r); return err }, "malformed blob string"},
+		{"?1\r\n", func(r *bufio.Reader) error { _, err := decodeSimpleString(r); return err }, "expected simple string, got unknown type"},
+	}
+	for _, gold := range golden {
+		err := gold.f(bufio.NewReader(strings.NewReader(gold.reply)))
+		if !errors.Is(err, errProtocol) {
+			t.Errorf("decode of %q got error %v, want %v", gold.reply, err, errProtocol)
+			continue
+		}
+		if !strings.Contains(err.Error(), gold.want) {
+			t.Errorf("decode of %q got error %q, want %q included", gold.reply, err, gold.want)
+		}
+	}
+
+	// error replies are no protocol violation
+	err := decodeOK(bufio.NewReader(strings.NewReader("-WRONGTYPE Operation against a key holding the wrong kind of value\r\n")))
+	if !IsWrongType(err) {
+		t.Errorf("got error %v, want a WRONGTYPE ServerError", err)
+	}
+}
+
+func TestDecodeStringMap(t *testing.T) {
+	m, err := decodeStringMap(bufio.NewReader(strings.NewReader("*0\r\n")))
+	if err != nil {
+		t.Fatal("empty map decode error:", err)
+	}
+	if m == nil || len(m) != 0 {
+		t.Errorf("empty map decode got %v, want an empty non-nil map", m)
+	}
+
+	const odd = "*3\r\n$1\r\na\r\n$1\r\nb\r\n$1\r\nc\r\n"
+	if _, err := decodeStringMap(bufio.NewReader(strings.NewReader(odd))); !errors.Is(err, errProtocol) {
+		t.Errorf("map decode with 3 elements got error %v, want %v", err, errProtocol)
+	}
+
+	// same content in RESP2 and RESP3
+	want := map[string]string{"maxmemory": "0", "maxmemory-policy": "noeviction"}
+	for _, reply := range []string{
+		"*4\r\n$9\r\nmaxmemory\r\n$1\r\n0\r\n$16\r\nmaxmemory-policy\r\n$10\r\nnoeviction\r\n",
+		"%2\r\n$9\r\nmaxmemory\r\n$1\r\n0\r\n$16\r\nmaxmemory-policy\r\n$10\r\nnoeviction\r\n",
+	} {
+		m, err := decodeStringMap(bufio.NewReader(strings.NewReader(reply)))
+		if err != nil {
+			t.Errorf("map decode %q error: %s", reply[:2], err)
+		} else if !reflect.DeepEqual(m, want) {
+			t.Errorf("map decode %q got %q, want %q", reply[:2], m, want)
+		}
+	}
+}
+
+func TestDecodeCommandSpecs(t *testing.T) {
+	// Redis 7 reply on COMMAND INFO get nonexistent
+	const reply = "*2\r\n" +
+		"*10\r\n$3\r\nget\r\n:2\r\n*2\r\n+readonly\r\n+fast\r\n:1\r\n:1\r\n:1\r\n" +
+		"*3\r\n+@read\r\n+@string\r\n+@fast\r\n" +
+		"*0\r\n" +
+		"*1\r\n*4\r\n$5\r\nflags\r\n*2\r\n+RO\r\n+access\r\n$12\r\nbegin_search\r\n*2\r\n$4\r\ntype\r\n$5\r\nindex\r\n" +
+		"*0\r\n" +
+		"*-1\r\n"
+
+	r := bufio.NewReader(strings.NewReader(reply + "+OK\r\n"))
+	specs, err := decodeCommandSpecs(r)
+	if err != nil {
+		t.Fatal("decode error:", err)
+	}
+	if err := decodeOK(r); err != nil {
+		t.Error("reply not consumed exactly; next decode error:", err)
+	}
+	want := []CommandSpec{
+		{Name: "get", Arity: 2, Flags: []string{"readonly", "fast"}, FirstKey: 1, LastKey: 1, KeyStep: 1},
+		{},
+	}
+	if !reflect.DeepEqual(specs, want) {
+		t.Errorf("got %+v, want %+v", specs, want)
+	}
+
+	const short = "*1\r\n*2\r\n$3\r\nget\r\n:2\r\n"
+	if _, err := decodeCommandSpecs(bufio.NewReader(strings.NewReader(short))); !errors.Is(err, errProtocol) {
+		t.Errorf("decode with 2 elements got error %v, want %v", err, errProtocol)
+	}
+}
+
+func TestServerErrorKinds(t *testing.T) {
+	golden := []struct {
+		err  error
+		pred func(error) bool
+		want bool
+	}{
+		{ServerError("WRONGTYPE Operation against a key holding the wrong kind of value"), IsWrongType, true},
+		{ServerError("NOSCRIPT No matching script. Please use EVAL."), IsNoScript, true},
+		{ServerError("LOADING Redis is loading the dataset in memory"), IsLoading, true},
+		{ServerError("READONLY You can't write against a read only replica."), IsReadOnly, true},
+		{ServerError("CLUSTERDOWN The cluster is down"), IsClusterDown, true},
+		{fmt.Errorf("wrapped: %w", ServerError("WRONGTYPE")), IsWrongType, true},
+		{ServerError("ERR WRONGTYPE"), IsWrongType, false},
+		{errors.New("WRONGTYPE"), IsWrongType, false},
+		{nil, IsLoading, false},
+	}
+	for _, gold := range golden {
+		if got := gold.pred(gold.err); got != gold.want {
+			t.Errorf("got %t for %#v, want %t", got, gold.err, gold.want)
+		}
+	}
+
+	kinds := []struct {
+		err  error
+		kind error
+	}{
+		{ServerError("WRONGTYPE Operation against a key holding the wrong kind of value"), ErrWrongType},
+		{ServerError("NOSCRIPT No matching script. Please use EVAL."), ErrNoScript},
+		{ServerError("BUSY Redis is busy running a script."), ErrBusy},
+		{ServerError("LOADING Redis is loading the dataset in memory"), ErrLoading},
+		{ServerError("READONLY You can't write against a read only replica."), ErrReadOnly},
+		{ServerError("OOM command not allowed when used memory > 'maxmemory'."), ErrOOM},
+		{fmt.Errorf("wrapped: %w", ServerError("CLUSTERDOWN The cluster is down")), ErrClusterDown},
+	}
+	for i, k := range kinds {
+		for j, other := range kinds {
+			if got := errors.Is(k.err, other.kind); got != (i == j) {
+				t.Errorf("errors.Is(%q, %q) got %t", k.err, other.kind, got)
+			}
+		}
+	}
+	if errors.Is(ServerError("ERR unknown"), ErrOOM) {
+		t.Error("errors.Is of an ERR matched ErrOOM")
+	}
+}
+
+func TestMovedError(t *testing.T) {
+	err := error(ServerError("MOVED 3999 127.0.0.1:6381"))
+	moved, ok := IsMoved(err)
+	if !ok {
+		t.Fatalf("IsMoved(%q) got false", err)
+	}
+	if moved.Ask || moved.Slot != 3999 || moved.Addr != "127.0.0.1:6381" {
+		t.Errorf("IsMoved(%q) got %+v", err, moved)
+	}
+	var e ServerError
+	if !errors.As(moved, &e) || e != err {
+		t.Errorf("MovedError does not wrap %q", err)
+	}
+	if moved.Error() != err.Error() {
+		t.Errorf("got error message %q, want %q", moved.Error(), err.Error())
+	}
+	if _, ok := IsAsk(err); ok {
+		t.Errorf("IsAsk(%q) got true", err)
+	}
+	var asMoved *MovedError
+	if !errors.As(fmt.Errorf("wrapped: %w", err), &asMoved) {
+		t.Errorf("errors.As(%q) to MovedError got false", err)
+	} else if asMoved.Slot != 3999 || asMoved.Addr != "127.0.0.1:6381" {
+		t.Errorf("errors.As(%q) to MovedError got %+v", err, asMoved)
+	}
+	if errors.As(ServerError("ERR moved"), &asMoved) {
+		t.Error("errors.As of an ERR to MovedError got true")
+	}
+
+	err = ServerError("ASK 12 [::1]:7000")
+	if ask, ok := IsAsk(err); !ok {
+		t.Errorf("IsAsk(%q) got false", err)
+	} else if !ask.Ask || ask.Slot != 12 || ask.Addr != "[::1]:7000" {
+		t.Errorf("IsAsk(%q) got %+v", err, ask)
+	}
+	if _, ok := IsMoved(err); ok {
+		t.Errorf("IsMoved(%q) got true", err)
+	}
+
+	for _, s := range []string{"MOVED", "MOVED x 127.0.0.1:6381", "MOVED 3999", "ERR MOVED 1 a:1"} {
+		if _, ok := IsMoved(ServerError(s)); ok {
+			t.Errorf("IsMoved(%q) got true", s)
+		}
+	}
+}
+
+func TestSlot(t *testing.T) {
+	golden := []struct {
+		Key  string
+		Slot uint16
+	}{
+		{"", 0},
+		{"123456789", 12739},
+		{"foo", 12182},
+		{"{user1000}.following", 3443},
+		{"{user1000}.followers", 3443},
+		{"user1000", 3443},
+		{"foo{}{bar}", 8363},
+		{"foo{{bar}}zap", 4015},
+		{"foo{bar}{zap}", 5061},
+		{"{bar", 4015},
+		{"bar", 5061},
+	}
+	for _, gold := range golden {
+		if got := Slot(gold.Key); got != gold.Slot {
+			t.Errorf("Slot(%q) got %d, want %d", gold.Key, got, gold.Slot)
+		}
+	}
+}
+
 func TestNormalizeAddr(t *testing.T) {
 	golden := []struct{ Addr, Normal string }{
 		{"", "localhost:6379"},
@@ -33,3 +373,82 @@ func TestNormalizeAddr(t *testing.T) {
 		}
 	}
 }
+
+func TestParseURL(t *testing.T) {
+	golden := []struct {
+		URL  string
+		Want urlConfig
+	}{
+		{"redis://", urlConfig{Addr: "localhost:6379"}},
+		{"redis://test.host/", urlConfig{Addr: "test.host:6379"}},
+		{"redis://:secret@test.host:6380/2", urlConfig{Addr: "test.host:6380", Password: []byte("secret"), DB: 2}},
+		{"rediss://user:p%40ss@[::1]:6380", urlConfig{Addr: "[::1]:6380", User: "user", Password: []byte("p@ss"), TLS: true}},
+		{"unix:///var/redis/../run/redis.sock", urlConfig{Addr: "/var/run/redis.sock"}},
+		{"unix://:secret@/var/run/redis.sock?db=1", urlConfig{Addr: "/var/run/redis.sock", Password: []byte("secret"), DB: 1}},
+		{"redis://test.host/3?dial_timeout=5s&command_timeout=250ms", urlConfig{Addr: "test.host:6379", DB: 3, DialTimeout: 5 * time.Second, CommandTimeout: 250 * time.Millisecond}},
+		{"unix:///var/run/redis.sock?command_timeout=1m&db=4", urlConfig{Addr: "/var/run/redis.sock", DB: 4, CommandTimeout: time.Minute}},
+	}
+	for _, gold := range golden {
+		got, err := parseURL(gold.URL)
+		if err != nil {
+			t.Errorf("%q got error: %s", gold.URL, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, gold.Want) {
+			t.Errorf("%q got %+v, want %+v", gold.URL, got, gold.Want)
+		}
+	}
+
+	for _, s := range []string{
+		"test.host:6379",
+		"/var/run/redis.sock",
+		"http://test.host",
+		"redis://test.host/x",
+		"redis://test.host/-1",
+		"redis://test.host/1/2",
+		"redis://test.host?db=1",
+		"redis://user@test.host",
+		"redis://test.host:port",
+		"unix://test.host/var/run/redis.sock",
+		"unix:///var/run/redis.sock?db=x",
+		"unix:///var/run/redis.sock?timeout=1",
+		"unix:///var/run/redis.sock?db=1&db=2",
+		"redis://test.host?dial_timeout=5",
+		"redis://test.host?dial_timeout=-1s",
+		"redis://test.host?command_timeout=x",
+	} {
+		if _, err := parseURL(s); err == nil {
+			t.Errorf("%q got no error", s)
+		}
+	}
+
+	if _, err := parseURL("redis://:top-secret@test.host:port"); err == nil {
+		t.Error("got no error for malformed port")
+	} else if strings.Contains(err.Error(), "top-secret") {
+		t.Errorf("error %q contains password", err)
+	}
+}
+
+func BenchmarkRequest(b *testing.B) {
+	key, value := "bench-key", make([]byte, 64)
+
+	b.Run("pool", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r := newRequest("*3\r\n$3\r\nSET\r\n$")
+			r.addStringBytes(key, value)
+			r.free()
+		}
+	})
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r := &request{
+				buf:     make([]byte, 0, 256),
+				receive: make(chan *bufio.Reader),
+			}
+			r.buf = append(r.buf, "*3\r\n$3\r\nSET\r\n$"...)
+			r.addStringBytes(key, value)
+		}
+	})
+}
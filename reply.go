@@ -0,0 +1,345 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ReplyType is the data type of a Reply.
+type ReplyType int
+
+// Reply Types
+const (
+	// NilReply is a null blob or a null array.
+	NilReply ReplyType = iota
+	// StringReply is a simple string, like OK or QUEUED.
+	StringReply
+	// IntReply is an integer.
+	IntReply
+	// BlobReply is a (binary safe) bulk string.
+	BlobReply
+	// ArrayReply is an array of replies.
+	ArrayReply
+	// ErrorReply is an error within an array.
+	ErrorReply
+)
+
+// Reply is a response of any type. The zero value is a null reply.
+type Reply struct {
+	typ   ReplyType
+	blob  []byte // StringReply, BlobReply or ErrorReply
+	int   int64
+	array []Reply
+}
+
+// Type returns the data type.
+func (r Reply) Type() ReplyType { return r.typ }
+
+// IsNil returns whether the reply is null, like GET on a key that does not
+// exist.
+func (r Reply) IsNil() bool { return r.typ == NilReply }
+
+// Int returns the value of an integer reply. Blob replies and simple strings
+// are parsed in decimal notation. The return is zero for any other type, or
+// when the parse fails.
+func (r Reply) Int() int64 {
+	switch r.typ {
+	case IntReply:
+		return r.int
+	case StringReply, BlobReply:
+		v, err := strconv.ParseInt(string(r.blob), 10, 64)
+		if err != nil {
+			return 0
+		}
+		return v
+	}
+	return 0
+}
+
+// Bytes returns the value of a blob reply or a simple string. Integers are
+// formatted in decimal notation. The return is nil for any other type.
+func (r Reply) Bytes() []byte {
+	switch r.typ {
+	case StringReply, BlobReply:
+		return r.blob
+	case IntReply:
+		return strconv.AppendInt(nil, r.int, 10)
+	}
+	return nil
+}
+
+// Str is like Bytes, yet it returns the empty string instead of nil.
+func (r Reply) Str() string {
+	switch r.typ {
+	case StringReply, BlobReply:
+		return string(r.blob)
+	case IntReply:
+		return strconv.FormatInt(r.int, 10)
+	}
+	return ""
+}
+
+// Array returns the elements of an array reply. The return is nil for any other
+// type.
+func (r Reply) Array() []Reply {
+	if r.typ != ArrayReply {
+		return nil
+	}
+	return r.array
+}
+
+// Err returns the ServerError of an error reply, or nil for any other type.
+// Error replies only occur as array elements, e.g., with EXEC or EVAL.
+func (r Reply) Err() error {
+	if r.typ != ErrorReply {
+		return nil
+	}
+	return ServerError(r.blob)
+}
+
+// String returns a human readable representation.
+func (r Reply) String() string {
+	switch r.typ {
+	case NilReply:
+		return "(nil)"
+	case StringReply:
+		return string(r.blob)
+	case IntReply:
+		return "(integer) " + strconv.FormatInt(r.int, 10)
+	case BlobReply:
+		return strconv.Quote(string(r.blob))
+	case ErrorReply:
+		return "(error) " + string(r.blob)
+	case ArrayReply:
+		return fmt.Sprint(r.array)
+	}
+	return fmt.Sprintf("ReplyType(%d)", int(r.typ))
+}
+
+var errNoCommand = errors.New("redis: Do needs a command name")
+
+// ErrSubscribeMode rejects commands which switch the connection into a push
+// mode, as the responses would break the pipeline of the Client. Such commands
+// include SUBSCRIBE, PSUBSCRIBE, SSUBSCRIBE, their UNSUBSCRIBE counterparts,
+// and MONITOR. Use a Listener or a Monitor instead, each of which runs on a
+// dedicated connection.
+var ErrSubscribeMode = errors.New("redis: push mode needs a dedicated connection")
+
+// IsModeCommand returns whether name switches the connection into a push mode,
+// or whether it may emit push messages in that mode.
+func isModeCommand(name string) bool {
+	switch strings.ToUpper(name) {
+	case "MONITOR", "SUBSCRIBE", "PSUBSCRIBE", "SSUBSCRIBE", "UNSUBSCRIBE", "PUNSUBSCRIBE", "SUNSUBSCRIBE":
+		return true
+	}
+	return false
+}
+
+// Do executes any command. The first argument is the command name, followed by
+// its arguments. Arguments may be a string, a []byte, an int, an int64 or a
+// float64. Do can be used for commands without a dedicated method, including
+// module commands. Error replies return as a ServerError, like with any other
+// command. Commands which switch the connection into a push mode fail with
+// ErrSubscribeMode, without submission.
+func (c *Client) Do(args ...interface{}) (Reply, error) {
+	return c.do(c.ctx, args)
+}
+
+// DoContext is like Do, with cancellation. The context applies to the wait for
+// submission, and to the wait for preceding responses. Cancellation before the
+// write prevents execution. Cancellation after the write discards the response
+// once it arrives, which keeps the connection in place for other commands. The
+// read of the response itself is bound by the command timeout only. The return
+// on cancellation is the context error. Ctx takes precedence over the context
+// from WithContext, if any. See WithContext for all other commands.
+func (c *Client) DoContext(ctx context.Context, args ...interface{}) (Reply, error) {
+	return c.do(ctx, args)
+}
+
+// DoAsking is like Do, with ASKING in front, as required for the one-time
+// redirection of an ASK error. See IsAsk for the node address. Both commands go
+// out in one write, such that no other command gets in between. An error from
+// ASKING, e.g., on nodes without cluster support, is returned instead of the
+// reply, as the command executes without the redirection in place.
+func (c *Client) DoAsking(args ...interface{}) (Reply, error) {
+	if err := checkDoArgs(args); err != nil {
+		return Reply{}, err
+	}
+	r := newRequest("*1\r\n$6\r\nASKING\r\n*")
+	if uint64(len(args)) > ElementMax {
+		r.invalid = errElementMax
+	}
+	r.buf = strconv.AppendUint(r.buf, uint64(len(args)), 10)
+	r.buf = append(r.buf, '\r', '\n', '$')
+	if err := r.addAnyList(args); err != nil {
+		r.free()
+		return Reply{}, err
+	}
+
+	r.more = 1
+	reader, err := c.submit(r)
+	if err != nil {
+		return Reply{}, err
+	}
+	askErr := decodeOK(reader)
+	if _, ok := askErr.(ServerError); askErr != nil && !ok {
+		c.pass(reader, askErr)
+		return Reply{}, askErr
+	}
+	v, err := decodeReply(reader)
+	c.pass(reader, err)
+	if askErr != nil {
+		return Reply{}, askErr
+	}
+	return v, err
+}
+
+func (c *Client) do(ctx context.Context, args []interface{}) (Reply, error) {
+	if err := checkDoArgs(args); err != nil {
+		return Reply{}, err
+	}
+	r := newRequestSize(len(args), "\r\n$")
+	if err := r.addAnyList(args); err != nil {
+		r.free()
+		return Reply{}, err
+	}
+	return c.commandReply(ctx, r)
+}
+
+func checkDoArgs(args []interface{}) error {
+	if len(args) == 0 {
+		return errNoCommand
+	}
+	switch name := args[0].(type) {
+	case string:
+		if isModeCommand(name) {
+			return ErrSubscribeMode
+		}
+	case []byte:
+		if isModeCommand(string(name)) {
+			return ErrSubscribeMode
+		}
+	}
+	return nil
+}
+
+func (r *request) addAnyList(a []interface{}) error {
+	for i, v := range a {
+		if i != 0 {
+			r.buf = append(r.buf, '\r', '\n', '$')
+		}
+		switch v := v.(type) {
+		case string:
+			r.string(v)
+		case []byte:
+			r.bytes(v)
+		case int:
+			r.decimal(int64(v))
+		case int64:
+			r.decimal(v)
+		case float64:
+			r.string(formatFloat(v))
+		default:
+			return fmt.Errorf("redis: Do argument %d of type %T not supported", i, v)
+		}
+	}
+	r.buf = append(r.buf, '\r', '\n')
+	return nil
+}
+
+// DecodeReply reads a reply of any type. Error replies within arrays are
+// included as ErrorReply elements, as they don't fail the command as a whole.
+// RESP3 types map to the nearest RESP2 equivalent. Doubles and big numbers are
+// a StringReply, booleans are an IntReply of one or zero, verbatim strings are
+// a BlobReply without the format, and sets and pushes are an ArrayReply. Maps
+// are an ArrayReply with field–value pairs in sequence, as with RESP2.
+func decodeReply(r *bufio.Reader) (Reply, error) {
+	line, err := readLF(r)
+	if err != nil {
+		return Reply{}, err
+	}
+
+	if len(line) > 2 {
+		switch line[0] {
+		case '+', ',', '(':
+			return Reply{typ: StringReply, blob: []byte(line[1 : len(line)-2])}, nil
+		case ':':
+			if len(line) > 3 {
+				return Reply{typ: IntReply, int: ParseInt(line[1 : len(line)-2])}, nil
+			}
+		case '#':
+			if len(line) == 4 {
+				reply := Reply{typ: IntReply}
+				if line[1] == 't' {
+					reply.int = 1
+				}
+				return reply, nil
+			}
+		case '_':
+			return Reply{}, nil
+		case '=':
+			if len(line) > 3 {
+				l := ParseInt(line[1 : len(line)-2])
+				if l >= 4 && l <= SizeMax {
+					if _, err := r.Discard(4); err != nil {
+						return Reply{}, err
+					}
+					blob, err := readBytesSize(r, int(l-4))
+					return Reply{typ: BlobReply, blob: blob}, err
+				}
+			}
+		case '~', '%', '>':
+			if len(line) > 3 {
+				l := ParseInt(line[1 : len(line)-2])
+				if line[0] == '%' {
+					l *= 2
+				}
+				if l >= 0 && l <= ElementMax {
+					return decodeReplyArray(r, l)
+				}
+			}
+		case '$':
+			if len(line) > 3 {
+				l := ParseInt(line[1 : len(line)-2])
+				switch {
+				case l >= 0 && l <= SizeMax:
+					blob, err := readBytesSize(r, int(l))
+					return Reply{typ: BlobReply, blob: blob}, err
+				case l == -1:
+					return Reply{}, nil
+				}
+			}
+		case '*':
+			if len(line) > 3 {
+				l := ParseInt(line[1 : len(line)-2])
+				switch {
+				case l >= 0 && l <= ElementMax:
+					return decodeReplyArray(r, l)
+				case l == -1:
+					return Reply{}, nil
+				}
+			}
+		}
+	}
+	return Reply{}, readError(r, line, "reply")
+}
+
+func decodeReplyArray(r *bufio.Reader, size int64) (Reply, error) {
+	array := make([]Reply, 0, size)
+	for len(array) < cap(array) {
+		v, err := decodeReply(r)
+		if err != nil {
+			e, ok := err.(ServerError)
+			if !ok {
+				return Reply{}, err
+			}
+			v = Reply{typ: ErrorReply, blob: []byte(e)}
+		}
+		array = append(array, v)
+	}
+	return Reply{typ: ArrayReply, array: array}, nil
+}
@@ -0,0 +1,118 @@
+package redis
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestDecodeReply(t *testing.T) {
+	const reply = "*4\r\n:42\r\n$-1\r\n*2\r\n+OK\r\n$3\r\nfoo\r\n-ERR inline\r\n"
+	v, err := decodeReply(bufio.NewReader(strings.NewReader(reply)))
+	if err != nil {
+		t.Fatal("decode error:", err)
+	}
+	a := v.Array()
+	if v.Type() != ArrayReply || len(a) != 4 {
+		t.Fatalf("got %s, want array of 4 elements", v)
+	}
+	if got := a[0].Int(); got != 42 {
+		t.Errorf("element 0 got %d, want 42", got)
+	}
+	if !a[1].IsNil() {
+		t.Errorf("element 1 got %s, want nil", a[1])
+	}
+	nested := a[2].Array()
+	if len(nested) != 2 || nested[0].Str() != "OK" || string(nested[1].Bytes()) != "foo" {
+		t.Errorf("element 2 got %s, want [OK \"foo\"]", a[2])
+	}
+	if e, ok := a[3].Err().(ServerError); !ok || e.Prefix() != "ERR" {
+		t.Errorf("element 3 got error %v, want ServerError with ERR prefix", a[3].Err())
+	}
+}
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test")
+
+	if v, err := testClient.Do("SET", key, []byte("41")); err != nil {
+		t.Fatal("SET error:", err)
+	} else if v.Type() != StringReply || v.Str() != "OK" {
+		t.Errorf("SET got %s, want OK", v)
+	}
+	if v, err := testClient.Do("INCRBY", key, 1); err != nil {
+		t.Fatal("INCRBY error:", err)
+	} else if v.Type() != IntReply || v.Int() != 42 {
+		t.Errorf("INCRBY got %s, want integer 42", v)
+	}
+	if v, err := testClient.Do("GET", key); err != nil {
+		t.Fatal("GET error:", err)
+	} else if v.Type() != BlobReply || v.Int() != 42 {
+		t.Errorf("GET got %s, want blob 42", v)
+	}
+	if v, err := testClient.Do("GET", key+"-absent"); err != nil {
+		t.Fatal("GET absent error:", err)
+	} else if !v.IsNil() {
+		t.Errorf("GET absent got %s, want nil", v)
+	}
+
+	list := key + "-list"
+	if _, err := testClient.Do("RPUSH", list, "a", 2.5, int64(3)); err != nil {
+		t.Fatal("RPUSH error:", err)
+	}
+	v, err := testClient.Do("LRANGE", list, 0, -1)
+	if err != nil {
+		t.Fatal("LRANGE error:", err)
+	}
+	var got []string
+	for _, e := range v.Array() {
+		got = append(got, e.Str())
+	}
+	if strings.Join(got, " ") != "a 2.5 3" {
+		t.Errorf("LRANGE got %q, want [a 2.5 3]", got)
+	}
+
+	if _, err := testClient.Do("NOSUCHCOMMAND", key); err == nil {
+		t.Error("unknown command got no error")
+	} else if _, ok := err.(ServerError); !ok {
+		t.Errorf("unknown command got error %v, want a ServerError", err)
+	}
+	if _, err := testClient.Do(); err != errNoCommand {
+		t.Errorf("Do without arguments got error %v, want %v", err, errNoCommand)
+	}
+	if _, err := testClient.Do("GET", struct{}{}); err == nil {
+		t.Error("Do with a struct argument got no error")
+	}
+	for _, args := range [][]interface{}{{"SUBSCRIBE", key}, {"psubscribe", "*"}, {[]byte("Monitor")}, {"UNSUBSCRIBE"}} {
+		if _, err := testClient.Do(args...); err != ErrSubscribeMode {
+			t.Errorf("Do %q got error %v, want %v", args, err, ErrSubscribeMode)
+		}
+	}
+	if _, err := testClient.Do("PING"); err != nil {
+		t.Error("PING after subscribe rejection got error:", err)
+	}
+}
+
+func TestDoAsking(t *testing.T) {
+	t.Parallel()
+	v, err := testClient.DoAsking("ECHO", "x")
+	switch err.(type) {
+	case nil:
+		if v.Str() != "x" {
+			t.Errorf(`ECHO after ASKING got %q, want "x"`, v.Str())
+		}
+	case ServerError:
+		break // no cluster support
+	default:
+		t.Fatal("ECHO after ASKING got error:", err)
+	}
+
+	if v, err := testClient.Do("ECHO", "y"); err != nil {
+		t.Error("ECHO after DoAsking got error:", err)
+	} else if v.Str() != "y" {
+		t.Errorf(`ECHO after DoAsking got %q, want "y"`, v.Str())
+	}
+	if _, err := testClient.DoAsking(); err != errNoCommand {
+		t.Errorf("DoAsking without arguments got error %v, want %v", err, errNoCommand)
+	}
+}
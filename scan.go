@@ -0,0 +1,313 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+)
+
+// ScanOptions filter the SCAN family of commands. The zero value has no
+// filters.
+type ScanOptions struct {
+	// Match limits the return to names which match a glob-style pattern,
+	// when not empty. The filter applies after retrieval, so pages may be
+	// empty, even when the iteration did not complete.
+	Match string
+
+	// Count is a hint for the amount of work per page, when positive.
+	// The server defaults to 10.
+	Count int64
+
+	// Type limits the return to keys of a data type, like TypeZSet or
+	// TypeStream, when not empty. The option applies to SCAN only.
+	Type KeyType
+
+	// NoValues omits the values of hash fields. The option applies to
+	// HSCAN only, and it requires Redis 7.4.
+	NoValues bool
+}
+
+func (o *ScanOptions) args() []string {
+	args := make([]string, 0, 7)
+	if o.Match != "" {
+		args = append(args, "MATCH", o.Match)
+	}
+	if o.Count > 0 {
+		args = append(args, "COUNT", strconv.FormatInt(o.Count, 10))
+	}
+	if o.Type != "" {
+		args = append(args, "TYPE", string(o.Type))
+	}
+	if o.NoValues {
+		args = append(args, "NOVALUES")
+	}
+	return args
+}
+
+// SCAN executes <https://redis.io/commands/scan> for one page of keys. The
+// iteration starts with cursor "0", and it completes once the next cursor is
+// "0" again. See ScanKeys for an iterator.
+func (c *Client) SCAN(cursor string, o ScanOptions) (next string, keys []string, err error) {
+	o.NoValues = false
+	next, page, err := c.scan(append([]string{"SCAN", cursor}, o.args()...))
+	if err != nil {
+		return "", nil, err
+	}
+	keys = make([]string, len(page))
+	for i, b := range page {
+		keys[i] = string(b)
+	}
+	return next, keys, nil
+}
+
+// HSCAN executes <https://redis.io/commands/hscan> for one page of fields,
+// like SCAN. Each value is at the same index as its field. Values is nil with
+// the NoValues option.
+func (c *Client) HSCAN(key, cursor string, o ScanOptions) (next string, fields []string, values [][]byte, err error) {
+	o.Type = ""
+	next, page, err := c.scan(append([]string{"HSCAN", key, cursor}, o.args()...))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if o.NoValues {
+		fields = make([]string, len(page))
+		for i, b := range page {
+			fields[i] = string(b)
+		}
+		return next, fields, nil, nil
+	}
+	if len(page)&1 != 0 {
+		return "", nil, nil, fmt.Errorf("%w; HSCAN with %d elements", errProtocol, len(page))
+	}
+	fields = make([]string, len(page)/2)
+	values = make([][]byte, len(page)/2)
+	for i := range fields {
+		fields[i] = string(page[2*i])
+		values[i] = page[2*i+1]
+	}
+	return next, fields, values, nil
+}
+
+// SSCAN executes <https://redis.io/commands/sscan> for one page of members,
+// like SCAN.
+func (c *Client) SSCAN(key, cursor string, o ScanOptions) (next string, members [][]byte, err error) {
+	o.Type, o.NoValues = "", false
+	return c.scan(append([]string{"SSCAN", key, cursor}, o.args()...))
+}
+
+// ZSCAN executes <https://redis.io/commands/zscan> for one page of members,
+// like SCAN.
+func (c *Client) ZSCAN(key, cursor string, o ScanOptions) (next string, members []ZMember, err error) {
+	o.Type, o.NoValues = "", false
+	next, page, err := c.scan(append([]string{"ZSCAN", key, cursor}, o.args()...))
+	if err != nil {
+		return "", nil, err
+	}
+	if len(page)&1 != 0 {
+		return "", nil, fmt.Errorf("%w; ZSCAN with %d elements", errProtocol, len(page))
+	}
+	members = make([]ZMember, len(page)/2)
+	for i := range members {
+		score, err := ParseFloat(page[2*i+1])
+		if err != nil {
+			return "", nil, fmt.Errorf("%w; ZSCAN score %q", errProtocol, page[2*i+1])
+		}
+		members[i] = ZMember{Member: page[2*i], Score: score}
+	}
+	return next, members, nil
+}
+
+func (c *Client) scan(args []string) (next string, page [][]byte, err error) {
+	r := newRequestSize(len(args), "")
+	r.addStringList(args)
+	return c.commandScan(r)
+}
+
+// DecodeScan reads the cursor and the elements of a SCAN page.
+func decodeScan(r *bufio.Reader) (next string, page [][]byte, err error) {
+	l, err := readArrayLen(r)
+	if err != nil {
+		return "", nil, err
+	}
+	if l != 2 {
+		return "", nil, fmt.Errorf("%w; scan with %d elements", errProtocol, l)
+	}
+	next, err = decodeBlobString(r)
+	if err != nil {
+		return "", nil, err
+	}
+	page, err = decodeBytesArray(r)
+	if err != nil {
+		return "", nil, err
+	}
+	return next, page, nil
+}
+
+// Scanner iterates over the pages of a SCAN command, with a fixed number of
+// elements per position.
+type scanner struct {
+	// fetch executes the command for a page
+	fetch  func(cursor string) (next string, page [][]byte, err error)
+	stride int
+
+	cursor string
+	// remainder of the current page
+	page [][]byte
+	// elements of the current position
+	elem [][]byte
+	// cursor back at "0"
+	done bool
+	err  error
+}
+
+func (s *scanner) next() bool {
+	for len(s.page) == 0 {
+		if s.done || s.err != nil {
+			s.elem = nil
+			return false
+		}
+		next, page, err := s.fetch(s.cursor)
+		if err == nil && len(page)%s.stride != 0 {
+			err = fmt.Errorf("%w; scan page with %d elements", errProtocol, len(page))
+		}
+		if err != nil {
+			s.err = err
+			s.elem = nil
+			return false
+		}
+		s.cursor, s.page = next, page
+		s.done = next == "0"
+	}
+	s.elem, s.page = s.page[:s.stride], s.page[s.stride:]
+	return true
+}
+
+// KeyScanner iterates over keys with SCAN. The server guarantees a complete
+// iteration for keys which remain present all along. Keys may appear more than
+// once. A KeyScanner is not safe for concurrent use.
+type KeyScanner struct {
+	s scanner
+}
+
+// ScanKeys returns an iterator over all keys in the database, with the filters
+// of o. Commands execute on demand, i.e., the first one with Next.
+func (c *Client) ScanKeys(o ScanOptions) *KeyScanner {
+	o.NoValues = false
+	args := o.args()
+	return &KeyScanner{scanner{stride: 1, cursor: "0", fetch: func(cursor string) (string, [][]byte, error) {
+		return c.scan(append([]string{"SCAN", cursor}, args...))
+	}}}
+}
+
+// Next advances to the following key. The return is false when the iteration
+// completed, or when it failed. See Err for the distinction.
+func (s *KeyScanner) Next() bool { return s.s.next() }
+
+// Key returns the current key.
+func (s *KeyScanner) Key() string { return string(s.s.elem[0]) }
+
+// Err returns the error which stopped the iteration, if any.
+func (s *KeyScanner) Err() error { return s.s.err }
+
+// HashScanner iterates over the fields of a hash with HSCAN, like KeyScanner.
+type HashScanner struct {
+	s scanner
+}
+
+// ScanHash returns an iterator over the fields of key, with the filters of o.
+// Commands execute on demand, i.e., the first one with Next.
+func (c *Client) ScanHash(key string, o ScanOptions) *HashScanner {
+	o.Type = ""
+	args := o.args()
+	stride := 2
+	if o.NoValues {
+		stride = 1
+	}
+	return &HashScanner{scanner{stride: stride, cursor: "0", fetch: func(cursor string) (string, [][]byte, error) {
+		return c.scan(append([]string{"HSCAN", key, cursor}, args...))
+	}}}
+}
+
+// Next advances to the following field, like KeyScanner Next.
+func (s *HashScanner) Next() bool { return s.s.next() }
+
+// Field returns the current field.
+func (s *HashScanner) Field() string { return string(s.s.elem[0]) }
+
+// Value returns the value of the current field. The return is nil with the
+// NoValues option.
+func (s *HashScanner) Value() []byte {
+	if len(s.s.elem) < 2 {
+		return nil
+	}
+	return s.s.elem[1]
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (s *HashScanner) Err() error { return s.s.err }
+
+// SetScanner iterates over the members of a set with SSCAN, like KeyScanner.
+type SetScanner struct {
+	s scanner
+}
+
+// ScanSet returns an iterator over the members of key, with the filters of o.
+// Commands execute on demand, i.e., the first one with Next.
+func (c *Client) ScanSet(key string, o ScanOptions) *SetScanner {
+	o.Type, o.NoValues = "", false
+	args := o.args()
+	return &SetScanner{scanner{stride: 1, cursor: "0", fetch: func(cursor string) (string, [][]byte, error) {
+		return c.scan(append([]string{"SSCAN", key, cursor}, args...))
+	}}}
+}
+
+// Next advances to the following member, like KeyScanner Next.
+func (s *SetScanner) Next() bool { return s.s.next() }
+
+// Member returns the current member.
+func (s *SetScanner) Member() []byte { return s.s.elem[0] }
+
+// Err returns the error which stopped the iteration, if any.
+func (s *SetScanner) Err() error { return s.s.err }
+
+// SortedSetScanner iterates over the members of a sorted set with ZSCAN, like
+// KeyScanner.
+type SortedSetScanner struct {
+	s scanner
+	// of the current member
+	score float64
+}
+
+// ScanSortedSet returns an iterator over the members of key, with the filters
+// of o. Commands execute on demand, i.e., the first one with Next.
+func (c *Client) ScanSortedSet(key string, o ScanOptions) *SortedSetScanner {
+	o.Type, o.NoValues = "", false
+	args := o.args()
+	return &SortedSetScanner{s: scanner{stride: 2, cursor: "0", fetch: func(cursor string) (string, [][]byte, error) {
+		return c.scan(append([]string{"ZSCAN", key, cursor}, args...))
+	}}}
+}
+
+// Next advances to the following member, like KeyScanner Next. A malformed
+// score stops the iteration with an error.
+func (s *SortedSetScanner) Next() bool {
+	if !s.s.next() {
+		return false
+	}
+	score, err := ParseFloat(s.s.elem[1])
+	if err != nil {
+		s.s.err = fmt.Errorf("%w; ZSCAN score %q", errProtocol, s.s.elem[1])
+		s.s.elem = nil
+		return false
+	}
+	s.score = score
+	return true
+}
+
+// Member returns the current member with its score.
+func (s *SortedSetScanner) Member() ZMember {
+	return ZMember{Member: s.s.elem[0], Score: s.score}
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (s *SortedSetScanner) Err() error { return s.s.err }
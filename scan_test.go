@@ -0,0 +1,149 @@
+package redis
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestScanKeys(t *testing.T) {
+	t.Parallel()
+	prefix := randomKey("scan")
+
+	want := make([]string, 25)
+	for i := range want {
+		want[i] = prefix + "-" + strconv.Itoa(i)
+		if err := testClient.SETString(want[i], "v"); err != nil {
+			t.Fatal("population error:", err)
+		}
+	}
+	sort.Strings(want)
+
+	seen := make(map[string]bool)
+	s := testClient.ScanKeys(ScanOptions{Match: prefix + "-*", Count: 7, Type: TypeString})
+	for s.Next() {
+		seen[s.Key()] = true
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal("scan error:", err)
+	}
+	got := make([]string, 0, len(seen))
+	for k := range seen {
+		got = append(got, k)
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got keys %q, want %q", got, want)
+	}
+
+	// one page at a time
+	seen = make(map[string]bool)
+	cursor := "0"
+	for {
+		next, keys, err := testClient.SCAN(cursor, ScanOptions{Match: prefix + "-*"})
+		if err != nil {
+			t.Fatal("SCAN error:", err)
+		}
+		for _, k := range keys {
+			seen[k] = true
+		}
+		if next == "0" {
+			break
+		}
+		cursor = next
+	}
+	if len(seen) != len(want) {
+		t.Errorf("SCAN got %d keys, want %d", len(seen), len(want))
+	}
+}
+
+func TestScanHash(t *testing.T) {
+	t.Parallel()
+	key := randomKey("hash")
+
+	want := make(map[string]string)
+	for i := 0; i < 25; i++ {
+		field, value := "f"+strconv.Itoa(i), "v"+strconv.Itoa(i)
+		want[field] = value
+		if _, err := testClient.HSETString(key, field, value); err != nil {
+			t.Fatal("population error:", err)
+		}
+	}
+
+	got := make(map[string]string)
+	s := testClient.ScanHash(key, ScanOptions{Count: 4})
+	for s.Next() {
+		got[s.Field()] = string(s.Value())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal("scan error:", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	next, fields, values, err := testClient.HSCAN(key, "0", ScanOptions{Match: "f1", Count: 100})
+	if err != nil {
+		t.Fatal("HSCAN error:", err)
+	}
+	if next != "0" || len(fields) != 1 || fields[0] != "f1" || len(values) != 1 || string(values[0]) != "v1" {
+		t.Errorf(`HSCAN with MATCH f1 got %q, %q, %q; want "0", ["f1"], ["v1"]`, next, fields, values)
+	}
+
+	_, fields, values, err = testClient.HSCAN(key, "0", ScanOptions{Match: "f1", Count: 100, NoValues: true})
+	if err != nil {
+		t.Fatal("HSCAN with NOVALUES error:", err)
+	}
+	if len(fields) != 1 || fields[0] != "f1" || values != nil {
+		t.Errorf(`HSCAN with NOVALUES got %q, %q; want ["f1"], nil`, fields, values)
+	}
+}
+
+func TestScanSets(t *testing.T) {
+	t.Parallel()
+	setKey, zsetKey := randomKey("set"), randomKey("zset")
+
+	for i := 0; i < 25; i++ {
+		if _, err := testClient.SADDString(setKey, "m"+strconv.Itoa(i)); err != nil {
+			t.Fatal("population error:", err)
+		}
+		if _, err := testClient.ZADD(zsetKey, int64(i), []byte("m"+strconv.Itoa(i))); err != nil {
+			t.Fatal("population error:", err)
+		}
+	}
+
+	members := make(map[string]bool)
+	s := testClient.ScanSet(setKey, ScanOptions{Count: 4})
+	for s.Next() {
+		members[string(s.Member())] = true
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal("set scan error:", err)
+	}
+	if len(members) != 25 {
+		t.Errorf("set scan got %d members, want 25", len(members))
+	}
+
+	scores := make(map[string]float64)
+	z := testClient.ScanSortedSet(zsetKey, ScanOptions{Count: 4})
+	for z.Next() {
+		m := z.Member()
+		scores[string(m.Member)] = m.Score
+	}
+	if err := z.Err(); err != nil {
+		t.Fatal("sorted set scan error:", err)
+	}
+	if len(scores) != 25 || scores["m3"] != 3 {
+		t.Errorf("sorted set scan got %v, want 25 members with m3 at 3", scores)
+	}
+
+	// wrong type
+	w := testClient.ScanSortedSet(setKey, ScanOptions{})
+	if w.Next() {
+		t.Error("sorted set scan on set got a member")
+	}
+	if err := w.Err(); !IsWrongType(err) {
+		t.Errorf("sorted set scan on set got error %v, want WRONGTYPE", err)
+	}
+}
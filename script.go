@@ -0,0 +1,231 @@
+package redis
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+)
+
+// EVAL executes <https://redis.io/commands/eval>. The return is either nil for
+// null, an int64 for integers, a string for status replies, a []byte for blobs,
+// or an []interface{} for arrays. Array elements may also be a ServerError.
+func (c *Client) EVAL(script string, keys, args []string) (interface{}, error) {
+	r := newRequestSize(3+len(keys)+len(args), "\r\n$4\r\nEVAL\r\n$")
+	r.addStringIntStringListStringList(script, int64(len(keys)), keys, args)
+	return c.commandAny(r)
+}
+
+// EVALSHA executes <https://redis.io/commands/evalsha>. The return is either
+// nil for null, an int64 for integers, a string for status replies, a []byte
+// for blobs, or an []interface{} for arrays. Array elements may also be a
+// ServerError.
+func (c *Client) EVALSHA(sha1 string, keys, args []string) (interface{}, error) {
+	r := newRequestSize(3+len(keys)+len(args), "\r\n$7\r\nEVALSHA\r\n$")
+	r.addStringIntStringListStringList(sha1, int64(len(keys)), keys, args)
+	return c.commandAny(r)
+}
+
+// EVAL_RO executes <https://redis.io/commands/eval_ro>, which requires Redis
+// 7.0. The script must not modify any data. Execution goes to the replica with
+// the ReplicaReads option. The return is the same as EVAL.
+func (c *Client) EVAL_RO(script string, keys, args []string) (interface{}, error) {
+	r := newRequestSize(3+len(keys)+len(args), "\r\n$7\r\nEVAL_RO\r\n$")
+	r.readOnly = true
+	r.addStringIntStringListStringList(script, int64(len(keys)), keys, args)
+	return c.commandAny(r)
+}
+
+// EVALSHA_RO executes <https://redis.io/commands/evalsha_ro>, which requires
+// Redis 7.0. The script must not modify any data. Execution goes to the replica
+// with the ReplicaReads option. The return is the same as EVALSHA.
+func (c *Client) EVALSHA_RO(sha1 string, keys, args []string) (interface{}, error) {
+	r := newRequestSize(3+len(keys)+len(args), "\r\n$10\r\nEVALSHA_RO\r\n$")
+	r.readOnly = true
+	r.addStringIntStringListStringList(sha1, int64(len(keys)), keys, args)
+	return c.commandAny(r)
+}
+
+// SCRIPTLOAD executes <https://redis.io/commands/script-load>. The return is
+// the SHA1 digest for EVALSHA.
+func (c *Client) SCRIPTLOAD(script string) (sha1 string, err error) {
+	r := newRequest("*3\r\n$6\r\nSCRIPT\r\n$4\r\nLOAD\r\n$")
+	r.addString(script)
+	sha1, _, err = c.commandBlobString(r)
+	return sha1, err
+}
+
+// SCRIPTEXISTS executes <https://redis.io/commands/script-exists>.
+// The return has an entry for each digest, in order of appearance.
+func (c *Client) SCRIPTEXISTS(sha1s ...string) ([]bool, error) {
+	r := newRequestSize(2+len(sha1s), "\r\n$6\r\nSCRIPT\r\n$6\r\nEXISTS")
+	r.addStringList(sha1s)
+	a, err := c.commandIntegerArray(r)
+	return flagsOf(a), err
+}
+
+// SCRIPTFLUSH executes <https://redis.io/commands/script-flush>, which clears
+// the script cache. Without async, the mode defaults to the
+// lazyfree-lazy-user-flush configuration of the server. The ASYNC option
+// requires Redis 6.2.
+func (c *Client) SCRIPTFLUSH(async bool) error {
+	var r *request
+	if async {
+		r = newRequest("*3\r\n$6\r\nSCRIPT\r\n$5\r\nFLUSH\r\n$5\r\nASYNC\r\n")
+	} else {
+		r = newRequest("*2\r\n$6\r\nSCRIPT\r\n$5\r\nFLUSH\r\n")
+	}
+	return c.commandOK(r)
+}
+
+// SCRIPTKILL executes <https://redis.io/commands/script-kill>, which stops the
+// script in execution, if any, on the condition that it did not write yet.
+// Commands which got ErrBusy may succeed afterwards. Without a script in
+// execution, the return is a ServerError with the "NOTBUSY" prefix.
+func (c *Client) SCRIPTKILL() error {
+	return c.commandOK(newRequest("*2\r\n$6\r\nSCRIPT\r\n$4\r\nKILL\r\n"))
+}
+
+// Script is a Lua program for the Redis scripting engine. Execution goes by the
+// SHA1 digest of the source, such that the source needs no transfer once the
+// server cached it. Multiple goroutines may use a Script simultaneously.
+type Script struct {
+	// Src is the Lua source code. This field is read-only.
+	Src string
+
+	// SHA1 is the hexadecimal digest of Src. This field is read-only.
+	SHA1 string
+}
+
+// NewScript returns a Script for the Lua source code.
+func NewScript(src string) *Script {
+	sum := sha1.Sum([]byte(src))
+	return &Script{Src: src, SHA1: hex.EncodeToString(sum[:])}
+}
+
+// Load caches the script on the server with SCRIPT LOAD, such that the first
+// EVAL needs no fallback. The cache does not survive a server restart, nor a
+// SCRIPT FLUSH.
+func (s *Script) Load(c *Client) error {
+	sha1, err := c.SCRIPTLOAD(s.Src)
+	if err == nil && sha1 != s.SHA1 {
+		err = fmt.Errorf("%w; SCRIPT LOAD got digest %q, want %q", errProtocol, sha1, s.SHA1)
+	}
+	return err
+}
+
+// EVAL executes the script with <https://redis.io/commands/evalsha>. When the
+// server has no script for the digest, then EVALSHA falls back to EVAL, which
+// caches the script on the server for following executions. The return is the
+// same as Client EVAL.
+func (s *Script) EVAL(c *Client, keys, args []string) (interface{}, error) {
+	v, err := c.EVALSHA(s.SHA1, keys, args)
+	if IsNoScript(err) {
+		return c.EVAL(s.Src, keys, args)
+	}
+	return v, err
+}
+
+// EVAL_RO is like EVAL, with <https://redis.io/commands/evalsha_ro> and
+// <https://redis.io/commands/eval_ro> instead, for scripts which do not modify
+// any data.
+func (s *Script) EVAL_RO(c *Client, keys, args []string) (interface{}, error) {
+	v, err := c.EVALSHA_RO(s.SHA1, keys, args)
+	if IsNoScript(err) {
+		return c.EVAL_RO(s.Src, keys, args)
+	}
+	return v, err
+}
+
+// FUNCTIONLOAD executes <https://redis.io/commands/function-load>, which
+// requires Redis 7.0. The code starts with a shebang, with the engine and the
+// library name, e.g., "#!lua name=mylib". Replace permits an update of an
+// existing library. The return is the library name.
+func (c *Client) FUNCTIONLOAD(code string, replace bool) (library string, err error) {
+	var r *request
+	if replace {
+		r = newRequest("*4\r\n$8\r\nFUNCTION\r\n$4\r\nLOAD\r\n$7\r\nREPLACE\r\n$")
+	} else {
+		r = newRequest("*3\r\n$8\r\nFUNCTION\r\n$4\r\nLOAD\r\n$")
+	}
+	r.addString(code)
+	library, _, err = c.commandBlobString(r)
+	return library, err
+}
+
+// FUNCTIONDELETE executes <https://redis.io/commands/function-delete>, which
+// requires Redis 7.0.
+func (c *Client) FUNCTIONDELETE(library string) error {
+	r := newRequest("*3\r\n$8\r\nFUNCTION\r\n$6\r\nDELETE\r\n$")
+	r.addString(library)
+	return c.commandOK(r)
+}
+
+// FUNCTIONFLUSH executes <https://redis.io/commands/function-flush>, which
+// requires Redis 7.0. Without async, the mode defaults to the
+// lazyfree-lazy-user-flush configuration of the server.
+func (c *Client) FUNCTIONFLUSH(async bool) error {
+	var r *request
+	if async {
+		r = newRequest("*3\r\n$8\r\nFUNCTION\r\n$5\r\nFLUSH\r\n$5\r\nASYNC\r\n")
+	} else {
+		r = newRequest("*2\r\n$8\r\nFUNCTION\r\n$5\r\nFLUSH\r\n")
+	}
+	return c.commandOK(r)
+}
+
+// FunctionLibrary is a FUNCTION LIST element.
+type FunctionLibrary struct {
+	Name   string
+	Engine string
+
+	Functions []FunctionInfo
+
+	// Code is the source, when requested.
+	Code string
+}
+
+// FunctionInfo is a function from a library.
+type FunctionInfo struct {
+	Name string
+
+	// Description is optional.
+	Description string
+
+	// Flags has options like "no-writes".
+	Flags []string
+}
+
+// FUNCTIONLIST executes <https://redis.io/commands/function-list>, which
+// requires Redis 7.0. A non-empty pattern limits the libraries to the ones with
+// a matching name. WithCode includes the source of each library.
+func (c *Client) FUNCTIONLIST(pattern string, withCode bool) ([]FunctionLibrary, error) {
+	args := make([]string, 0, 4)
+	args = append(args, "LIST")
+	if pattern != "" {
+		args = append(args, "LIBRARYNAME", pattern)
+	}
+	if withCode {
+		args = append(args, "WITHCODE")
+	}
+	r := newRequestSize(1+len(args), "\r\n$8\r\nFUNCTION")
+	r.addStringList(args)
+	return c.commandFunctionLibraries(r)
+}
+
+// FCALL executes <https://redis.io/commands/fcall>, which requires Redis 7.0.
+// The return is the same as EVAL.
+func (c *Client) FCALL(function string, keys, args []string) (interface{}, error) {
+	r := newRequestSize(3+len(keys)+len(args), "\r\n$5\r\nFCALL\r\n$")
+	r.addStringIntStringListStringList(function, int64(len(keys)), keys, args)
+	return c.commandAny(r)
+}
+
+// FCALL_RO executes <https://redis.io/commands/fcall_ro>, which requires Redis
+// 7.0. The function must have the "no-writes" flag. Execution goes to the
+// replica with the ReplicaReads option. The return is the same as EVAL.
+func (c *Client) FCALL_RO(function string, keys, args []string) (interface{}, error) {
+	r := newRequestSize(3+len(keys)+len(args), "\r\n$8\r\nFCALL_RO\r\n$")
+	r.readOnly = true
+	r.addStringIntStringListStringList(function, int64(len(keys)), keys, args)
+	return c.commandAny(r)
+}
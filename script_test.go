@@ -0,0 +1,250 @@
+package redis
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEVAL(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test")
+	const script = `return {KEYS[1], ARGV[1], tonumber(ARGV[2]), redis.status_reply("FINE"), false}`
+
+	got, err := testClient.EVAL(script, []string{key}, []string{"arg", "42"})
+	if err != nil {
+		t.Fatal("EVAL error:", err)
+	}
+	want := []interface{}{[]byte(key), []byte("arg"), int64(42), "FINE", nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EVAL got %#v, want %#v", got, want)
+	}
+
+	_, err = testClient.EVAL(`return redis.error_reply("BOOM fail")`, nil, nil)
+	if e, ok := err.(ServerError); !ok {
+		t.Errorf("EVAL of error reply got error %v, want a ServerError", err)
+	} else if e.Prefix() != "BOOM" {
+		t.Errorf(`EVAL of error reply got prefix %q, want "BOOM"`, e.Prefix())
+	}
+}
+
+func TestScript(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test")
+	// unique source prevents a cache hit on the first run
+	s := NewScript(`return redis.call("INCRBY", KEYS[1], ARGV[1]) -- ` + key)
+
+	for i, want := range []int64{2, 4} {
+		got, err := s.EVAL(testClient, []string{key}, []string{"2"})
+		if err != nil {
+			t.Fatalf("run %d got error: %s", i+1, err)
+		}
+		if got != want {
+			t.Errorf("run %d got %#v, want %d", i+1, got, want)
+		}
+	}
+
+	if _, err := testClient.EVALSHA(s.SHA1, []string{key}, []string{"2"}); err != nil {
+		t.Error("EVALSHA after script run got error:", err)
+	}
+}
+
+func TestScriptReadOnly(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test")
+	if err := testClient.SETString(key, "v"); err != nil {
+		t.Fatal("SET error:", err)
+	}
+	// unique source prevents a cache hit on the first run
+	s := NewScript(`return redis.call("GET", KEYS[1]) -- ` + key)
+
+	for i := 1; i <= 2; i++ {
+		got, err := s.EVAL_RO(testClient, []string{key}, nil)
+		if err != nil {
+			t.Fatalf("run %d got error: %s", i, err)
+		}
+		if !reflect.DeepEqual(got, []byte("v")) {
+			t.Errorf("run %d got %#v, want %q", i, got, "v")
+		}
+	}
+
+	_, err := testClient.EVAL_RO(`return redis.call("DEL", KEYS[1])`, []string{key}, nil)
+	if _, ok := err.(ServerError); !ok {
+		t.Errorf("EVAL_RO with write got error %v, want a ServerError", err)
+	}
+}
+
+func TestScriptCache(t *testing.T) {
+	// no parallel SCRIPT FLUSH
+	key := randomKey("test")
+	s := NewScript(`return 1 -- ` + key)
+
+	if err := s.Load(testClient); err != nil {
+		t.Fatal("Load error:", err)
+	}
+	unknown := NewScript(`return 2 -- ` + key)
+	got, err := testClient.SCRIPTEXISTS(s.SHA1, unknown.SHA1)
+	if err != nil {
+		t.Fatal("SCRIPT EXISTS error:", err)
+	}
+	if want := []bool{true, false}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SCRIPT EXISTS got %t, want %t", got, want)
+	}
+
+	if err := testClient.SCRIPTFLUSH(false); err != nil {
+		t.Fatal("SCRIPT FLUSH error:", err)
+	}
+	if got, err := testClient.SCRIPTEXISTS(s.SHA1); err != nil {
+		t.Error("SCRIPT EXISTS error:", err)
+	} else if !reflect.DeepEqual(got, []bool{false}) {
+		t.Errorf("SCRIPT EXISTS after SCRIPT FLUSH got %t, want [false]", got)
+	}
+
+	err = testClient.SCRIPTKILL()
+	if e, ok := err.(ServerError); !ok || e.Prefix() != "NOTBUSY" {
+		t.Errorf("SCRIPT KILL without script in execution got error %v, want a NOTBUSY", err)
+	}
+}
+
+func TestFunctionLibrary(t *testing.T) {
+	t.Parallel()
+	lib := strings.Replace(randomKey("testlib"), "-", "", 1)
+	code := "#!lua name=" + lib + `
+redis.register_function('` + lib + `_echo', function(keys, args) return args[1] end)
+redis.register_function{function_name='` + lib + `_get', callback=function(keys) return redis.call('GET', keys[1]) end, flags={'no-writes'}}
+`
+
+	if name, err := testClient.FUNCTIONLOAD(code, false); err != nil {
+		t.Fatal("FUNCTION LOAD error:", err)
+	} else if name != lib {
+		t.Errorf("FUNCTION LOAD got library %q, want %q", name, lib)
+	}
+	if _, err := testClient.FUNCTIONLOAD(code, false); err == nil {
+		t.Error("FUNCTION LOAD of existing library got no error")
+	}
+	if _, err := testClient.FUNCTIONLOAD(code, true); err != nil {
+		t.Error("FUNCTION LOAD REPLACE error:", err)
+	}
+
+	libs, err := testClient.FUNCTIONLIST(lib, true)
+	if err != nil {
+		t.Fatal("FUNCTION LIST error:", err)
+	}
+	want := []FunctionLibrary{{
+		Name:   lib,
+		Engine: "LUA",
+		Functions: []FunctionInfo{
+			{Name: lib + "_echo", Flags: []string{}},
+			{Name: lib + "_get", Flags: []string{"no-writes"}},
+		},
+		Code: code,
+	}}
+	if len(libs) == 1 {
+		// order is not defined
+		sort.Slice(libs[0].Functions, func(i, j int) bool {
+			return libs[0].Functions[i].Name < libs[0].Functions[j].Name
+		})
+	}
+	if !reflect.DeepEqual(libs, want) {
+		t.Errorf("FUNCTION LIST got %+v, want %+v", libs, want)
+	}
+
+	if err := testClient.FUNCTIONDELETE(lib); err != nil {
+		t.Error("FUNCTION DELETE error:", err)
+	}
+	if libs, err := testClient.FUNCTIONLIST(lib, false); err != nil {
+		t.Error("FUNCTION LIST error:", err)
+	} else if len(libs) != 0 {
+		t.Errorf("FUNCTION LIST after FUNCTION DELETE got %+v, want none", libs)
+	}
+}
+
+func TestFCALL(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test")
+	lib := strings.Replace(randomKey("testlib"), "-", "", 1)
+	code := "#!lua name=" + lib + `
+redis.register_function('` + lib + `_set', function(keys, args) return redis.call('SET', keys[1], args[1]) end)
+redis.register_function{function_name='` + lib + `_get', callback=function(keys) return redis.call('GET', keys[1]) end, flags={'no-writes'}}
+`
+	if _, err := testClient.FUNCTIONLOAD(code, false); err != nil {
+		t.Fatal("FUNCTION LOAD error:", err)
+	}
+	defer testClient.FUNCTIONDELETE(lib)
+
+	if got, err := testClient.FCALL(lib+"_set", []string{key}, []string{"v"}); err != nil {
+		t.Error("FCALL error:", err)
+	} else if got != "OK" {
+		t.Errorf("FCALL got %#v, want OK", got)
+	}
+	if got, err := testClient.FCALL_RO(lib+"_get", []string{key}, nil); err != nil {
+		t.Error("FCALL_RO error:", err)
+	} else if !reflect.DeepEqual(got, []byte("v")) {
+		t.Errorf("FCALL_RO got %#v, want %q", got, "v")
+	}
+	if _, err := testClient.FCALL_RO(lib+"_set", []string{key}, []string{"w"}); err == nil {
+		t.Error("FCALL_RO of function without no-writes flag got no error")
+	}
+}
+
+func TestLock(t *testing.T) {
+	t.Parallel()
+	key := randomKey("lock")
+
+	lock, err := testClient.AcquireLock(key, time.Minute)
+	if err != nil {
+		t.Fatal("acquire error:", err)
+	}
+	if lock == nil {
+		t.Fatal("acquire of free lock got nil")
+	}
+	if len(lock.Token) != 32 {
+		t.Errorf("got token %q, want 32 hexadecimals", lock.Token)
+	}
+
+	if other, err := testClient.AcquireLock(key, time.Minute); err != nil {
+		t.Error("acquire of held lock error:", err)
+	} else if other != nil {
+		t.Errorf("acquire of held lock got token %q, want nil", other.Token)
+	}
+
+	if ok, err := lock.Refresh(2 * time.Minute); err != nil {
+		t.Error("refresh error:", err)
+	} else if !ok {
+		t.Error("refresh got false, want true")
+	}
+
+	// other owner must not release
+	impostor := &Lock{Key: key, Token: "impostor", c: testClient}
+	if ok, err := impostor.Release(); err != nil {
+		t.Error("release by other owner error:", err)
+	} else if ok {
+		t.Error("release by other owner got true, want false")
+	}
+	if ok, err := impostor.Refresh(time.Minute); err != nil {
+		t.Error("refresh by other owner error:", err)
+	} else if ok {
+		t.Error("refresh by other owner got true, want false")
+	}
+
+	if ok, err := lock.Release(); err != nil {
+		t.Error("release error:", err)
+	} else if !ok {
+		t.Error("release got false, want true")
+	}
+	if ok, err := lock.Release(); err != nil {
+		t.Error("second release error:", err)
+	} else if ok {
+		t.Error("second release got true, want false")
+	}
+
+	if again, err := testClient.AcquireLock(key, time.Minute); err != nil {
+		t.Error("acquire after release error:", err)
+	} else if again == nil {
+		t.Error("acquire after release got nil")
+	} else if again.Token == lock.Token {
+		t.Errorf("acquire after release got the same token %q", again.Token)
+	}
+}
@@ -0,0 +1,125 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// SentinelConfig defines master discovery with
+// <https://redis.io/topics/sentinel>.
+type SentinelConfig struct {
+	// Service is the master name, as monitored by the Sentinels.
+	Service string
+
+	// Addrs has the Sentinel nodes, in order of preference.
+	Addrs []string
+
+	// Optional AUTH [command] value applied to the Sentinel connections.
+	// The Password option of ClientOptions applies to the master only.
+	Password []byte
+}
+
+type sentinelDiscovery struct {
+	SentinelConfig
+
+	// query expiry, including connection establishment
+	dialTimeout, commandTimeout time.Duration
+}
+
+// NewSentinelClient is like NewClientWithOptions, with the master address of a
+// service as discovered with Sentinels. Each (re)connect queries the Sentinels
+// in order, until one of them has the master. A +switch-master event from any
+// of the Sentinels causes a reconnect on the next command submission, once all
+// responses in progress are read. The ReplicaAddr option is not supported.
+func NewSentinelClient(config SentinelConfig, commandTimeout, dialTimeout time.Duration, o ClientOptions) (*Client, error) {
+	if len(config.Addrs) == 0 {
+		return nil, errors.New("redis: no Sentinel addresses")
+	}
+	o.ReplicaAddr = ""
+
+	c := newClient("", commandTimeout, dialTimeout, o)
+	c.Addr = ""
+	c.sentinel = &sentinelDiscovery{
+		SentinelConfig: config,
+		dialTimeout:    c.dialTimeout,
+		commandTimeout: commandTimeout,
+	}
+
+	listeners := make([]*Listener, len(config.Addrs))
+	for i, addr := range config.Addrs {
+		listeners[i] = NewListener(ListenerConfig{
+			Func: func(channel string, message []byte, err error) {
+				// payload has "<name> <old-ip> <old-port> <new-ip> <new-port>"
+				if err == nil && channel == "+switch-master" && strings.HasPrefix(string(message), config.Service+" ") {
+					atomic.StoreInt32(&c.renew, 1)
+				}
+			},
+			Addr:           addr,
+			DialTimeout:    dialTimeout,
+			Password:       config.Password,
+			CommandTimeout: commandTimeout,
+		})
+		listeners[i].SUBSCRIBE("+switch-master")
+	}
+	go func() {
+		<-c.closed
+		for _, l := range listeners {
+			l.Close()
+		}
+	}()
+
+	c.launch()
+	return c, nil
+}
+
+// MasterAddr returns the address from the first Sentinel with an answer.
+func (d *sentinelDiscovery) masterAddr() (string, error) {
+	var err error
+	for _, addr := range d.Addrs {
+		var master string
+		master, err = d.query(normalizeAddr(addr))
+		if err == nil {
+			return master, nil
+		}
+	}
+	return "", fmt.Errorf("redis: Sentinel discovery of %q; last error: %w", d.Service, err)
+}
+
+func (d *sentinelDiscovery) query(addr string) (string, error) {
+	timeout := d.commandTimeout
+	if timeout == 0 {
+		timeout = d.dialTimeout
+	}
+	conn, reader, err := connect(connConfig{
+		BufferSize:     conservativeMSS,
+		Addr:           addr,
+		Password:       d.Password,
+		CommandTimeout: timeout,
+		DialTimeout:    d.dialTimeout,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	req := newRequest("*3\r\n$8\r\nSENTINEL\r\n$23\r\nGET-MASTER-ADDR-BY-NAME\r\n$")
+	defer req.free()
+	req.addString(d.Service)
+
+	conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := conn.Write(req.buf); err != nil {
+		return "", err
+	}
+	hostPort, err := decodeStringArray(reader)
+	if err == ErrNil || (err == nil && len(hostPort) != 2) {
+		return "", fmt.Errorf("redis: Sentinel %s has no master", addr)
+	}
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(hostPort[0], hostPort[1]), nil
+}
@@ -0,0 +1,143 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// NewSentinelServer serves the master address for service "mymaster", and
+// +switch-master subscriptions.
+func newSentinelServer(t *testing.T, master string) (ln net.Listener, switchMaster func()) {
+	host, port, err := net.SplitHostPort(master)
+	if err != nil {
+		t.Fatal("master address:", err)
+	}
+	ln, err = net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal("sentinel server unavailable:", err)
+	}
+
+	var mutex sync.Mutex
+	var subscribers []net.Conn
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					n, err := readArrayLen(r)
+					if err != nil || n == 0 {
+						return
+					}
+					args := make([]string, n)
+					for i := range args {
+						args[i], err = decodeBlobString(r)
+						if err != nil {
+							return
+						}
+					}
+
+					mutex.Lock()
+					switch args[0] {
+					case "SENTINEL":
+						if args[2] == "mymaster" {
+							fmt.Fprintf(conn, "*2\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(host), host, len(port), port)
+						} else {
+							fmt.Fprintf(conn, "*-1\r\n")
+						}
+					case "SUBSCRIBE":
+						fmt.Fprintf(conn, "*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:1\r\n", len(args[1]), args[1])
+						subscribers = append(subscribers, conn)
+					case "QUIT":
+						fmt.Fprintf(conn, "+OK\r\n")
+						mutex.Unlock()
+						return
+					default:
+						fmt.Fprintf(conn, "-ERR unknown command\r\n")
+					}
+					mutex.Unlock()
+				}
+			}()
+		}
+	}()
+
+	return ln, func() {
+		payload := fmt.Sprintf("mymaster 10.0.0.1 6379 %s %s", host, port)
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, conn := range subscribers {
+			fmt.Fprintf(conn, "*3\r\n$7\r\nmessage\r\n$14\r\n+switch-master\r\n$%d\r\n%s\r\n", len(payload), payload)
+		}
+	}
+}
+
+func TestSentinel(t *testing.T) {
+	t.Parallel()
+	server, switchMaster := newSentinelServer(t, testClient.Addr)
+	defer server.Close()
+
+	c, err := NewSentinelClient(SentinelConfig{
+		Service: "mymaster",
+		Addrs:   []string{"localhost:1", server.Addr().String()},
+	}, time.Second, 0, ClientOptions{Password: password})
+	if err != nil {
+		t.Fatal("NewSentinelClient error:", err)
+	}
+	defer c.Close()
+	states := make(chan StateChange, 10)
+	c.Notify(states)
+
+	if _, err := c.EXISTS(randomKey("test")); err != nil {
+		t.Fatal("EXISTS error:", err)
+	}
+	if state := c.State(); state.State != Online || state.Addr != testClient.Addr {
+		t.Fatalf("got state %+v, want Online at %q", state, testClient.Addr)
+	}
+
+	// await subscription
+	time.Sleep(100 * time.Millisecond)
+	switchMaster()
+	time.Sleep(100 * time.Millisecond)
+	if _, err := c.EXISTS(randomKey("test")); err != nil {
+		t.Fatal("EXISTS after switch error:", err)
+	}
+
+	var got []ConnState
+	for len(states) != 0 {
+		got = append(got, (<-states).State)
+	}
+	if len(got) < 2 || got[len(got)-2] != Offline || got[len(got)-1] != Online {
+		t.Errorf("got states %v, want a reconnect on switch", got)
+	}
+}
+
+func TestSentinelNoMaster(t *testing.T) {
+	t.Parallel()
+	server, _ := newSentinelServer(t, testClient.Addr)
+	defer server.Close()
+
+	c, err := NewSentinelClient(SentinelConfig{
+		Service: "nosuchmaster",
+		Addrs:   []string{server.Addr().String()},
+	}, time.Second, 0, ClientOptions{})
+	if err != nil {
+		t.Fatal("NewSentinelClient error:", err)
+	}
+	defer c.Close()
+
+	if _, err := c.EXISTS("k"); err == nil {
+		t.Error("EXISTS without master got no error")
+	}
+
+	if _, err := NewSentinelClient(SentinelConfig{Service: "mymaster"}, time.Second, 0, ClientOptions{}); err == nil {
+		t.Error("NewSentinelClient without addresses got no error")
+	}
+}
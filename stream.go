@@ -0,0 +1,486 @@
+package redis
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// StreamEntry is an element from a stream.
+type StreamEntry struct {
+	// ID is the unique identifier, formatted as
+	// <millisecondsTime>-<sequenceNumber>.
+	ID string
+
+	// Fields has the field–value pairs in order of appearance:
+	// a field name on each even index, followed by its value.
+	Fields []string
+}
+
+// Stream is a read result, with entries from a single key.
+type Stream struct {
+	Key     string
+	Entries []StreamEntry
+}
+
+// XADD executes <https://redis.io/commands/xadd>. Use "*" as the id to have the
+// server generate one. FieldVals must have a value after each field name. The
+// return is the ID of the entry added.
+func (c *Client) XADD(key, id string, fieldVals ...string) (string, error) {
+	if len(fieldVals)&1 != 0 {
+		return "", errMapSlices
+	}
+	r := newRequestSize(3+len(fieldVals), "\r\n$4\r\nXADD\r\n$")
+	r.addStringStringStringList(key, id, fieldVals)
+	id, _, err := c.commandBlobString(r)
+	return id, err
+}
+
+// XTrim is a size limit for streams, as applied by XADDWithOptions and XTRIM.
+type XTrim struct {
+	// MaxLen is the maximum number of entries, when positive.
+	MaxLen int64
+
+	// MinID evicts entries with a lower ID, when not empty. Use either
+	// MaxLen or MinID; not both.
+	MinID string
+
+	// Approx makes trimming efficient, with the "~" modifier. The server
+	// only evicts whole nodes, so the stream may hold more entries than
+	// MaxLen, or entries lower than MinID.
+	Approx bool
+
+	// Limit is the maximum number of entries evicted per call, when
+	// positive. Limit requires Approx.
+	Limit int64
+}
+
+var errXTrimStrategy = errors.New("redis: stream trim needs either MaxLen or MinID")
+
+// Args returns the command arguments, which are empty without MaxLen and
+// MinID.
+func (t *XTrim) args() ([]string, error) {
+	var args []string
+	switch {
+	case t.MaxLen > 0 && t.MinID != "":
+		return nil, errXTrimStrategy
+	case t.MaxLen > 0:
+		args = append(args, "MAXLEN", "=", strconv.FormatInt(t.MaxLen, 10))
+	case t.MinID != "":
+		args = append(args, "MINID", "=", t.MinID)
+	default:
+		if t.Approx || t.Limit > 0 {
+			return nil, errXTrimStrategy
+		}
+		return nil, nil
+	}
+	if t.Approx {
+		args[1] = "~"
+	}
+	if t.Limit > 0 {
+		if !t.Approx {
+			return nil, errors.New("redis: stream trim with Limit needs Approx")
+		}
+		args = append(args, "LIMIT", strconv.FormatInt(t.Limit, 10))
+	}
+	return args, nil
+}
+
+// XADDOptions are extra arguments for the XADD command.
+type XADDOptions struct {
+	// NoMkStream prevents the creation of a new stream, with NOMKSTREAM.
+	NoMkStream bool
+
+	// Trim applies to the stream after the addition, when set.
+	Trim XTrim
+}
+
+// XADDWithOptions executes <https://redis.io/commands/xadd> with options. Use
+// "*" as the id to have the server generate one. FieldVals must have a value
+// after each field name. The return is the ID of the entry added, which is the
+// empty string when NoMkStream applies to a key which does not exist.
+func (c *Client) XADDWithOptions(key, id string, o XADDOptions, fieldVals ...string) (string, error) {
+	if len(fieldVals)&1 != 0 {
+		return "", errMapSlices
+	}
+	trimArgs, err := o.Trim.args()
+	if err != nil {
+		return "", err
+	}
+	args := make([]string, 0, 3+len(trimArgs)+len(fieldVals))
+	args = append(args, key)
+	if o.NoMkStream {
+		args = append(args, "NOMKSTREAM")
+	}
+	args = append(args, trimArgs...)
+	args = append(args, id)
+	args = append(args, fieldVals...)
+
+	r := newRequestSize(1+len(args), "\r\n$4\r\nXADD")
+	r.addStringList(args)
+	id, _, err = c.commandBlobString(r)
+	return id, err
+}
+
+// XTRIM executes <https://redis.io/commands/xtrim>. The return is the number of
+// entries evicted.
+func (c *Client) XTRIM(key string, t XTrim) (int64, error) {
+	trimArgs, err := t.args()
+	if err != nil {
+		return 0, err
+	}
+	if len(trimArgs) == 0 {
+		return 0, errXTrimStrategy
+	}
+	r := newRequestSize(2+len(trimArgs), "\r\n$5\r\nXTRIM\r\n$")
+	r.addStringStringList(key, trimArgs)
+	return c.commandInteger(r)
+}
+
+// XDEL executes <https://redis.io/commands/xdel>. The return is the number of
+// entries deleted, which excludes the IDs not found.
+func (c *Client) XDEL(key string, ids ...string) (int64, error) {
+	r := newRequestSize(2+len(ids), "\r\n$4\r\nXDEL\r\n$")
+	r.addStringStringList(key, ids)
+	return c.commandInteger(r)
+}
+
+// XLEN executes <https://redis.io/commands/xlen>.
+// The return is 0 if key does not exist.
+func (c *Client) XLEN(key string) (int64, error) {
+	r := newRequest("*2\r\n$4\r\nXLEN\r\n$")
+	r.addString(key)
+	return c.commandInteger(r)
+}
+
+// XRANGE executes <https://redis.io/commands/xrange>. Use "-" and "+" for the
+// minimum and the maximum ID possible respectively. A count of zero or less
+// applies no limit. The return is empty if key does not exist.
+func (c *Client) XRANGE(key, start, end string, count int64) ([]StreamEntry, error) {
+	var r *request
+	if count > 0 {
+		r = newRequest("*6\r\n$6\r\nXRANGE\r\n$")
+		r.addStringStringStringStringInt(key, start, end, "COUNT", count)
+	} else {
+		r = newRequest("*4\r\n$6\r\nXRANGE\r\n$")
+		r.addStringStringString(key, start, end)
+	}
+	return c.commandStreamEntries(r)
+}
+
+// XREVRANGE executes <https://redis.io/commands/xrevrange>, which is like XRANGE
+// in reverse order. Note that end goes before start. Use "+" and "-" for the
+// maximum and the minimum ID possible respectively.
+func (c *Client) XREVRANGE(key, end, start string, count int64) ([]StreamEntry, error) {
+	var r *request
+	if count > 0 {
+		r = newRequest("*6\r\n$9\r\nXREVRANGE\r\n$")
+		r.addStringStringStringStringInt(key, end, start, "COUNT", count)
+	} else {
+		r = newRequest("*4\r\n$9\r\nXREVRANGE\r\n$")
+		r.addStringStringString(key, end, start)
+	}
+	return c.commandStreamEntries(r)
+}
+
+// XREAD executes <https://redis.io/commands/xread> without BLOCK. Each key reads
+// entries with an ID greater than the one at the same index in ids. A count of
+// zero or less applies no limit. The return has only the keys with entries, if
+// any.
+func (c *Client) XREAD(count int64, keys, ids []string) ([]Stream, error) {
+	return c.xread(count, 0, keys, ids)
+}
+
+// XREADBlock executes <https://redis.io/commands/xread> with BLOCK. When none of
+// the keys have entries available, then the server holds the response for up to
+// timeout, or indefinitely with a zero timeout. The return is nil on timeout.
+// The command timeout of the Client extends with the blocking timeout. The read
+// executes on a dedicated connection, like Watch, such that other commands on c
+// don't wait for its completion.
+func (c *Client) XREADBlock(count int64, timeout time.Duration, keys, ids []string) (streams []Stream, err error) {
+	err = c.onDedicated(func(d *Client) error {
+		streams, err = d.xread(count, blockOf(timeout), keys, ids)
+		return err
+	})
+	return streams, err
+}
+
+func (c *Client) xread(count int64, block time.Duration, keys, ids []string) ([]Stream, error) {
+	if len(keys) != len(ids) {
+		return nil, errMapSlices
+	}
+
+	args := make([]string, 0, 5+len(keys)+len(ids))
+	if count > 0 {
+		args = append(args, "COUNT", strconv.FormatInt(count, 10))
+	}
+	if block != 0 {
+		args = append(args, "BLOCK", strconv.FormatInt(blockMillis(block), 10))
+	}
+	args = append(args, "STREAMS")
+	args = append(args, keys...)
+	args = append(args, ids...)
+
+	r := newRequestSize(1+len(args), "\r\n$5\r\nXREAD")
+	r.addStringList(args)
+	return c.commandStreams(r, block)
+}
+
+// XGROUPCREATE executes <https://redis.io/commands/xgroup-create>. The group
+// starts reading after id, with "$" for the last entry in the stream, and "0"
+// for all entries. MkStream creates an empty stream when key does not exist.
+// An existing group gets an error which matches ErrBusyGroup.
+func (c *Client) XGROUPCREATE(key, group, id string, mkStream bool) error {
+	var r *request
+	if mkStream {
+		r = newRequest("*6\r\n$6\r\nXGROUP\r\n$6\r\nCREATE\r\n$")
+		r.addStringStringStringString(key, group, id, "MKSTREAM")
+	} else {
+		r = newRequest("*5\r\n$6\r\nXGROUP\r\n$6\r\nCREATE\r\n$")
+		r.addStringStringString(key, group, id)
+	}
+	return c.commandOK(r)
+}
+
+// XREADGROUP executes <https://redis.io/commands/xreadgroup> without BLOCK, on
+// behalf of consumer in group. Use ">" as the id to read entries never
+// delivered to any consumer of the group. Any other id reads the entries
+// delivered to consumer, and not acknowledged yet, with a greater ID. Such
+// history has nil Fields for the entries deleted since. A count of zero or less
+// applies no limit. The return has only the keys with entries, if any. A group
+// which does not exist gets an error which matches ErrNoGroup.
+func (c *Client) XREADGROUP(group, consumer string, count int64, keys, ids []string) ([]Stream, error) {
+	return c.xreadgroup(group, consumer, count, 0, keys, ids)
+}
+
+// XREADGROUPBlock executes <https://redis.io/commands/xreadgroup> with BLOCK.
+// When none of the keys have entries available, then the server holds the
+// response for up to timeout, or indefinitely with a zero timeout. The return
+// is nil on timeout. The read executes on a dedicated connection, like
+// XREADBlock.
+func (c *Client) XREADGROUPBlock(group, consumer string, count int64, timeout time.Duration, keys, ids []string) (streams []Stream, err error) {
+	err = c.onDedicated(func(d *Client) error {
+		streams, err = d.xreadgroup(group, consumer, count, blockOf(timeout), keys, ids)
+		return err
+	})
+	return streams, err
+}
+
+func (c *Client) xreadgroup(group, consumer string, count int64, block time.Duration, keys, ids []string) ([]Stream, error) {
+	if len(keys) != len(ids) {
+		return nil, errMapSlices
+	}
+
+	args := make([]string, 0, 8+len(keys)+len(ids))
+	args = append(args, "GROUP", group, consumer)
+	if count > 0 {
+		args = append(args, "COUNT", strconv.FormatInt(count, 10))
+	}
+	if block != 0 {
+		args = append(args, "BLOCK", strconv.FormatInt(blockMillis(block), 10))
+	}
+	args = append(args, "STREAMS")
+	args = append(args, keys...)
+	args = append(args, ids...)
+
+	r := newRequestSize(1+len(args), "\r\n$10\r\nXREADGROUP")
+	r.addStringList(args)
+	return c.commandStreams(r, block)
+}
+
+// XACK executes <https://redis.io/commands/xack>. The return is the number of
+// entries acknowledged, which excludes the IDs not pending in group.
+func (c *Client) XACK(key, group string, ids ...string) (int64, error) {
+	r := newRequestSize(3+len(ids), "\r\n$4\r\nXACK\r\n$")
+	r.addStringStringStringList(key, group, ids)
+	return c.commandInteger(r)
+}
+
+// XAUTOCLAIM executes <https://redis.io/commands/xautoclaim>, which requires
+// Redis 6.2. Entries pending in group for at least minIdle, with an ID of start
+// or greater, transfer to consumer. A count of zero or less applies the server
+// default of 100. The return has the ID to start the next call with, which is
+// "0-0" once the scan completed. Deleted contains the IDs of entries which no
+// longer exist, and which the server removed from the pending list as such, as
+// of Redis 7.0.
+func (c *Client) XAUTOCLAIM(key, group, consumer string, minIdle time.Duration, start string, count int64) (next string, entries []StreamEntry, deleted []string, err error) {
+	var r *request
+	if count > 0 {
+		r = newRequestSize(8, "\r\n$10\r\nXAUTOCLAIM")
+		r.addStringList([]string{key, group, consumer, strconv.FormatInt(minIdle.Milliseconds(), 10), start, "COUNT", strconv.FormatInt(count, 10)})
+	} else {
+		r = newRequest("*6\r\n$10\r\nXAUTOCLAIM\r\n$")
+		r.addStringStringStringIntString(key, group, consumer, minIdle.Milliseconds(), start)
+	}
+	return c.commandXAutoClaim(r)
+}
+
+// XPendingSummary is the XPENDING overview of a consumer group.
+type XPendingSummary struct {
+	// Count is the number of entries pending.
+	Count int64
+
+	// Lowest and Highest are the boundaries of the pending IDs, which
+	// are empty when Count is zero.
+	Lowest, Highest string
+
+	// Consumers has each consumer with pending entries.
+	Consumers []XPendingConsumer
+}
+
+// XPendingConsumer is a consumer with entries pending.
+type XPendingConsumer struct {
+	Name  string
+	Count int64
+}
+
+// XPendingEntry is an entry delivered, which is not acknowledged yet.
+type XPendingEntry struct {
+	ID string
+
+	// Consumer is the current owner.
+	Consumer string
+
+	// Idle is the duration since the last delivery to Consumer.
+	Idle time.Duration
+
+	// Deliveries is the number of times the entry was delivered,
+	// including claims.
+	Deliveries int64
+}
+
+// XPendingRange selects the pending entries for XPENDINGRange.
+type XPendingRange struct {
+	// Start and End are the boundaries of the IDs, inclusive. Use "-"
+	// and "+" for the minimum and the maximum ID possible respectively.
+	Start, End string
+
+	// Count is the maximum number of entries.
+	Count int64
+
+	// MinIdle excludes entries with less idle time, when positive. The
+	// option requires Redis 6.2.
+	MinIdle time.Duration
+
+	// Consumer limits the entries to one owner, when not empty.
+	Consumer string
+}
+
+// XPENDING executes <https://redis.io/commands/xpending> in summary form.
+// A group which does not exist gets an error which matches ErrNoGroup.
+func (c *Client) XPENDING(key, group string) (XPendingSummary, error) {
+	r := newRequest("*3\r\n$8\r\nXPENDING\r\n$")
+	r.addStringString(key, group)
+	return c.commandXPendingSummary(r)
+}
+
+// XPENDINGRange executes <https://redis.io/commands/xpending> in extended
+// form. The return is in order of ID. A group which does not exist gets an
+// error which matches ErrNoGroup.
+func (c *Client) XPENDINGRange(key, group string, q XPendingRange) ([]XPendingEntry, error) {
+	args := make([]string, 0, 8)
+	args = append(args, key, group)
+	if q.MinIdle > 0 {
+		args = append(args, "IDLE", strconv.FormatInt(q.MinIdle.Milliseconds(), 10))
+	}
+	args = append(args, q.Start, q.End, strconv.FormatInt(q.Count, 10))
+	if q.Consumer != "" {
+		args = append(args, q.Consumer)
+	}
+
+	r := newRequestSize(1+len(args), "\r\n$8\r\nXPENDING")
+	r.addStringList(args)
+	return c.commandXPendingEntries(r)
+}
+
+// XCLAIM executes <https://redis.io/commands/xclaim>. Entries pending in group
+// for at least minIdle transfer to consumer. The return has the entries
+// claimed, which excludes the IDs not pending, and the entries which no longer
+// exist in the stream.
+func (c *Client) XCLAIM(key, group, consumer string, minIdle time.Duration, ids ...string) ([]StreamEntry, error) {
+	r := newRequestSize(5+len(ids), "\r\n$6\r\nXCLAIM")
+	r.addStringList(append([]string{key, group, consumer, strconv.FormatInt(minIdle.Milliseconds(), 10)}, ids...))
+	return c.commandStreamEntries(r)
+}
+
+// XCLAIMJustID is like XCLAIM, but with the JUSTID option, which returns the IDs
+// only, and which does not increment the delivery count.
+func (c *Client) XCLAIMJustID(key, group, consumer string, minIdle time.Duration, ids ...string) ([]string, error) {
+	r := newRequestSize(6+len(ids), "\r\n$6\r\nXCLAIM")
+	r.addStringList(append(append([]string{key, group, consumer, strconv.FormatInt(minIdle.Milliseconds(), 10)}, ids...), "JUSTID"))
+	return c.commandStringArray(r)
+}
+
+// XInfoStream is the XINFO STREAM summary of a stream.
+type XInfoStream struct {
+	// Length is the number of entries.
+	Length int64
+
+	// LastGeneratedID is the highest ID added, which may have been
+	// deleted since.
+	LastGeneratedID string
+
+	// Groups is the number of consumer groups.
+	Groups int64
+
+	// FirstEntry and LastEntry are nil for empty streams.
+	FirstEntry, LastEntry *StreamEntry
+}
+
+// XInfoGroup is an XINFO GROUPS element.
+type XInfoGroup struct {
+	Name string
+
+	// Consumers is the number of consumers in the group.
+	Consumers int64
+
+	// Pending is the number of entries delivered, which are not
+	// acknowledged yet.
+	Pending int64
+
+	// LastDeliveredID is the highest ID delivered to the group.
+	LastDeliveredID string
+
+	// EntriesRead is the number of entries delivered to the group, and
+	// Lag is the number of entries yet to deliver. Both require Redis 7.0,
+	// and both are -1 when unknown.
+	EntriesRead, Lag int64
+}
+
+// XInfoConsumer is an XINFO CONSUMERS element.
+type XInfoConsumer struct {
+	Name string
+
+	// Pending is the number of entries delivered to the consumer, which
+	// are not acknowledged yet.
+	Pending int64
+
+	// Idle is the duration since the last interaction.
+	Idle time.Duration
+
+	// Inactive is the duration since the last successful interaction,
+	// with -1 when unknown. The value requires Redis 7.2.
+	Inactive time.Duration
+}
+
+// XINFOSTREAM executes <https://redis.io/commands/xinfo-stream>.
+func (c *Client) XINFOSTREAM(key string) (XInfoStream, error) {
+	r := newRequest("*3\r\n$5\r\nXINFO\r\n$6\r\nSTREAM\r\n$")
+	r.addString(key)
+	return c.commandXInfoStream(r)
+}
+
+// XINFOGROUPS executes <https://redis.io/commands/xinfo-groups>.
+func (c *Client) XINFOGROUPS(key string) ([]XInfoGroup, error) {
+	r := newRequest("*3\r\n$5\r\nXINFO\r\n$6\r\nGROUPS\r\n$")
+	r.addString(key)
+	return c.commandXInfoGroups(r)
+}
+
+// XINFOCONSUMERS executes <https://redis.io/commands/xinfo-consumers>. A group
+// which does not exist gets an error which matches ErrNoGroup.
+func (c *Client) XINFOCONSUMERS(key, group string) ([]XInfoConsumer, error) {
+	r := newRequest("*4\r\n$5\r\nXINFO\r\n$9\r\nCONSUMERS\r\n$")
+	r.addStringString(key, group)
+	return c.commandXInfoConsumers(r)
+}
@@ -0,0 +1,422 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestStreamCRUD(t *testing.T) {
+	t.Parallel()
+	key := randomKey("stream")
+
+	if id, err := testClient.XADD(key, "1-1", "name", "one"); err != nil {
+		t.Fatalf("XADD %q with explicit ID error: %s", key, err)
+	} else if id != "1-1" {
+		t.Errorf("XADD %q with explicit ID got %q, want %q", key, id, "1-1")
+	}
+	id2, err := testClient.XADD(key, "*", "name", "two", "extra", "")
+	if err != nil {
+		t.Fatalf("XADD %q with auto ID error: %s", key, err)
+	}
+	if _, err := testClient.XADD(key, "*", "odd"); err != errMapSlices {
+		t.Errorf("XADD with odd field count got error %v, want %v", err, errMapSlices)
+	}
+
+	if n, err := testClient.XLEN(key); err != nil {
+		t.Errorf("XLEN %q error: %s", key, err)
+	} else if n != 2 {
+		t.Errorf("XLEN %q got %d, want 2", key, n)
+	}
+
+	want := []StreamEntry{
+		{ID: "1-1", Fields: []string{"name", "one"}},
+		{ID: id2, Fields: []string{"name", "two", "extra", ""}},
+	}
+	if entries, err := testClient.XRANGE(key, "-", "+", 0); err != nil {
+		t.Errorf("XRANGE %q error: %s", key, err)
+	} else if !reflect.DeepEqual(entries, want) {
+		t.Errorf("XRANGE %q got %+v, want %+v", key, entries, want)
+	}
+	if entries, err := testClient.XRANGE(key, "-", "+", 1); err != nil {
+		t.Errorf("XRANGE %q COUNT 1 error: %s", key, err)
+	} else if !reflect.DeepEqual(entries, want[:1]) {
+		t.Errorf("XRANGE %q COUNT 1 got %+v, want %+v", key, entries, want[:1])
+	}
+
+	if streams, err := testClient.XREAD(0, []string{key}, []string{"1-1"}); err != nil {
+		t.Errorf("XREAD %q error: %s", key, err)
+	} else if w := []Stream{{Key: key, Entries: want[1:]}}; !reflect.DeepEqual(streams, w) {
+		t.Errorf("XREAD %q got %+v, want %+v", key, streams, w)
+	}
+	if streams, err := testClient.XREAD(0, []string{key}, []string{id2}); err != nil {
+		t.Errorf("XREAD %q after last error: %s", key, err)
+	} else if streams != nil {
+		t.Errorf("XREAD %q after last got %+v, want nil", key, streams)
+	}
+}
+
+func TestStreamTrim(t *testing.T) {
+	t.Parallel()
+	key := randomKey("stream")
+
+	for i := 1; i <= 5; i++ {
+		id := fmt.Sprintf("%d-0", i)
+		if _, err := testClient.XADDWithOptions(key, id, XADDOptions{Trim: XTrim{MaxLen: 4}}, "n", strconv.Itoa(i)); err != nil {
+			t.Fatalf("XADD %q MAXLEN 4 error: %s", id, err)
+		}
+	}
+	if n, err := testClient.XLEN(key); err != nil {
+		t.Errorf("XLEN %q error: %s", key, err)
+	} else if n != 4 {
+		t.Errorf("XLEN %q after XADD with MAXLEN 4 got %d", key, n)
+	}
+
+	if entries, err := testClient.XREVRANGE(key, "+", "-", 2); err != nil {
+		t.Errorf("XREVRANGE %q error: %s", key, err)
+	} else if want := []StreamEntry{{ID: "5-0", Fields: []string{"n", "5"}}, {ID: "4-0", Fields: []string{"n", "4"}}}; !reflect.DeepEqual(entries, want) {
+		t.Errorf("XREVRANGE %q got %+v, want %+v", key, entries, want)
+	}
+
+	if n, err := testClient.XDEL(key, "3-0", "9-0"); err != nil {
+		t.Errorf("XDEL %q error: %s", key, err)
+	} else if n != 1 {
+		t.Errorf("XDEL %q got %d, want 1", key, n)
+	}
+	if n, err := testClient.XTRIM(key, XTrim{MinID: "5"}); err != nil {
+		t.Errorf("XTRIM %q MINID error: %s", key, err)
+	} else if n != 2 {
+		t.Errorf("XTRIM %q MINID got %d, want 2", key, n)
+	}
+
+	absent := randomKey("stream")
+	if id, err := testClient.XADDWithOptions(absent, "*", XADDOptions{NoMkStream: true}, "n", "0"); err != nil {
+		t.Errorf("XADD %q NOMKSTREAM error: %s", absent, err)
+	} else if id != "" {
+		t.Errorf("XADD %q NOMKSTREAM got ID %q, want none", absent, id)
+	}
+
+	for _, trim := range []XTrim{{}, {MaxLen: 1, MinID: "1"}, {MaxLen: 1, Limit: 10}, {Approx: true}} {
+		if _, err := testClient.XTRIM(key, trim); err == nil {
+			t.Errorf("XTRIM with %+v got no error", trim)
+		}
+	}
+}
+
+func TestStreamAbsent(t *testing.T) {
+	t.Parallel()
+	key := randomKey("stream")
+
+	if n, err := testClient.XLEN(key); err != nil {
+		t.Errorf("XLEN %q error: %s", key, err)
+	} else if n != 0 {
+		t.Errorf("XLEN %q got %d, want 0", key, n)
+	}
+	if entries, err := testClient.XRANGE(key, "-", "+", 0); err != nil {
+		t.Errorf("XRANGE %q error: %s", key, err)
+	} else if len(entries) != 0 {
+		t.Errorf("XRANGE %q got %+v, want none", key, entries)
+	}
+}
+
+func TestXREADBlock(t *testing.T) {
+	t.Parallel()
+	key := randomKey("stream")
+
+	// command timeout must not apply to the blocking duration
+	c := NewClient(testClient.Addr, 100*time.Millisecond, 0)
+	defer c.Close()
+
+	// no wait for the blocking read
+	pingDone := make(chan time.Duration, 1)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		start := time.Now()
+		if err := c.PING(); err != nil {
+			t.Error("PING error:", err)
+		}
+		pingDone <- time.Since(start)
+	}()
+
+	start := time.Now()
+	if streams, err := c.XREADBlock(0, 200*time.Millisecond, []string{key}, []string{"$"}); err != nil {
+		t.Fatalf("XREAD BLOCK %q error: %s", key, err)
+	} else if streams != nil {
+		t.Errorf("XREAD BLOCK %q got %+v, want nil", key, streams)
+	}
+	if d := time.Since(start); d < 200*time.Millisecond {
+		t.Errorf("XREAD BLOCK 200 ms returned after %s", d)
+	}
+	if d := <-pingDone; d > 100*time.Millisecond {
+		t.Errorf("PING during XREAD BLOCK took %s", d)
+	}
+
+	// testClient may have another database selected
+	w := NewClient(testClient.Addr, time.Second, 0)
+	defer w.Close()
+	go func() {
+		time.Sleep(150 * time.Millisecond)
+		if _, err := w.XADD(key, "*", "k", "v"); err != nil {
+			t.Errorf("XADD %q error: %s", key, err)
+		}
+	}()
+	streams, err := c.XREADBlock(1, 0, []string{key}, []string{"0"})
+	if err != nil {
+		t.Fatalf("XREAD BLOCK 0 %q error: %s", key, err)
+	}
+	if len(streams) != 1 || streams[0].Key != key || len(streams[0].Entries) != 1 {
+		t.Errorf("XREAD BLOCK 0 %q got %+v, want 1 entry", key, streams)
+	}
+}
+
+func TestXREADGROUP(t *testing.T) {
+	t.Parallel()
+	key := randomKey("stream")
+
+	if _, err := testClient.XREADGROUP("g", "c1", 0, []string{key}, []string{">"}); !errors.Is(err, ErrNoGroup) {
+		t.Errorf("XREADGROUP %q without group got error %v, want a NOGROUP", key, err)
+	}
+	if err := testClient.XGROUPCREATE(key, "g", "$", true); err != nil {
+		t.Fatalf("XGROUP CREATE %q MKSTREAM error: %s", key, err)
+	}
+	if err := testClient.XGROUPCREATE(key, "g", "$", false); !errors.Is(err, ErrBusyGroup) {
+		t.Errorf("XGROUP CREATE %q again got error %v, want a BUSYGROUP", key, err)
+	}
+
+	id1, err := testClient.XADD(key, "*", "n", "1")
+	if err != nil {
+		t.Fatal("XADD error:", err)
+	}
+	id2, err := testClient.XADD(key, "*", "n", "2")
+	if err != nil {
+		t.Fatal("XADD error:", err)
+	}
+
+	streams, err := testClient.XREADGROUP("g", "c1", 1, []string{key}, []string{">"})
+	if err != nil {
+		t.Fatalf("XREADGROUP %q error: %s", key, err)
+	}
+	want := []Stream{{Key: key, Entries: []StreamEntry{{ID: id1, Fields: []string{"n", "1"}}}}}
+	if !reflect.DeepEqual(streams, want) {
+		t.Errorf("XREADGROUP %q got %+v, want %+v", key, streams, want)
+	}
+	streams, err = testClient.XREADGROUP("g", "c2", 0, []string{key}, []string{">"})
+	if err != nil {
+		t.Fatalf("XREADGROUP %q error: %s", key, err)
+	}
+	want = []Stream{{Key: key, Entries: []StreamEntry{{ID: id2, Fields: []string{"n", "2"}}}}}
+	if !reflect.DeepEqual(streams, want) {
+		t.Errorf("XREADGROUP %q got %+v, want %+v", key, streams, want)
+	}
+
+	// pending history with a deleted entry
+	if _, err := testClient.XDEL(key, id1); err != nil {
+		t.Fatal("XDEL error:", err)
+	}
+	streams, err = testClient.XREADGROUP("g", "c1", 0, []string{key}, []string{"0"})
+	if err != nil {
+		t.Fatalf("XREADGROUP %q history error: %s", key, err)
+	}
+	want = []Stream{{Key: key, Entries: []StreamEntry{{ID: id1}}}}
+	if !reflect.DeepEqual(streams, want) {
+		t.Errorf("XREADGROUP %q history got %+v, want %+v", key, streams, want)
+	}
+
+	if n, err := testClient.XACK(key, "g", id1, id2, "1-1"); err != nil {
+		t.Errorf("XACK %q error: %s", key, err)
+	} else if n != 2 {
+		t.Errorf("XACK %q got %d, want 2", key, n)
+	}
+	streams, err = testClient.XREADGROUP("g", "c1", 0, []string{key}, []string{"0"})
+	if err != nil {
+		t.Fatalf("XREADGROUP %q history error: %s", key, err)
+	}
+	if len(streams) != 1 || len(streams[0].Entries) != 0 {
+		t.Errorf("XREADGROUP %q history after XACK got %+v, want no entries", key, streams)
+	}
+}
+
+func TestXREADGROUPBlock(t *testing.T) {
+	t.Parallel()
+	key := randomKey("stream")
+
+	// testClient may have another database selected
+	c := NewClient(testClient.Addr, 100*time.Millisecond, 0)
+	defer c.Close()
+	if err := c.XGROUPCREATE(key, "g", "$", true); err != nil {
+		t.Fatalf("XGROUP CREATE %q MKSTREAM error: %s", key, err)
+	}
+
+	start := time.Now()
+	if streams, err := c.XREADGROUPBlock("g", "c", 0, 200*time.Millisecond, []string{key}, []string{">"}); err != nil {
+		t.Fatalf("XREADGROUP BLOCK %q error: %s", key, err)
+	} else if streams != nil {
+		t.Errorf("XREADGROUP BLOCK %q got %+v, want nil", key, streams)
+	}
+	if d := time.Since(start); d < 200*time.Millisecond {
+		t.Errorf("XREADGROUP BLOCK 200 ms returned after %s", d)
+	}
+
+	w := NewClient(testClient.Addr, time.Second, 0)
+	defer w.Close()
+	go func() {
+		time.Sleep(150 * time.Millisecond)
+		if _, err := w.XADD(key, "*", "k", "v"); err != nil {
+			t.Errorf("XADD %q error: %s", key, err)
+		}
+	}()
+	streams, err := c.XREADGROUPBlock("g", "c", 1, 0, []string{key}, []string{">"})
+	if err != nil {
+		t.Fatalf("XREADGROUP BLOCK 0 %q error: %s", key, err)
+	}
+	if len(streams) != 1 || streams[0].Key != key || len(streams[0].Entries) != 1 {
+		t.Errorf("XREADGROUP BLOCK 0 %q got %+v, want 1 entry", key, streams)
+	}
+}
+
+func TestXPENDING(t *testing.T) {
+	t.Parallel()
+	key := randomKey("stream")
+
+	if err := testClient.XGROUPCREATE(key, "g", "0", true); err != nil {
+		t.Fatalf("XGROUP CREATE %q MKSTREAM error: %s", key, err)
+	}
+	if summary, err := testClient.XPENDING(key, "g"); err != nil {
+		t.Fatalf("XPENDING %q error: %s", key, err)
+	} else if !reflect.DeepEqual(summary, XPendingSummary{}) {
+		t.Errorf("XPENDING %q got %+v, want zero", key, summary)
+	}
+
+	for _, id := range []string{"1-1", "2-1", "3-1"} {
+		if _, err := testClient.XADD(key, id, "k", "v"); err != nil {
+			t.Fatal("XADD error:", err)
+		}
+	}
+	if _, err := testClient.XREADGROUP("g", "c1", 2, []string{key}, []string{">"}); err != nil {
+		t.Fatal("XREADGROUP error:", err)
+	}
+	if _, err := testClient.XREADGROUP("g", "c2", 0, []string{key}, []string{">"}); err != nil {
+		t.Fatal("XREADGROUP error:", err)
+	}
+
+	summary, err := testClient.XPENDING(key, "g")
+	if err != nil {
+		t.Fatalf("XPENDING %q error: %s", key, err)
+	}
+	want := XPendingSummary{Count: 3, Lowest: "1-1", Highest: "3-1",
+		Consumers: []XPendingConsumer{{"c1", 2}, {"c2", 1}}}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("XPENDING %q got %+v, want %+v", key, summary, want)
+	}
+
+	entries, err := testClient.XPENDINGRange(key, "g", XPendingRange{Start: "-", End: "+", Count: 10, Consumer: "c1"})
+	if err != nil {
+		t.Fatalf("XPENDING %q - + 10 c1 error: %s", key, err)
+	}
+	if len(entries) != 2 || entries[0].ID != "1-1" || entries[1].ID != "2-1" || entries[0].Consumer != "c1" || entries[0].Deliveries != 1 {
+		t.Errorf("XPENDING %q - + 10 c1 got %+v, want entries 1-1 and 2-1 delivered once", key, entries)
+	}
+	if entries, err := testClient.XPENDINGRange(key, "g", XPendingRange{Start: "-", End: "+", Count: 10, MinIdle: time.Hour}); err != nil {
+		t.Errorf("XPENDING %q IDLE error: %s", key, err)
+	} else if len(entries) != 0 {
+		t.Errorf("XPENDING %q IDLE 1 hour got %+v, want none", key, entries)
+	}
+
+	claimed, err := testClient.XCLAIM(key, "g", "c2", 0, "1-1", "9-9")
+	if err != nil {
+		t.Fatalf("XCLAIM %q error: %s", key, err)
+	}
+	if want := []StreamEntry{{ID: "1-1", Fields: []string{"k", "v"}}}; !reflect.DeepEqual(claimed, want) {
+		t.Errorf("XCLAIM %q got %+v, want %+v", key, claimed, want)
+	}
+	if ids, err := testClient.XCLAIMJustID(key, "g", "c2", 0, "2-1"); err != nil {
+		t.Errorf("XCLAIM %q JUSTID error: %s", key, err)
+	} else if !reflect.DeepEqual(ids, []string{"2-1"}) {
+		t.Errorf("XCLAIM %q JUSTID got %q, want [2-1]", key, ids)
+	}
+	if _, err := testClient.XDEL(key, "3-1"); err != nil {
+		t.Fatal("XDEL error:", err)
+	}
+
+	next, entries2, deleted, err := testClient.XAUTOCLAIM(key, "g", "c3", 0, "0", 0)
+	if err != nil {
+		t.Fatalf("XAUTOCLAIM %q error: %s", key, err)
+	}
+	if next != "0-0" || len(entries2) != 2 || !reflect.DeepEqual(deleted, []string{"3-1"}) {
+		t.Errorf("XAUTOCLAIM %q got next %q, entries %+v and deleted %q; want 0-0, 2 entries and [3-1]", key, next, entries2, deleted)
+	}
+
+	entries, err = testClient.XPENDINGRange(key, "g", XPendingRange{Start: "-", End: "+", Count: 10})
+	if err != nil {
+		t.Fatalf("XPENDING %q - + 10 error: %s", key, err)
+	}
+	if len(entries) != 2 || entries[0].Consumer != "c3" || entries[0].Deliveries != 3 {
+		t.Errorf("XPENDING %q - + 10 got %+v, want 2 entries of c3, with 3 deliveries on the first", key, entries)
+	}
+}
+
+func TestXINFO(t *testing.T) {
+	t.Parallel()
+	key := randomKey("stream")
+
+	if err := testClient.XGROUPCREATE(key, "g", "0", true); err != nil {
+		t.Fatalf("XGROUP CREATE %q MKSTREAM error: %s", key, err)
+	}
+	info, err := testClient.XINFOSTREAM(key)
+	if err != nil {
+		t.Fatalf("XINFO STREAM %q error: %s", key, err)
+	}
+	if want := (XInfoStream{LastGeneratedID: "0-0", Groups: 1}); !reflect.DeepEqual(info, want) {
+		t.Errorf("XINFO STREAM %q got %+v, want %+v", key, info, want)
+	}
+
+	for _, id := range []string{"1-1", "2-1", "3-1"} {
+		if _, err := testClient.XADD(key, id, "k", id); err != nil {
+			t.Fatal("XADD error:", err)
+		}
+	}
+	if _, err := testClient.XREADGROUP("g", "c", 2, []string{key}, []string{">"}); err != nil {
+		t.Fatal("XREADGROUP error:", err)
+	}
+
+	info, err = testClient.XINFOSTREAM(key)
+	if err != nil {
+		t.Fatalf("XINFO STREAM %q error: %s", key, err)
+	}
+	want := XInfoStream{Length: 3, LastGeneratedID: "3-1", Groups: 1,
+		FirstEntry: &StreamEntry{ID: "1-1", Fields: []string{"k", "1-1"}},
+		LastEntry:  &StreamEntry{ID: "3-1", Fields: []string{"k", "3-1"}},
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("XINFO STREAM %q got %+v, want %+v", key, info, want)
+	}
+
+	groups, err := testClient.XINFOGROUPS(key)
+	if err != nil {
+		t.Fatalf("XINFO GROUPS %q error: %s", key, err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("XINFO GROUPS %q got %+v, want 1 group", key, groups)
+	}
+	g := groups[0]
+	if g.Name != "g" || g.Consumers != 1 || g.Pending != 2 || g.LastDeliveredID != "2-1" {
+		t.Errorf("XINFO GROUPS %q got %+v, want group g with 1 consumer, 2 pending, up to 2-1", key, g)
+	}
+	if g.Lag != -1 && g.Lag != 1 {
+		t.Errorf("XINFO GROUPS %q got lag %d, want 1", key, g.Lag)
+	}
+
+	consumers, err := testClient.XINFOCONSUMERS(key, "g")
+	if err != nil {
+		t.Fatalf("XINFO CONSUMERS %q error: %s", key, err)
+	}
+	if len(consumers) != 1 || consumers[0].Name != "c" || consumers[0].Pending != 2 || consumers[0].Idle < 0 {
+		t.Errorf("XINFO CONSUMERS %q got %+v, want consumer c with 2 pending", key, consumers)
+	}
+	if _, err := testClient.XINFOCONSUMERS(key, "nosuchgroup"); !errors.Is(err, ErrNoGroup) {
+		t.Errorf("XINFO CONSUMERS %q without group got error %v, want a NOGROUP", key, err)
+	}
+}
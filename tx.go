@@ -0,0 +1,316 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync/atomic"
+)
+
+// Result is the outcome of a command in a Tx or a Pipeline, available once
+// executed.
+type Result struct {
+	reply Reply
+	err   error
+}
+
+var errNotExecuted = errors.New("redis: result of command not executed")
+
+// ErrWatch is a transaction which did not execute, because any of the keys
+// watched changed.
+var ErrWatch = errors.New("redis: transaction aborted on change of watched key")
+
+// Reply returns the response. Error replies return as a ServerError.
+func (r *Result) Reply() (Reply, error) { return r.reply, r.err }
+
+// Err returns the error, if any.
+func (r *Result) Err() error { return r.err }
+
+// Int returns the response like Reply Int.
+func (r *Result) Int() (int64, error) { return r.reply.Int(), r.err }
+
+// Bytes returns the response like Reply Bytes. The return is nil for null.
+func (r *Result) Bytes() ([]byte, error) { return r.reply.Bytes(), r.err }
+
+// Str returns the response like Reply Str.
+func (r *Result) Str() (string, error) { return r.reply.Str(), r.err }
+
+// Tx is a transaction with <https://redis.io/topics/transactions>. Commands
+// queue locally, until Exec submits them all in one write, wrapped in MULTI and
+// EXEC. Other commands on the Client can't get in between as a result. A Tx is
+// not safe for concurrent use.
+type Tx struct {
+	// Client is the executor of the transaction.
+	Client *Client
+
+	cmds    [][]interface{}
+	results []*Result
+	// first argument error, if any
+	err error
+
+	// connection with the WATCH state, if any
+	pin net.Conn
+}
+
+// Tx returns a new transaction on c.
+func (c *Client) Tx() *Tx {
+	return &Tx{Client: c}
+}
+
+// Queue adds a command for execution. The arguments go like Do. The Result is
+// available once Exec returned.
+func (tx *Tx) Queue(args ...interface{}) *Result {
+	r := &Result{err: errNotExecuted}
+	if err := checkDoArgs(args); err != nil && tx.err == nil {
+		tx.err = err
+	}
+	tx.cmds = append(tx.cmds, args)
+	tx.results = append(tx.results, r)
+	return r
+}
+
+// Len returns the number of commands queued.
+func (tx *Tx) Len() int { return len(tx.cmds) }
+
+// Exec submits the commands queued, after which the Tx is empty again. A nil
+// return means that the transaction executed. Individual commands may still
+// have failed, each with a ServerError in its Result. When the server rejects
+// any of the commands during queueing, then none of them execute. The return
+// matches ErrExecAbort in such case, and the Result of the rejected commands
+// have the cause. The return is ErrWatch when the transaction did not execute
+// because of a change to a key watched. Any other error applies to all Results.
+func (tx *Tx) Exec() error {
+	cmds, results, err := tx.cmds, tx.results, tx.err
+	tx.cmds, tx.results, tx.err = nil, nil, nil
+	if err == nil {
+		err = tx.exec(cmds, results)
+	}
+	if err != nil {
+		for _, r := range results {
+			if r.err == errNotExecuted {
+				r.err = err
+			}
+		}
+	}
+	return err
+}
+
+func (tx *Tx) exec(cmds [][]interface{}, results []*Result) error {
+	req := newRequest("*1\r\n$5\r\nMULTI\r\n")
+	if err := req.addCommandList(cmds); err != nil {
+		req.free()
+		return err
+	}
+	req.buf = append(req.buf, "*1\r\n$4\r\nEXEC\r\n"...)
+	req.pin = tx.pin
+	req.more = len(cmds) + 1
+
+	reader, err := tx.Client.submit(req)
+	if err != nil {
+		return err
+	}
+
+	// MULTI and each command get a response
+	if err := decodeOK(reader); err != nil {
+		if _, ok := err.(ServerError); !ok {
+			tx.Client.pass(reader, err)
+			return err
+		}
+		// MULTI calls can not be nested; discard all responses
+		multiErr := err
+		for i := 0; i <= len(results); i++ {
+			if _, err := decodeReply(reader); err != nil {
+				if _, ok := err.(ServerError); !ok {
+					tx.Client.pass(reader, err)
+					return err
+				}
+			}
+		}
+		tx.Client.pass(reader, nil)
+		return multiErr
+	}
+	for _, r := range results {
+		if err := tx.Client.dispatchPush(reader); err != nil {
+			tx.Client.pass(reader, err)
+			return err
+		}
+		_, err := decodeSimpleString(reader)
+		if err != nil {
+			if _, ok := err.(ServerError); !ok {
+				tx.Client.pass(reader, err)
+				return err
+			}
+			r.err = err
+		}
+	}
+
+	// EXEC with all responses
+	if err := tx.Client.dispatchPush(reader); err != nil {
+		tx.Client.pass(reader, err)
+		return err
+	}
+	reply, err := decodeReply(reader)
+	if err != nil {
+		if _, ok := err.(ServerError); !ok {
+			tx.Client.pass(reader, err)
+			return err
+		}
+	}
+	tx.Client.pass(reader, nil)
+	if err != nil {
+		return err // EXECABORT
+	}
+	if reply.IsNil() {
+		return ErrWatch
+	}
+	if reply.Type() != ArrayReply || len(reply.array) != len(results) {
+		return fmt.Errorf("%w; EXEC got %s for %d commands", errProtocol, reply, len(results))
+	}
+	for i, v := range reply.array {
+		results[i].reply = v
+		results[i].err = v.Err()
+	}
+	return nil
+}
+
+// AddCommandList appends each command with its arguments, like Do.
+func (r *request) addCommandList(cmds [][]interface{}) error {
+	for _, args := range cmds {
+		if uint64(len(args)) > ElementMax {
+			r.invalid = errElementMax
+		}
+		r.buf = append(r.buf, '*')
+		r.buf = strconv.AppendUint(r.buf, uint64(len(args)), 10)
+		r.buf = append(r.buf, '\r', '\n', '$')
+		if err := r.addAnyList(args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WatchRetryMax is the number of times Watch repeats on ErrWatch.
+const WatchRetryMax = 9
+
+// DedicatedIdleMax is the number of connections Watch and the blocking reads
+// keep for reuse.
+const dedicatedIdleMax = 4
+
+// Watch executes a transaction with optimistic locking, with WATCH on keys.
+// The transaction runs on a dedicated connection, such that the commands of
+// others can not change the watch state. Fn may read with the Client of the
+// Tx, and it queues the commands for execution. A non-nil return from fn
+// cancels the transaction, and Watch returns with the error as is. Fn gets
+// invoked again when any of the keys changed before EXEC, for up to
+// WatchRetryMax times. The return is ErrWatch once the retries exhausted. The
+// return is ErrConnLost, without execution, when the connection got replaced
+// after WATCH, as the watch state is lost with the connection. This includes
+// retries from the ReadRetries option.
+func (c *Client) Watch(keys []string, fn func(tx *Tx) error) error {
+	if len(keys) == 0 {
+		return errors.New("redis: Watch needs a key")
+	}
+	d := c.checkoutDedicated()
+
+	for retry := 0; ; retry++ {
+		req := newRequestSize(1+len(keys), "\r\n$5\r\nWATCH")
+		req.addStringList(keys)
+		if err := d.commandOK(req); err != nil {
+			d.Close()
+			return err
+		}
+		// Commands on d are submitted by fn only, so
+		// the connection is the one which got WATCH.
+		tx := &Tx{Client: d, pin: d.currentConn()}
+		if err := fn(tx); err != nil {
+			if err := d.commandOK(newRequest("*1\r\n$7\r\nUNWATCH\r\n")); err != nil {
+				d.Close()
+			} else {
+				c.checkinDedicated(d)
+			}
+			return err
+		}
+
+		err := tx.Exec()
+		if err == ErrWatch && retry < WatchRetryMax {
+			continue // EXEC cleared the watch state
+		}
+		if _, ok := err.(ServerError); err == nil || ok || err == ErrWatch {
+			c.checkinDedicated(d)
+		} else {
+			d.Close()
+		}
+		return err
+	}
+}
+
+// CheckoutDedicated returns an idle Client from Watch, or a new one with the
+// same configuration as c.
+func (c *Client) checkoutDedicated() *Client {
+	select {
+	case d := <-c.dedicated:
+		return d
+	default:
+		break
+	}
+
+	o := c.options
+	o.ReplicaAddr = ""
+	o.Password, _ = c.password.Load().([]byte)
+	o.DB = atomic.LoadInt64(&c.db)
+	d := newClient(c.Addr, c.commandTimeout, c.dialTimeout, o)
+	if c.sentinel != nil {
+		d.Addr = ""
+		d.sentinel = c.sentinel
+	}
+	if name, ok := c.name.Load().(string); ok {
+		d.name.Store(name)
+	}
+	d.launch()
+	return d
+}
+
+// OnDedicated executes fn on a Client from checkoutDedicated, with the context
+// of c, if any. The Client is retained for reuse, unless fn failed on anything
+// other than a ServerError.
+func (c *Client) onDedicated(fn func(d *Client) error) error {
+	d := c.checkoutDedicated()
+	view := d
+	if c.ctx != nil {
+		view = d.WithContext(c.ctx)
+	}
+	err := fn(view)
+	if _, ok := err.(ServerError); err == nil || ok {
+		c.checkinDedicated(d)
+	} else {
+		d.Close()
+	}
+	return err
+}
+
+// CheckinDedicated retains d for reuse, when there's room.
+func (c *Client) checkinDedicated(d *Client) {
+	select {
+	case c.dedicated <- d:
+		select {
+		case <-c.closed:
+			c.closeDedicated() // lost race
+		default:
+			break
+		}
+	default:
+		d.Close()
+	}
+}
+
+func (c *Client) closeDedicated() {
+	for {
+		select {
+		case d := <-c.dedicated:
+			d.Close()
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package redis
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTx(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test")
+	listKey := randomKey("test-list")
+
+	tx := testClient.Tx()
+	set := tx.Queue("SET", key, "41")
+	incr := tx.Queue("INCR", key)
+	tx.Queue("RPUSH", listKey, "x")
+	wrongType := tx.Queue("GET", listKey)
+	get := tx.Queue("GET", key)
+	if _, err := get.Str(); err == nil {
+		t.Error("result before Exec got no error")
+	}
+	if n := tx.Len(); n != 5 {
+		t.Errorf("got length %d, want 5", n)
+	}
+	if err := tx.Exec(); err != nil {
+		t.Fatal("Exec error:", err)
+	}
+	if tx.Len() != 0 {
+		t.Error("Tx not empty after Exec")
+	}
+
+	if v, err := set.Str(); err != nil || v != "OK" {
+		t.Errorf("SET got %q, %v; want OK", v, err)
+	}
+	if v, err := incr.Int(); err != nil || v != 42 {
+		t.Errorf("INCR got %d, %v; want 42", v, err)
+	}
+	if err := wrongType.Err(); !errors.Is(err, ErrWrongType) {
+		t.Errorf("GET on list got error %v, want a WRONGTYPE", err)
+	}
+	if v, err := get.Bytes(); err != nil || string(v) != "42" {
+		t.Errorf("GET got %q, %v; want 42", v, err)
+	}
+}
+
+func TestTxAbort(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test")
+
+	tx := testClient.Tx()
+	set := tx.Queue("SET", key, "v")
+	bogus := tx.Queue("NOSUCHCOMMAND", key)
+	err := tx.Exec()
+	if !errors.Is(err, ErrExecAbort) {
+		t.Fatalf("Exec got error %v, want an EXECABORT", err)
+	}
+	if err := set.Err(); !errors.Is(err, ErrExecAbort) {
+		t.Errorf("SET got error %v, want an EXECABORT", err)
+	}
+	if err := bogus.Err(); err == nil || errors.Is(err, ErrExecAbort) {
+		t.Errorf("unknown command got error %v, want the queueing error", err)
+	}
+	if n, err := testClient.EXISTS(key); err != nil {
+		t.Error("EXISTS error:", err)
+	} else if n != 0 {
+		t.Error("SET executed in aborted transaction")
+	}
+
+	// client side rejection
+	tx.Queue("SUBSCRIBE", "ch")
+	if err := tx.Exec(); err != ErrSubscribeMode {
+		t.Errorf("Exec with SUBSCRIBE got error %v, want ErrSubscribeMode", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+	key := randomKey("test")
+
+	// testClient may have another database selected
+	c := NewClient(testClient.Addr, time.Second, 0)
+	defer c.Close()
+
+	var runs int
+	err := c.Watch([]string{key}, func(tx *Tx) error {
+		runs++
+		v, err := tx.Client.GET(key)
+		if err != nil {
+			return err
+		}
+		if runs == 1 {
+			// concurrent modification
+			if err := c.SET(key, []byte("7")); err != nil {
+				return err
+			}
+		}
+		n, _ := strconv.ParseInt(string(v), 10, 64)
+		tx.Queue("SET", key, n*2)
+		return nil
+	})
+	if err != nil {
+		t.Fatal("Watch error:", err)
+	}
+	if runs != 2 {
+		t.Errorf("got %d runs, want 2", runs)
+	}
+	if v, err := c.GET(key); err != nil {
+		t.Error("GET error:", err)
+	} else if string(v) != "14" {
+		t.Errorf("got %q, want 14", v)
+	}
+
+	// conflict on each attempt
+	runs = 0
+	err = c.Watch([]string{key}, func(tx *Tx) error {
+		runs++
+		tx.Queue("INCR", key)
+		return c.SET(key, []byte("0"))
+	})
+	if err != ErrWatch {
+		t.Errorf("Watch with conflicts got error %v, want ErrWatch", err)
+	}
+	if runs != WatchRetryMax+1 {
+		t.Errorf("got %d runs, want %d", runs, WatchRetryMax+1)
+	}
+
+	// cancel
+	cancel := errors.New("test cancel")
+	if err := c.Watch([]string{key}, func(tx *Tx) error {
+		tx.Queue("DEL", key)
+		return cancel
+	}); err != cancel {
+		t.Errorf("Watch got error %v, want the cancel error of fn", err)
+	}
+	if n, err := c.EXISTS(key); err != nil || n != 1 {
+		t.Errorf("EXISTS after cancel got %d, %v; want 1", n, err)
+	}
+}
+
+func TestWatchConnLoss(t *testing.T) {
+	t.Parallel()
+	server, counts := newDropServerOn(t, 1, "GET")
+	defer server.Close()
+	c := NewClientWithOptions(server.Addr().String(), time.Second, 0, ClientOptions{ReadRetries: 1})
+	defer c.Close()
+
+	err := c.Watch([]string{"k"}, func(tx *Tx) error {
+		// retry on a new connection, without WATCH
+		if _, err := tx.Client.GET("k"); err != nil {
+			return err
+		}
+		tx.Queue("SET", "k", "v")
+		return nil
+	})
+	if err != ErrConnLost {
+		t.Errorf("Watch with connection loss got error %v, want ErrConnLost", err)
+	}
+	if n := counts("GET"); n != 2 {
+		t.Errorf("GET executed %d times, want 2 (one retry)", n)
+	}
+	if n := counts("MULTI") + counts("EXEC"); n != 0 {
+		t.Errorf("got %d MULTI and EXEC commands, want none", n)
+	}
+}